@@ -0,0 +1,113 @@
+// Package apicoretest provides helpers for testing handlers built on apicore:
+// building JSON requests, running them through a handler, and asserting on
+// responses and APIErrors without hand-decoding recorder bodies.
+//
+//	req := apicoretest.NewJSONRequest(http.MethodPost, "/users", map[string]string{"email": ""})
+//	resp := apicoretest.DoHandler(r, req)
+//	apicoretest.RequireAPIError(t, resp, http.StatusUnprocessableEntity, "validation")
+package apicoretest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// NewJSONRequest returns a server request for method and target with body
+// encoded as JSON. A nil body sends none; a string or []byte is sent as is.
+// Content-Type and Accept are set to application/json. It panics when body
+// cannot be encoded, like httptest.NewRequest on invalid input.
+func NewJSONRequest(method, target string, body any) *http.Request {
+	var r io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		r = bytes.NewBufferString(b)
+	case []byte:
+		r = bytes.NewBuffer(b)
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			panic("apicoretest: encoding request body: " + err.Error())
+		}
+		r = bytes.NewBuffer(data)
+	}
+
+	req := httptest.NewRequest(method, target, r)
+	if r != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	return req
+}
+
+// Response is a response captured by DoHandler.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// DoHandler serves req with h and captures the response.
+func DoHandler(h http.Handler, req *http.Request) *Response {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return &Response{StatusCode: w.Code, Header: w.Result().Header, Body: w.Body.Bytes()}
+}
+
+// Decode unmarshals the JSON body into v, failing the test when it is not
+// valid JSON for v.
+func (r *Response) Decode(t testing.TB, v any) {
+	t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		t.Fatalf("Failed to decode response body %q: %v", r.Body, err)
+	}
+}
+
+// DecodeJSON returns the JSON body decoded as T, e.g.
+//
+//	user := apicoretest.DecodeJSON[User](t, resp)
+func DecodeJSON[T any](t testing.TB, r *Response) T {
+	t.Helper()
+	var v T
+	r.Decode(t, &v)
+	return v
+}
+
+// RequireStatus fails the test unless the response has status.
+func RequireStatus(t testing.TB, r *Response, status int) {
+	t.Helper()
+	if r.StatusCode != status {
+		t.Fatalf("Expected status %d, got %d: %s", status, r.StatusCode, r.Body)
+	}
+}
+
+// RequireJSON fails the test unless the response has status and a JSON body,
+// and returns the body decoded as T.
+func RequireJSON[T any](t testing.TB, r *Response, status int) T {
+	t.Helper()
+	RequireStatus(t, r, status)
+	return DecodeJSON[T](t, r)
+}
+
+// RequireAPIError fails the test unless the response is an APIError with
+// status and errType, e.g. (422, "validation"), and returns it for further
+// checks on Message and Details.
+func RequireAPIError(t testing.TB, r *Response, status int, errType string) *apierr.APIError {
+	t.Helper()
+	RequireStatus(t, r, status)
+
+	var apiErr apierr.APIError
+	if err := json.Unmarshal(r.Body, &apiErr); err != nil {
+		t.Fatalf("Expected an APIError body, got %q: %v", r.Body, err)
+	}
+	if apiErr.StatusCode != status || apiErr.Type != errType {
+		t.Fatalf("Expected APIError %d %q, got %d %q: %s", status, errType, apiErr.StatusCode, apiErr.Type, r.Body)
+	}
+	return &apiErr
+}
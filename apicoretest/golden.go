@@ -0,0 +1,143 @@
+package apicoretest
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// UpdateEnv is the environment variable that makes Golden rewrite golden
+// files with the current responses instead of comparing against them.
+const UpdateEnv = "APICORETEST_UPDATE"
+
+// GoldenOption normalizes a response before it is compared with a golden file.
+type GoldenOption func(*golden)
+
+type golden struct {
+	keys     []string
+	patterns []replacement
+	funcs    []func([]byte) []byte
+}
+
+type replacement struct {
+	re   *regexp.Regexp
+	repl string
+}
+
+// MaskKeys replaces the values of these JSON object keys, at any depth, with
+// "<masked>", e.g. MaskKeys("id", "created_at") for generated IDs and times.
+func MaskKeys(keys ...string) GoldenOption {
+	return func(g *golden) {
+		g.keys = append(g.keys, keys...)
+	}
+}
+
+// MaskPattern replaces every match of re in the normalized body with repl.
+func MaskPattern(re *regexp.Regexp, repl string) GoldenOption {
+	return func(g *golden) {
+		g.patterns = append(g.patterns, replacement{re: re, repl: repl})
+	}
+}
+
+// rfc3339 matches RFC 3339 timestamps such as "2025-10-15T13:55:36.123Z".
+var rfc3339 = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`)
+
+// MaskTimestamps replaces RFC 3339 timestamps with "<timestamp>".
+func MaskTimestamps() GoldenOption {
+	return MaskPattern(rfc3339, "<timestamp>")
+}
+
+// Normalize applies fn to the normalized body, for anything the other options
+// do not cover.
+func Normalize(fn func(body []byte) []byte) GoldenOption {
+	return func(g *golden) {
+		g.funcs = append(g.funcs, fn)
+	}
+}
+
+// Golden compares the response body with the golden file at path, e.g.
+// "testdata/create_user.json", failing the test with both versions when they
+// differ. JSON bodies are indented with sorted keys so files diff well;
+// masking happens before comparison. Set APICORETEST_UPDATE=1 to write the
+// current bodies to the files instead:
+//
+//	APICORETEST_UPDATE=1 go test ./...
+func Golden(t testing.TB, r *Response, path string, opts ...GoldenOption) {
+	t.Helper()
+
+	var g golden
+	for _, opt := range opts {
+		opt(&g)
+	}
+	got := g.normalize(r.Body)
+
+	if update, _ := strconv.ParseBool(os.Getenv(UpdateEnv)); update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("Failed to write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file %s (set APICORETEST_UPDATE=1 to create it): %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Response differs from %s (set APICORETEST_UPDATE=1 to accept it)\n--- want\n%s\n--- got\n%s", path, want, got)
+	}
+}
+
+// normalize re-indents JSON bodies with masked keys, then applies the patterns
+// and functions in order.
+func (g *golden) normalize(body []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err == nil && !dec.More() {
+		// Encoding a map sorts its keys, so field order changes don't churn files.
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if enc.Encode(maskKeys(v, g.keys)) == nil {
+			body = buf.Bytes()
+		}
+	}
+	for _, p := range g.patterns {
+		body = p.re.ReplaceAll(body, []byte(p.repl))
+	}
+	for _, fn := range g.funcs {
+		body = fn(body)
+	}
+	return body
+}
+
+// maskKeys replaces the values of keys in every object within v.
+func maskKeys(v any, keys []string) any {
+	if len(keys) == 0 {
+		return v
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if slices.Contains(keys, k) {
+				v[k] = "<masked>"
+			} else {
+				v[k] = maskKeys(val, keys)
+			}
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = maskKeys(val, keys)
+		}
+	}
+	return v
+}
@@ -0,0 +1,213 @@
+package apicoretest
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+// ServerOptions configures NewServer.
+type ServerOptions struct {
+	// Middleware wraps the handler inside the standard stack, e.g. the
+	// authentication the tested routes expect.
+	Middleware []middleware.Middleware
+	// Logger configures the request logger. Logger, Sinks, Format and
+	// Histograms are replaced by the Server's captures.
+	Logger middleware.LoggerOptions
+}
+
+// Server is an httptest.Server running a handler behind the standard
+// middleware stack (RequestID, InjectLogger, RequestLogger, Recover), for
+// black-box tests of endpoints through the real middleware chain. Logs of the
+// request logger, of Recover and of handlers using middleware.Log are captured
+// instead of printed, and request latencies are recorded in histograms:
+//
+//	srv := apicoretest.NewServer(r, apicoretest.ServerOptions{})
+//	defer srv.Close()
+//	resp := srv.Do(t, apicoretest.NewJSONRequest(http.MethodGet, "/users/1", nil))
+//	apicoretest.RequireStatus(t, resp, http.StatusOK)
+//	if len(srv.Logs()) != 1 { ... }
+type Server struct {
+	*httptest.Server
+
+	logs       *logStore
+	histograms *middleware.LatencyHistograms
+
+	// active counts requests whose handlers are still running, so Do can
+	// wait for the request logger, which logs after the response is sent.
+	mu     sync.Mutex
+	idle   *sync.Cond
+	active int
+}
+
+// NewServer starts a Server serving h.
+func NewServer(h http.Handler, opts ServerOptions) *Server {
+	s := &Server{logs: &logStore{}, histograms: middleware.NewLatencyHistograms()}
+	s.idle = sync.NewCond(&s.mu)
+	logger := slog.New(&captureHandler{store: s.logs})
+
+	logOpts := opts.Logger
+	logOpts.Logger = logger
+	logOpts.Sinks = nil
+	logOpts.Format = middleware.FormatSlog
+	logOpts.Histograms = s.histograms
+
+	stack := []middleware.Middleware{
+		s.track,
+		middleware.RequestID,
+		middleware.InjectLogger(logger),
+		middleware.RequestLoggerWith(logOpts),
+		middleware.RecoverWithOptions(middleware.RecoverOptions{Logger: logger}),
+	}
+	s.Server = httptest.NewServer(middleware.Chain(h, append(stack, opts.Middleware...)...))
+	return s
+}
+
+// track maintains s.active around the rest of the chain.
+func (s *Server) track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.active++
+		s.mu.Unlock()
+		defer func() {
+			s.mu.Lock()
+			s.active--
+			s.idle.Broadcast()
+			s.mu.Unlock()
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Do sends req, which may be built with NewJSONRequest or httptest.NewRequest,
+// to the server and captures the response. Its path is resolved against the
+// server URL. It returns once every request in flight has finished, so their
+// logs and metrics are recorded. Transport errors fail the test.
+func (s *Server) Do(t testing.TB, req *http.Request) *Response {
+	t.Helper()
+
+	out := req.Clone(req.Context())
+	out.RequestURI = ""
+	parsed, err := url.Parse(s.URL + req.URL.RequestURI())
+	if err != nil {
+		t.Fatalf("Failed to build request URL: %v", err)
+	}
+	out.URL = parsed
+	out.Host = parsed.Host
+
+	resp, err := s.Client().Do(out)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %v", err)
+	}
+
+	s.mu.Lock()
+	for s.active > 0 {
+		s.idle.Wait()
+	}
+	s.mu.Unlock()
+	return &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}
+}
+
+// LogEntry is a captured log record. Attrs are keyed by name, with groups
+// joined by dots, e.g. "request.id".
+type LogEntry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   map[string]any
+}
+
+// Logs returns the records logged so far, in order.
+func (s *Server) Logs() []LogEntry {
+	s.logs.mu.Lock()
+	defer s.logs.mu.Unlock()
+	return append([]LogEntry(nil), s.logs.entries...)
+}
+
+// ResetLogs discards the captured records.
+func (s *Server) ResetLogs() {
+	s.logs.mu.Lock()
+	defer s.logs.mu.Unlock()
+	s.logs.entries = nil
+}
+
+// Histograms returns the request latency histograms by route and status class.
+func (s *Server) Histograms() []middleware.HistogramSnapshot {
+	return s.histograms.Snapshot()
+}
+
+type logStore struct {
+	mu      sync.Mutex
+	entries []LogEntry
+}
+
+// captureHandler is a slog.Handler appending records to a logStore.
+type captureHandler struct {
+	store  *logStore
+	attrs  []slog.Attr
+	prefix string
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	entry := LogEntry{Time: r.Time, Level: r.Level, Message: r.Message, Attrs: make(map[string]any)}
+	for _, a := range h.attrs {
+		addAttr(entry.Attrs, "", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(entry.Attrs, h.prefix, a)
+		return true
+	})
+
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+	h.store.entries = append(h.store.entries, entry)
+	return nil
+}
+
+func (h *captureHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	scoped := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		scoped[i] = slog.Attr{Key: h.prefix + a.Key, Value: a.Value}
+	}
+	return &captureHandler{store: h.store, attrs: append(append([]slog.Attr(nil), h.attrs...), scoped...), prefix: h.prefix}
+}
+
+func (h *captureHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &captureHandler{store: h.store, attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+// addAttr stores a under prefix, flattening groups.
+func addAttr(attrs map[string]any, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			addAttr(attrs, prefix, ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	attrs[prefix+a.Key] = v.Any()
+}
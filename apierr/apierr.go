@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"reflect"
 	"strings"
+	"sync/atomic"
 )
 
 type contextKey string
@@ -18,11 +19,35 @@ type contextKey string
 // OriginalErrorContextKey is the key for storing the original error in request context.
 const OriginalErrorContextKey contextKey = "OriginalError"
 
+var logger atomic.Pointer[slog.Logger]
+
+// SetLogger sets the logger MapError reports unmapped errors to, instead of
+// slog.Default(). A nil logger restores the default.
+func SetLogger(l *slog.Logger) {
+	logger.Store(l)
+}
+
+func currentLogger() *slog.Logger {
+	if l := logger.Load(); l != nil {
+		return l
+	}
+	return slog.Default()
+}
+
 // APIError represents an API error with HTTP status code, type, and message.
 type APIError struct {
 	StatusCode int    `json:"status"` // HTTP status code
 	Type       string `json:"type"`
 	Message    any    `json:"msg"` // Support various message types
+	// Details carries optional per-field guidance, e.g. documentation links
+	// for validation failures.
+	Details map[string]FieldDetail `json:"details,omitempty"`
+}
+
+// FieldDetail points client developers at how to fix a failing field.
+type FieldDetail struct {
+	DocURL string `json:"docs,omitempty"`
+	Hint   string `json:"hint,omitempty"`
 }
 
 func (e *APIError) Error() string {
@@ -58,11 +83,28 @@ func NewError(code int, errtype string, message any) *APIError {
 }
 
 // MapError converts various error types to APIError with appropriate HTTP status codes and messages.
+// Every result is counted in ErrorTypes, and validation failures in ValidationFailures.
 func MapError(err error, r *http.Request) *APIError {
 	if err == nil {
 		return nil
 	}
 
+	apiErr := mapError(err, r)
+	ErrorTypes.Add(apiErr.Type, 1)
+	return apiErr
+}
+
+// MapNested converts err like MapError without counting it in ErrorTypes, for
+// As methods mapping the errors nested in an error that MapError counts once
+// as a whole, such as the items of a bulk request.
+func MapNested(err error) *APIError {
+	if err == nil {
+		return nil
+	}
+	return mapError(err, nil)
+}
+
+func mapError(err error, r *http.Request) *APIError {
 	// Store the original error in context for RequestLogger
 	// It will log the metadata
 	if r != nil {
@@ -75,6 +117,11 @@ func MapError(err error, r *http.Request) *APIError {
 		return apiErr
 	}
 
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return NewError(413, "payload_too_large", fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit))
+	}
+
 	var syntaxErr *json.SyntaxError
 	var unmarshalErr *json.UnmarshalTypeError
 	if errors.As(err, &syntaxErr) || errors.As(err, &unmarshalErr) {
@@ -88,8 +135,11 @@ func MapError(err error, r *http.Request) *APIError {
 	if errVal := reflect.ValueOf(err); errVal.Kind() == reflect.Slice && errVal.Len() > 0 {
 		// Check if the first element has Field and Tag methods
 		if elem := errVal.Index(0); elem.MethodByName("Field").IsValid() && elem.MethodByName("Tag").IsValid() {
+			countValidationFailures(err)
 			formattedErrors := formatValidationErrors(err)
-			return NewError(422, "validation", formattedErrors)
+			apiErr := NewError(422, "validation", formattedErrors)
+			apiErr.Details = validationDetails(err)
+			return apiErr
 		}
 	}
 
@@ -101,7 +151,7 @@ func MapError(err error, r *http.Request) *APIError {
 		return NewError(504, "canceled", "request timeout")
 	}
 
-	slog.With("error", err).Error("Error missed mappers!")
+	currentLogger().With("error", err).Error("Error missed mappers!")
 	return NewError(500, "internal", "internal server error")
 }
 
@@ -131,9 +181,49 @@ func formatValidationErrors(err error) map[string]string {
 		if len(fieldResult) > 0 && len(tagResult) > 0 {
 			fieldName := strings.ToLower(fieldResult[0].String())
 			tag := tagResult[0].String()
+
+			// Prefer a translated message when the validator provides one
+			if messageMethod := elem.MethodByName("Message"); messageMethod.IsValid() && messageMethod.Type().NumIn() == 0 {
+				if msg := messageMethod.Call(nil); len(msg) == 1 && msg[0].Kind() == reflect.String && msg[0].String() != "" {
+					tag = msg[0].String()
+				}
+			}
 			formattedErrors[fieldName] = tag
 		}
 	}
 
 	return formattedErrors
 }
+
+// validationDetails collects DocURL and Hint from validation errors that
+// provide them, keyed like formatValidationErrors. It returns nil when none do.
+func validationDetails(err error) map[string]FieldDetail {
+	var details map[string]FieldDetail
+
+	errVal := reflect.ValueOf(err)
+	for i := 0; i < errVal.Len(); i++ {
+		elem := errVal.Index(i)
+		detail := FieldDetail{
+			DocURL: callString(elem, "DocURL"),
+			Hint:   callString(elem, "Hint"),
+		}
+		if detail == (FieldDetail{}) {
+			continue
+		}
+		if details == nil {
+			details = make(map[string]FieldDetail)
+		}
+		details[strings.ToLower(callString(elem, "Field"))] = detail
+	}
+
+	return details
+}
+
+// callString calls the niladic string method name on v, returning "" when v has none.
+func callString(v reflect.Value, name string) string {
+	method := v.MethodByName(name)
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 || method.Type().Out(0).Kind() != reflect.String {
+		return ""
+	}
+	return method.Call(nil)[0].String()
+}
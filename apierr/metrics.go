@@ -0,0 +1,31 @@
+package apierr
+
+import (
+	"expvar"
+	"reflect"
+	"strings"
+)
+
+// ErrorTypes counts the errors mapped by MapError by APIError type, e.g.
+// "validation" or "not_found". It is published with expvar as "apierr.types",
+// so it is served by router.Debug under /debug/vars.
+var ErrorTypes = expvar.NewMap("apierr.types")
+
+// ValidationFailures counts failed validation rules by field and rule, e.g.
+// "email.required", showing which inputs most often reject real users. It is
+// published with expvar as "apierr.validation_failures".
+var ValidationFailures = expvar.NewMap("apierr.validation_failures")
+
+// countValidationFailures adds each failed rule of a validation error to
+// ValidationFailures, keyed by the field name used in the 422 response.
+func countValidationFailures(err error) {
+	errVal := reflect.ValueOf(err)
+	for i := 0; i < errVal.Len(); i++ {
+		elem := errVal.Index(i)
+		field, tag := callString(elem, "Field"), callString(elem, "Tag")
+		if field == "" || tag == "" {
+			continue
+		}
+		ValidationFailures.Add(strings.ToLower(field)+"."+tag, 1)
+	}
+}
@@ -0,0 +1,184 @@
+// Package bind decodes and validates request input, returning errors that
+// apierr.MapError maps to 400, 413 and 422 responses. After decoding, every
+// binder normalizes fields tagged `mod:"..."`, fills zero-valued fields tagged
+// `default:"..."`, then validates.
+package bind
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/validate"
+)
+
+// DefaultMaxBytes is the largest request body JSON and Form read by default.
+const DefaultMaxBytes int64 = 1 << 20
+
+// Option configures a bind call.
+type Option func(*config)
+
+// Validator validates a decoded struct. *validate.Validator implements it, as
+// does go-playground's *validator.Validate, so services can keep their
+// existing validator, rules and translations. Errors shaped like
+// validate.Errors, a slice of values with Field and Tag methods, map to 422.
+type Validator interface {
+	StructCtx(ctx context.Context, s any) error
+}
+
+type config struct {
+	validator   Validator
+	strict      bool
+	cookieCodec CookieCodec
+	maxBytes    int64
+	scenario    string
+}
+
+// Scenario validates with the rules for scenario, e.g. "create" or "update".
+// See validate.WithScenario for the tag syntax.
+func Scenario(name string) Option {
+	return func(c *config) {
+		c.scenario = name
+	}
+}
+
+// MaxBytes limits the request body to n bytes; larger bodies are a 413
+// APIError typed "payload_too_large". Without it, binders use the limit set by
+// middleware.MaxBody for the route, or DefaultMaxBytes (DefaultMultipartMaxBytes
+// for Multipart). The limit stays on r.Body, so it also holds for handlers
+// that read the body after binding.
+func MaxBytes(n int64) Option {
+	return func(c *config) {
+		c.maxBytes = n
+	}
+}
+
+// Strict rejects JSON bodies containing fields dst does not declare with a
+// 400 APIError typed "unknown_field" naming the field, so client typos fail
+// loudly instead of being silently ignored.
+func Strict() Option {
+	return func(c *config) {
+		c.strict = true
+	}
+}
+
+// WithValidator validates with v instead of validate.Default.
+func WithValidator(v Validator) Option {
+	return func(c *config) {
+		c.validator = v
+	}
+}
+
+func newConfig(opts []Option) config {
+	cfg := config{validator: validate.Default}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// limitBody caps r.Body at the configured limit, failing early when the
+// declared Content-Length already exceeds it. A zero limit leaves r.Body as is.
+func (c *config) limitBody(r *http.Request, defaultLimit int64) error {
+	n := c.maxBytes
+	if n == 0 {
+		n = defaultLimit
+		if limit, ok := middleware.GetMaxBody(r.Context()); ok {
+			n = limit
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	if r.ContentLength > n {
+		return &http.MaxBytesError{Limit: n}
+	}
+	r.Body = http.MaxBytesReader(nil, r.Body, n)
+	return nil
+}
+
+// finish runs the steps shared by every binder once dst is decoded:
+// modifiers, defaults, then validation in the client's preferred language.
+func (c *config) finish(r *http.Request, dst any) error {
+	if err := applyModifiers(dst); err != nil {
+		return err
+	}
+	if err := applyDefaults(dst); err != nil {
+		return err
+	}
+
+	ctx := r.Context()
+	if lang := r.Header.Get("Accept-Language"); lang != "" {
+		ctx = validate.WithLocale(ctx, lang)
+	}
+	if c.scenario != "" {
+		ctx = validate.WithScenario(ctx, c.scenario)
+	}
+	return c.validator.StructCtx(ctx, dst)
+}
+
+// JSON decodes the JSON request body into dst, a pointer to a struct, and
+// validates it. The returned error can be passed straight to writeError or
+// returned from a middleware.APIFunc:
+//
+//	var req CreateUserRequest
+//	if err := bind.JSON(r, &req); err != nil {
+//		return err
+//	}
+func JSON(r *http.Request, dst any, opts ...Option) error {
+	cfg := newConfig(opts)
+	if err := cfg.decodeJSON(r, dst); err != nil {
+		return err
+	}
+	return cfg.finish(r, dst)
+}
+
+// decodeJSON decodes the size-limited body into dst, rejecting trailing data.
+func (c *config) decodeJSON(r *http.Request, dst any) error {
+	if err := c.limitBody(r, DefaultMaxBytes); err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(r.Body)
+	if c.strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(dst); err != nil {
+		return unknownFieldError(err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return err
+		}
+		return apierr.NewError(http.StatusBadRequest, "json", "unexpected data after JSON body")
+	}
+	return nil
+}
+
+// unmarshal decodes an already read body into dst, honoring Strict.
+func (c *config) unmarshal(data []byte, dst any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if c.strict {
+		dec.DisallowUnknownFields()
+	}
+	return unknownFieldError(dec.Decode(dst))
+}
+
+// unknownFieldError turns the error DisallowUnknownFields produces into a 400
+// APIError naming the field. encoding/json reports it only as a formatted error.
+func unknownFieldError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return apierr.NewError(http.StatusBadRequest, "unknown_field", "unknown field "+field)
+	}
+	return err
+}
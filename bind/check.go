@@ -0,0 +1,66 @@
+package bind
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Check reports malformed tags in the type of dst, a struct or pointer to
+// struct, so mistakes fail at startup or in tests instead of on every request:
+// unknown `mod` modifiers, malformed `file` tags, and the validation rules
+// checked by the validator when it has a Check method like
+// *validate.Validator. opts select the validator as for the binders.
+//
+//	if err := bind.Check(CreateUserRequest{}); err != nil {
+//		log.Fatal(err)
+//	}
+func Check(dst any, opts ...Option) error {
+	t := reflect.TypeOf(dst)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("bind: expected struct, got %T", dst)
+	}
+	if err := checkTags(t, make(map[reflect.Type]bool)); err != nil {
+		return err
+	}
+	if _, err := fileFields(t); err != nil {
+		return err
+	}
+
+	cfg := newConfig(opts)
+	if c, ok := cfg.validator.(interface{ Check(any) error }); ok {
+		return c.Check(dst)
+	}
+	return nil
+}
+
+// checkTags checks the bind tags of struct type t and the structs it contains.
+func checkTags(t reflect.Type, seen map[reflect.Type]bool) error {
+	if seen[t] || t.PkgPath() == "time" {
+		return nil
+	}
+	seen[t] = true
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if _, err := parseModifiers(f); err != nil {
+			return err
+		}
+
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer || ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			if err := checkTags(ft, seen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
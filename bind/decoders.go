@@ -0,0 +1,70 @@
+package bind
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+type decoder struct {
+	name   string
+	decode func(s string) (reflect.Value, error)
+}
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = make(map[reflect.Type]decoder)
+)
+
+// RegisterDecoder makes path, query, form, cookie and header binding parse
+// fields of type T with decode. name describes the expected format in 400
+// errors, e.g. `expected decimal, got "1,5"`. It takes precedence over
+// encoding.TextUnmarshaler and is safe to call from init:
+//
+//	bind.RegisterDecoder("decimal", decimal.NewFromString)
+//	bind.RegisterDecoder("ULID", ulid.ParseStrict)
+func RegisterDecoder[T any](name string, decode func(s string) (T, error)) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[reflect.TypeFor[T]()] = decoder{name: name, decode: func(s string) (reflect.Value, error) {
+		v, err := decode(s)
+		return reflect.ValueOf(v), err
+	}}
+}
+
+func lookupDecoder(t reflect.Type) (decoder, bool) {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	d, ok := decoders[t]
+	return d, ok
+}
+
+// decodeRegistered sets fv with its registered decoder, reporting false when
+// its type has none.
+func decodeRegistered(fv reflect.Value, s string) (bool, error) {
+	d, ok := lookupDecoder(fv.Type())
+	if !ok {
+		return false, nil
+	}
+	v, err := d.decode(s)
+	if err != nil {
+		return true, fmt.Errorf("expected %s, got %q", d.name, s)
+	}
+	fv.Set(v)
+	return true, nil
+}
+
+func init() {
+	RegisterDecoder("duration", time.ParseDuration)
+	RegisterDecoder("date or RFC 3339 time", parseTime)
+}
+
+// parseTime accepts RFC 3339 timestamps and civil dates such as 2024-05-31,
+// which are read as midnight UTC.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,56 @@
+package bind
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// applyDefaults sets zero-valued fields tagged `default:"value"` to value,
+// parsed like a query parameter. Slice defaults are comma separated.
+// Nested and embedded structs are descended into.
+//
+//	type ListParams struct {
+//		PerPage int      `query:"per_page" default:"20" validate:"max=100"`
+//		Sort    string   `query:"sort" default:"created_at"`
+//		States  []string `query:"state" default:"open,pending"`
+//	}
+func applyDefaults(dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return defaultStruct(rv.Elem())
+}
+
+func defaultStruct(rv reflect.Value) error {
+	t := rv.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		fv := rv.Field(i)
+
+		if def, ok := f.Tag.Lookup("default"); ok {
+			if !fv.IsZero() {
+				continue
+			}
+			values := []string{def}
+			if fv.Kind() == reflect.Slice {
+				values = strings.Split(def, ",")
+			}
+			if err := setField(fv, values); err != nil {
+				return fmt.Errorf("bind: invalid default for %s: %w", f.Name, err)
+			}
+			continue
+		}
+
+		if fv.Kind() == reflect.Struct && fv.Type().PkgPath() != "time" {
+			if err := defaultStruct(fv); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
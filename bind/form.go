@@ -0,0 +1,52 @@
+package bind
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// Form sets the fields of dst tagged `form:"name"` from an
+// application/x-www-form-urlencoded request body, then validates dst, so
+// OAuth token endpoints and HTML form posts bind like JSON bodies. Fields
+// take the first value for their name, slices every value. Other content
+// types are a 415 APIError; values that do not parse are a 400 typed "invalid_form".
+//
+//	var req struct {
+//		GrantType string   `form:"grant_type" validate:"required,oneof=client_credentials refresh_token"`
+//		Scope     []string `form:"scope"`
+//	}
+func Form(r *http.Request, dst any, opts ...Option) error {
+	cfg := newConfig(opts)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" {
+		return apierr.NewError(http.StatusUnsupportedMediaType, "unsupported_media_type",
+			"content type must be application/x-www-form-urlencoded")
+	}
+	if err := cfg.decodeForm(r, dst); err != nil {
+		return err
+	}
+
+	return cfg.finish(r, dst)
+}
+
+// decodeForm parses the size-limited urlencoded body into dst's form fields.
+func (c *config) decodeForm(r *http.Request, dst any) error {
+	if err := c.limitBody(r, DefaultMaxBytes); err != nil {
+		return err
+	}
+	if err := r.ParseForm(); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return err
+		}
+		return apierr.NewError(http.StatusBadRequest, "invalid_form", "malformed form body")
+	}
+
+	return bindValues(dst, "form", "invalid_form", func(name, _ string) ([]string, error) {
+		return r.PostForm[name], nil
+	})
+}
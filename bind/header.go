@@ -0,0 +1,28 @@
+package bind
+
+import "net/http"
+
+// Header sets the fields of dst tagged `header:"Name"` from the request
+// headers, then applies defaults and validates dst. Names are matched
+// case-insensitively; slices take every value. Values that do not parse are a
+// 400 APIError typed "invalid_header".
+//
+//	var h struct {
+//		IdempotencyKey string        `header:"Idempotency-Key" validate:"required"`
+//		Timeout        time.Duration `header:"X-Timeout" default:"30s"`
+//	}
+func Header(r *http.Request, dst any, opts ...Option) error {
+	cfg := newConfig(opts)
+
+	if err := bindValues(dst, "header", "invalid_header", headerValues(r)); err != nil {
+		return err
+	}
+
+	return cfg.finish(r, dst)
+}
+
+func headerValues(r *http.Request) lookupFunc {
+	return func(name, _ string) ([]string, error) {
+		return r.Header.Values(name), nil
+	}
+}
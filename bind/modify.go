@@ -0,0 +1,121 @@
+package bind
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+var (
+	modifiersMu sync.RWMutex
+	modifiers   = map[string]func(string) string{
+		"trim":  strings.TrimSpace,
+		"ltrim": func(s string) string { return strings.TrimLeftFunc(s, unicode.IsSpace) },
+		"rtrim": func(s string) string { return strings.TrimRightFunc(s, unicode.IsSpace) },
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+		// squash collapses runs of whitespace into single spaces.
+		"squash": func(s string) string { return strings.Join(strings.Fields(s), " ") },
+		// strip_ctrl removes control characters such as NUL and escape codes,
+		// keeping tabs and newlines.
+		"strip_ctrl": func(s string) string {
+			return strings.Map(func(r rune) rune {
+				if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+					return -1
+				}
+				return r
+			}, s)
+		},
+	}
+)
+
+// RegisterModifier adds or replaces the `mod` tag modifier called name.
+func RegisterModifier(name string, fn func(string) string) {
+	modifiersMu.Lock()
+	defer modifiersMu.Unlock()
+	modifiers[name] = fn
+}
+
+func lookupModifier(name string) (func(string) string, bool) {
+	modifiersMu.RLock()
+	defer modifiersMu.RUnlock()
+	fn, ok := modifiers[name]
+	return fn, ok
+}
+
+// parseModifiers returns the modifiers listed in the `mod` tag of f.
+func parseModifiers(f reflect.StructField) ([]func(string) string, error) {
+	tag := f.Tag.Get("mod")
+	if tag == "" {
+		return nil, nil
+	}
+	var mods []func(string) string
+	for name := range strings.SplitSeq(tag, ",") {
+		fn, ok := lookupModifier(name)
+		if !ok {
+			return nil, fmt.Errorf("bind: unknown modifier %q on %s", name, f.Name)
+		}
+		mods = append(mods, fn)
+	}
+	return mods, nil
+}
+
+// applyModifiers normalizes string fields tagged `mod:"..."` with the listed
+// modifiers in order, before defaults and validation, so normalization is
+// uniform rather than repeated in handlers:
+//
+//	type SignupRequest struct {
+//		Email string `json:"email" mod:"trim,lower" validate:"required,email"`
+//		Name  string `json:"name" mod:"strip_ctrl,squash"`
+//	}
+//
+// Modifiers apply to string, *string and []string fields. Nested structs are
+// descended into. An unknown modifier is an error, reported by Check at startup.
+func applyModifiers(dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return nil
+	}
+	return modifyValue(rv.Elem(), nil)
+}
+
+func modifyValue(v reflect.Value, mods []func(string) string) error {
+	switch v.Kind() {
+	case reflect.String:
+		if len(mods) > 0 && v.CanSet() {
+			s := v.String()
+			for _, mod := range mods {
+				s = mod(s)
+			}
+			v.SetString(s)
+		}
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			return modifyValue(v.Elem(), mods)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			if err := modifyValue(v.Index(i), mods); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			fieldMods, err := parseModifiers(f)
+			if err != nil {
+				return err
+			}
+			if err := modifyValue(v.Field(i), fieldMods); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,406 @@
+package bind
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+const (
+	// DefaultMultipartMaxBytes is the largest multipart body Multipart reads by default.
+	DefaultMultipartMaxBytes int64 = 32 << 20
+	// multipartMemory is how much of a multipart body is buffered in memory
+	// before file parts spill to temporary files.
+	multipartMemory int64 = 8 << 20
+)
+
+// sniffLen is how many leading bytes are inspected to detect a file's type.
+const sniffLen = 512
+
+// FileLimits constrains uploaded files.
+type FileLimits struct {
+	// MaxSize is the largest accepted file in bytes. Zero means no per-file limit.
+	MaxSize int64
+	// Types lists accepted content types, sniffed from the file's leading bytes
+	// rather than trusted from the client. Entries may end in "/*", e.g. "image/*".
+	// Empty accepts any type.
+	Types []string
+	// MaxCount is the most files accepted for one field, or per request for
+	// Parts. Zero means no limit.
+	MaxCount int
+}
+
+// UploadedFile is a file part bound by Multipart.
+type UploadedFile struct {
+	Filename string
+	Size     int64
+	// ContentType is sniffed from the file's content with http.DetectContentType.
+	ContentType string
+
+	header *multipart.FileHeader
+}
+
+// Open opens the file's content, which may be in memory or a temporary file.
+func (f *UploadedFile) Open() (multipart.File, error) {
+	return f.header.Open()
+}
+
+var (
+	uploadedFileType      = reflect.TypeFor[*UploadedFile]()
+	uploadedFileSliceType = reflect.TypeFor[[]*UploadedFile]()
+)
+
+// Multipart binds a multipart/form-data request into dst, then validates it.
+// Fields tagged `form:"name"` are set from text parts like Form does. Fields of
+// type *UploadedFile or []*UploadedFile tagged `file:"name"` receive file
+// parts, with optional limits after the name:
+//
+//	var req struct {
+//		Title  string               `form:"title" validate:"required"`
+//		Avatar *bind.UploadedFile   `file:"avatar,size=2MB,types=image/png image/jpeg" validate:"required"`
+//		Docs   []*bind.UploadedFile `file:"docs,size=10MB,types=application/pdf,count=5"`
+//	}
+//
+// Oversized files and too many files are 413 APIErrors; files whose sniffed
+// type is not allowed are 415. The whole body is limited to DefaultMultipartMaxBytes
+// unless MaxBytes or middleware.MaxBody set another limit.
+// Use Parts to stream large uploads without buffering them.
+func Multipart(r *http.Request, dst any, opts ...Option) error {
+	cfg := newConfig(opts)
+
+	if err := checkMultipart(r); err != nil {
+		return err
+	}
+
+	if err := cfg.limitBody(r, DefaultMultipartMaxBytes); err != nil {
+		return err
+	}
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return err
+		}
+		return apierr.NewError(http.StatusBadRequest, "invalid_multipart", "malformed multipart body")
+	}
+
+	err := bindValues(dst, "form", "invalid_form", func(name, _ string) ([]string, error) {
+		return r.MultipartForm.Value[name], nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := bindFiles(reflect.ValueOf(dst).Elem(), r.MultipartForm.File); err != nil {
+		return err
+	}
+
+	return cfg.finish(r, dst)
+}
+
+func checkMultipart(r *http.Request) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return apierr.NewError(http.StatusUnsupportedMediaType, "unsupported_media_type",
+			"content type must be multipart/form-data")
+	}
+	return nil
+}
+
+func bindFiles(rv reflect.Value, files map[string][]*multipart.FileHeader) error {
+	fields, err := fileFields(rv.Type())
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		headers := files[f.name]
+		if len(headers) == 0 {
+			continue
+		}
+		limits := f.limits
+		if !f.multi && limits.MaxCount == 0 {
+			limits.MaxCount = 1
+		}
+		if limits.MaxCount > 0 && len(headers) > limits.MaxCount {
+			return apierr.NewError(http.StatusRequestEntityTooLarge, "too_many_files",
+				fmt.Sprintf("%s: at most %d files allowed", f.name, limits.MaxCount))
+		}
+
+		uploaded := make([]*UploadedFile, len(headers))
+		for j, h := range headers {
+			file, err := newUploadedFile(f.name, h, limits)
+			if err != nil {
+				return err
+			}
+			uploaded[j] = file
+		}
+
+		if f.multi {
+			rv.FieldByIndex(f.index).Set(reflect.ValueOf(uploaded))
+		} else {
+			rv.FieldByIndex(f.index).Set(reflect.ValueOf(uploaded[0]))
+		}
+	}
+	return nil
+}
+
+// fileField is a struct field tagged `file`, with its parsed tag.
+type fileField struct {
+	index  []int
+	name   string
+	limits FileLimits
+	multi  bool
+}
+
+type fileFieldsResult struct {
+	fields []fileField
+	err    error
+}
+
+// fileFieldCache holds the parsed file fields of each struct type.
+var fileFieldCache sync.Map
+
+// fileFields returns the file fields of struct type t, including those of
+// embedded structs, parsing the tags on first use.
+func fileFields(t reflect.Type) ([]fileField, error) {
+	if cached, ok := fileFieldCache.Load(t); ok {
+		res := cached.(fileFieldsResult)
+		return res.fields, res.err
+	}
+	var res fileFieldsResult
+	res.err = collectFileFields(t, nil, &res.fields)
+	fileFieldCache.Store(t, res)
+	return res.fields, res.err
+}
+
+func collectFileFields(t reflect.Type, index []int, fields *[]fileField) error {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		fieldIndex := append(slices.Clone(index), i)
+		tag, ok := f.Tag.Lookup("file")
+		if !ok || !f.IsExported() {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				if err := collectFileFields(f.Type, fieldIndex, fields); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if f.Type != uploadedFileType && f.Type != uploadedFileSliceType {
+			return fmt.Errorf("bind: file field %s must be *UploadedFile or []*UploadedFile", f.Name)
+		}
+
+		name, limits, err := parseFileTag(tag)
+		if err != nil {
+			return fmt.Errorf("bind: file field %s: %w", f.Name, err)
+		}
+		*fields = append(*fields, fileField{index: fieldIndex, name: name, limits: limits, multi: f.Type == uploadedFileSliceType})
+	}
+	return nil
+}
+
+func newUploadedFile(field string, h *multipart.FileHeader, limits FileLimits) (*UploadedFile, error) {
+	if limits.MaxSize > 0 && h.Size > limits.MaxSize {
+		return nil, apierr.NewError(http.StatusRequestEntityTooLarge, "payload_too_large",
+			fmt.Sprintf("%s: file exceeds %d bytes", field, limits.MaxSize))
+	}
+
+	f, err := h.Open()
+	if err != nil {
+		return nil, fmt.Errorf("opening upload %s: %w", field, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("reading upload %s: %w", field, err)
+	}
+
+	contentType := http.DetectContentType(head[:n])
+	if err := limits.checkType(field, contentType); err != nil {
+		return nil, err
+	}
+
+	return &UploadedFile{Filename: h.Filename, Size: h.Size, ContentType: contentType, header: h}, nil
+}
+
+func (l FileLimits) checkType(field, contentType string) error {
+	if len(l.Types) == 0 {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	for _, t := range l.Types {
+		if prefix, ok := strings.CutSuffix(t, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return nil
+			}
+		} else if mediaType == t {
+			return nil
+		}
+	}
+	return apierr.NewError(http.StatusUnsupportedMediaType, "unsupported_media_type",
+		fmt.Sprintf("%s: file type %s not allowed", field, mediaType))
+}
+
+// parseFileTag splits a `file` tag into the part name and its limits.
+func parseFileTag(tag string) (string, FileLimits, error) {
+	name, rest, _ := strings.Cut(tag, ",")
+
+	var limits FileLimits
+	for opt := range strings.SplitSeq(rest, ",") {
+		key, value, _ := strings.Cut(opt, "=")
+		switch key {
+		case "":
+		case "size":
+			n, err := parseSize(value)
+			if err != nil {
+				return "", FileLimits{}, err
+			}
+			limits.MaxSize = n
+		case "types":
+			limits.Types = strings.Fields(value)
+		case "count":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return "", FileLimits{}, fmt.Errorf("invalid file count %q", value)
+			}
+			limits.MaxCount = n
+		default:
+			return "", FileLimits{}, fmt.Errorf("unknown file tag option %q", key)
+		}
+	}
+	return name, limits, nil
+}
+
+// parseSize parses a byte count with an optional KB, MB or GB suffix (powers of 1024).
+func parseSize(s string) (int64, error) {
+	multiplier := int64(1)
+	for suffix, m := range map[string]int64{"KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			s, multiplier = n, m
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid file size %q", s)
+	}
+	return n * multiplier, nil
+}
+
+// Part is a multipart part being streamed by Parts. Read it to consume its content.
+type Part struct {
+	// Name is the form field name.
+	Name string
+	// Filename is the client-supplied file name, empty for text fields.
+	Filename string
+	// ContentType is sniffed from the content for file parts and empty for text fields.
+	ContentType string
+
+	r io.Reader
+}
+
+// Read reads the part's content. For file parts, reading past FileLimits.MaxSize
+// fails with *http.MaxBytesError, which maps to a 413.
+func (p *Part) Read(b []byte) (int, error) {
+	return p.r.Read(b)
+}
+
+// Parts streams a multipart/form-data body part by part, calling fn for each
+// in order without buffering file content, e.g. to copy uploads straight to
+// object storage. limits apply to file parts; MaxCount caps files per request.
+// fn must consume or discard the part before returning; errors it returns
+// abort the stream and are returned as is.
+//
+//	err := bind.Parts(r, bind.FileLimits{MaxSize: 1 << 30, Types: []string{"video/*"}}, func(p *bind.Part) error {
+//		if p.Filename == "" {
+//			return nil
+//		}
+//		return store.Put(r.Context(), p.Filename, p)
+//	})
+func Parts(r *http.Request, limits FileLimits, fn func(p *Part) error) error {
+	if err := checkMultipart(r); err != nil {
+		return err
+	}
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return apierr.NewError(http.StatusBadRequest, "invalid_multipart", "malformed multipart body")
+	}
+
+	files := 0
+	for {
+		mp, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return err
+			}
+			return apierr.NewError(http.StatusBadRequest, "invalid_multipart", "malformed multipart body")
+		}
+
+		part := &Part{Name: mp.FormName(), Filename: mp.FileName(), r: mp}
+		if part.Filename != "" {
+			files++
+			if limits.MaxCount > 0 && files > limits.MaxCount {
+				return apierr.NewError(http.StatusRequestEntityTooLarge, "too_many_files",
+					fmt.Sprintf("at most %d files allowed", limits.MaxCount))
+			}
+
+			var body io.Reader = mp
+			if limits.MaxSize > 0 {
+				body = &limitedPart{r: mp, remaining: limits.MaxSize, limit: limits.MaxSize}
+			}
+			br := bufio.NewReaderSize(body, sniffLen)
+			head, err := br.Peek(sniffLen)
+			if err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+			part.ContentType = http.DetectContentType(head)
+			if err := limits.checkType(part.Name, part.ContentType); err != nil {
+				return err
+			}
+			part.r = br
+		}
+
+		err = fn(part)
+		_ = mp.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// limitedPart fails with *http.MaxBytesError once more than limit bytes are read.
+type limitedPart struct {
+	r         io.Reader
+	remaining int64
+	limit     int64
+}
+
+func (l *limitedPart) Read(b []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, &http.MaxBytesError{Limit: l.limit}
+	}
+	if int64(len(b)) > l.remaining+1 {
+		b = b[:l.remaining+1]
+	}
+	n, err := l.r.Read(b)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n - int(-l.remaining), &http.MaxBytesError{Limit: l.limit}
+	}
+	return n, err
+}
@@ -0,0 +1,124 @@
+package bind
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/response"
+)
+
+// PaginationOptions configures ParsePagination.
+type PaginationOptions struct {
+	// DefaultPerPage is used when per_page is absent. Defaults to 20.
+	DefaultPerPage int
+	// MaxPerPage is the largest accepted per_page. Defaults to 100.
+	MaxPerPage int
+	// MaxPage, when set, rejects deeper pages to keep OFFSET scans cheap.
+	MaxPage int
+}
+
+// Pagination is a parsed page request, ready for a query.
+type Pagination struct {
+	// Page is the 1-based page number, 0 when paginating by cursor.
+	Page    int
+	PerPage int
+	// Cursor is the opaque cursor the client sent, "" for the first page or
+	// when paginating by page number. Decode it with DecodeCursor.
+	Cursor string
+	// Offset and Limit are the SQL OFFSET and LIMIT for page-number pagination.
+	// Offset is always 0 with a cursor.
+	Offset int
+	Limit  int
+}
+
+// ParsePagination reads page, per_page and cursor from the query. Missing
+// values take their defaults; non-numeric, out-of-range values, or page and
+// cursor together, are a 400 APIError typed "invalid_pagination".
+//
+//	p, err := bind.ParsePagination(r, bind.PaginationOptions{MaxPerPage: 50})
+//	if err != nil {
+//		return err
+//	}
+//	rows, err := db.Query(ctx, "SELECT ... LIMIT $1 OFFSET $2", p.Limit+1, p.Offset)
+//	...
+//	return response.Paginated(w, http.StatusOK, users, p.Info(hasMore, ""))
+func ParsePagination(r *http.Request, opts PaginationOptions) (Pagination, error) {
+	if opts.DefaultPerPage == 0 {
+		opts.DefaultPerPage = 20
+	}
+	if opts.MaxPerPage == 0 {
+		opts.MaxPerPage = 100
+	}
+
+	query := r.URL.Query()
+	p := Pagination{Page: 1, PerPage: opts.DefaultPerPage, Cursor: query.Get("cursor")}
+
+	if s := query.Get("per_page"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > opts.MaxPerPage {
+			return Pagination{}, invalidPagination(fmt.Sprintf("per_page must be between 1 and %d", opts.MaxPerPage))
+		}
+		p.PerPage = n
+	}
+
+	if s := query.Get("page"); s != "" {
+		if p.Cursor != "" {
+			return Pagination{}, invalidPagination("page and cursor cannot be combined")
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return Pagination{}, invalidPagination("page must be a positive integer")
+		}
+		if opts.MaxPage > 0 && n > opts.MaxPage {
+			return Pagination{}, invalidPagination(fmt.Sprintf("page must be at most %d", opts.MaxPage))
+		}
+		// Reject pages whose offset would overflow into a negative OFFSET.
+		if n-1 > math.MaxInt/p.PerPage {
+			return Pagination{}, invalidPagination("page is too large")
+		}
+		p.Page = n
+	}
+
+	p.Limit = p.PerPage
+	if p.Cursor != "" {
+		p.Page = 0
+	} else {
+		p.Offset = (p.Page - 1) * p.PerPage
+	}
+	return p, nil
+}
+
+func invalidPagination(msg string) *apierr.APIError {
+	return apierr.NewError(http.StatusBadRequest, "invalid_pagination", msg)
+}
+
+// EncodeCursor encodes v, e.g. the sort key of the last row served, as an
+// opaque cursor for the next page.
+func EncodeCursor(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("encoding cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DecodeCursor decodes the request's cursor, as produced by EncodeCursor,
+// into dst. A cursor that does not decode is a 400 APIError typed "invalid_pagination".
+func (p Pagination) DecodeCursor(dst any) error {
+	b, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	if err != nil || json.Unmarshal(b, dst) != nil {
+		return invalidPagination("invalid cursor")
+	}
+	return nil
+}
+
+// Info describes the served page for response.Paginated. hasMore is usually
+// found by fetching Limit+1 rows; nextCursor is "" for page-number pagination.
+func (p Pagination) Info(hasMore bool, nextCursor string) response.PageInfo {
+	return response.PageInfo{Page: p.Page, PerPage: p.PerPage, HasMore: hasMore, NextCursor: nextCursor}
+}
@@ -0,0 +1,82 @@
+package bind
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+)
+
+// FieldMask records which JSON keys a PATCH body supplied, so handlers can
+// tell an absent field from an explicit null or zero value. Nested object
+// keys are dotted paths such as "address.city".
+type FieldMask struct {
+	present map[string]bool // path -> value was null
+}
+
+// Has reports whether path was present in the body, including as null.
+func (m FieldMask) Has(path string) bool {
+	_, ok := m.present[path]
+	return ok
+}
+
+// IsNull reports whether path was present with an explicit null, e.g. to clear it.
+func (m FieldMask) IsNull(path string) bool {
+	return m.present[path]
+}
+
+// Paths returns every supplied path in sorted order, parents before children.
+func (m FieldMask) Paths() []string {
+	return slices.Sorted(maps.Keys(m.present))
+}
+
+// Patch binds a JSON merge-patch style body into dst like JSON and returns
+// the FieldMask of keys it contained:
+//
+//	var req UpdateUserRequest
+//	mask, err := bind.Patch(r, &req, bind.Scenario("update"))
+//	if err != nil {
+//		return err
+//	}
+//	if mask.Has("nickname") {
+//		user.Nickname = req.Nickname // "" or null clears it
+//	}
+//
+// Absent fields are still validated, so pair Patch with omitempty rules or a Scenario.
+func Patch(r *http.Request, dst any, opts ...Option) (FieldMask, error) {
+	cfg := newConfig(opts)
+
+	var raw json.RawMessage
+	if err := cfg.decodeJSON(r, &raw); err != nil {
+		return FieldMask{}, err
+	}
+	if err := cfg.unmarshal(raw, dst); err != nil {
+		return FieldMask{}, err
+	}
+
+	mask := FieldMask{present: make(map[string]bool)}
+	collectPaths(raw, "", mask.present)
+
+	if err := cfg.finish(r, dst); err != nil {
+		return FieldMask{}, err
+	}
+	return mask, nil
+}
+
+// collectPaths records every key of the JSON object in raw, descending into
+// nested objects. Arrays are recorded as a whole.
+func collectPaths(raw json.RawMessage, prefix string, present map[string]bool) {
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(raw, &obj) != nil {
+		return
+	}
+	for key, value := range obj {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		present[path] = bytes.Equal(bytes.TrimSpace(value), []byte("null"))
+		collectPaths(value, path, present)
+	}
+}
@@ -0,0 +1,35 @@
+package bind
+
+import "net/http"
+
+// Path sets the fields of dst tagged `path:"name"` from r.PathValue(name),
+// i.e. the wildcards of the ServeMux pattern that matched r, then validates dst.
+// Values are parsed into the field's type; a segment that does not parse,
+// e.g. "abc" for an int64 ID, is a 400 APIError typed "invalid_path".
+//
+//	// mux.Handle("GET /orgs/{org}/users/{id}", ...)
+//	var params struct {
+//		Org string `path:"org"`
+//		ID  int64  `path:"id" validate:"gt=0"`
+//	}
+//	if err := bind.Path(r, &params); err != nil {
+//		return err
+//	}
+func Path(r *http.Request, dst any, opts ...Option) error {
+	cfg := newConfig(opts)
+
+	if err := bindValues(dst, "path", "invalid_path", pathValues(r)); err != nil {
+		return err
+	}
+
+	return cfg.finish(r, dst)
+}
+
+func pathValues(r *http.Request) lookupFunc {
+	return func(name, _ string) ([]string, error) {
+		if v := r.PathValue(name); v != "" {
+			return []string{v}, nil
+		}
+		return nil, nil
+	}
+}
@@ -0,0 +1,24 @@
+package bind
+
+import "net/http"
+
+// Query sets the fields of dst tagged `query:"name"` from the URL query, then
+// applies defaults and validates dst. Fields take the first value for their
+// name, slices every value. Values that do not parse are a 400 APIError typed
+// "invalid_query".
+func Query(r *http.Request, dst any, opts ...Option) error {
+	cfg := newConfig(opts)
+
+	if err := bindValues(dst, "query", "invalid_query", queryValues(r)); err != nil {
+		return err
+	}
+
+	return cfg.finish(r, dst)
+}
+
+func queryValues(r *http.Request) lookupFunc {
+	query := r.URL.Query()
+	return func(name, _ string) ([]string, error) {
+		return query[name], nil
+	}
+}
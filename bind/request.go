@@ -0,0 +1,78 @@
+package bind
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// Request binds every input of an endpoint into one struct, so the struct
+// alone describes the endpoint for docs generation and tests. Tags declare
+// each field's source:
+//
+//	type UpdateOrderRequest struct {
+//		OrgID   int64  `path:"org" json:"-"`
+//		OrderID int64  `path:"id" json:"-" validate:"gt=0"`
+//		DryRun  bool   `query:"dry_run" json:"-"`
+//		IfMatch string `header:"If-Match" json:"-"`
+//		Note    string `json:"note" validate:"max=500"`
+//	}
+//
+// Sources are applied in the order body, header, query, path, so a later
+// source overrides an earlier one for a field tagged with both: the URL
+// identifies the resource and cannot be overridden from the body. Tag fields
+// that must not come from the body `json:"-"`, as encoding/json otherwise
+// matches untagged fields by name. The body is
+// decoded as JSON or as an urlencoded form (`form` tags) by Content-Type;
+// other types are a 415 and an empty body is skipped. Modifiers, defaults and
+// validation run once, after every source.
+func Request(r *http.Request, dst any, opts ...Option) error {
+	cfg := newConfig(opts)
+
+	if r.ContentLength != 0 {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch {
+		case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+			if err := cfg.decodeJSON(r, dst); err != nil {
+				return err
+			}
+		case mediaType == "application/x-www-form-urlencoded":
+			if err := cfg.decodeForm(r, dst); err != nil {
+				return err
+			}
+		default:
+			return apierr.NewError(http.StatusUnsupportedMediaType, "unsupported_media_type",
+				"content type must be application/json or application/x-www-form-urlencoded")
+		}
+	}
+
+	if err := bindValues(dst, "header", "invalid_header", headerValues(r)); err != nil {
+		return err
+	}
+	if err := bindValues(dst, "query", "invalid_query", queryValues(r)); err != nil {
+		return err
+	}
+	if err := bindValues(dst, "path", "invalid_path", pathValues(r)); err != nil {
+		return err
+	}
+
+	return cfg.finish(r, dst)
+}
+
+// As binds a new T from r like Request, for handlers that prefer a typed
+// value over declaring a variable and passing a pointer:
+//
+//	req, err := bind.As[CreateUserRequest](r)
+//	if err != nil {
+//		return err
+//	}
+func As[T any](r *http.Request, opts ...Option) (T, error) {
+	var dst T
+	if err := Request(r, &dst, opts...); err != nil {
+		var zero T
+		return zero, err
+	}
+	return dst, nil
+}
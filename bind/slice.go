@@ -0,0 +1,95 @@
+package bind
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// ItemErrors holds the decode and validation errors of a bulk body by item index.
+// apierr.MapError turns it into a 422 whose message maps each failing index
+// to that item's error message, e.g. {"2": {"email": "required"}}.
+type ItemErrors map[int]error
+
+func (e ItemErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, i := range slices.Sorted(maps.Keys(e)) {
+		msgs = append(msgs, fmt.Sprintf("item %d: %v", i, e[i]))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// APIError returns the mapped error for item i, or nil when it is valid.
+func (e ItemErrors) APIError(i int) *apierr.APIError {
+	if err, ok := e[i]; ok {
+		return apierr.MapNested(err)
+	}
+	return nil
+}
+
+// As lets errors.As, and so apierr.MapError, see ItemErrors as a 422 APIError.
+func (e ItemErrors) As(target any) bool {
+	t, ok := target.(**apierr.APIError)
+	if !ok {
+		return false
+	}
+	items := make(map[string]any, len(e))
+	for i := range e {
+		items[strconv.Itoa(i)] = e.APIError(i).Message
+	}
+	*t = apierr.NewError(http.StatusUnprocessableEntity, "validation", items)
+	return true
+}
+
+// Slice binds a JSON array body for bulk endpoints, decoding and validating
+// each item independently. It returns every item alongside ItemErrors for the
+// failing ones, so handlers can reject the whole batch with return err or
+// process the valid items and answer with response.MultiStatus:
+//
+//	items, err := bind.Slice[CreateUserRequest](r)
+//	var itemErrs bind.ItemErrors
+//	if err != nil && !errors.As(err, &itemErrs) {
+//		return err
+//	}
+//	results := make([]response.ItemStatus, len(items))
+//	for i, item := range items {
+//		if apiErr := itemErrs.APIError(i); apiErr != nil {
+//			results[i] = response.ItemStatus{Index: i, Status: apiErr.StatusCode, Body: apiErr}
+//			continue
+//		}
+//		results[i] = response.ItemStatus{Index: i, Status: http.StatusCreated, Body: create(item)}
+//	}
+//	return response.MultiStatus(w, results)
+//
+// A body that is not a JSON array fails as a whole like JSON does.
+func Slice[T any](r *http.Request, opts ...Option) ([]T, error) {
+	cfg := newConfig(opts)
+
+	var raw []json.RawMessage
+	if err := cfg.decodeJSON(r, &raw); err != nil {
+		return nil, err
+	}
+
+	items := make([]T, len(raw))
+	errs := make(ItemErrors)
+	for i, data := range raw {
+		if err := cfg.unmarshal(data, &items[i]); err != nil {
+			errs[i] = err
+			continue
+		}
+		if err := cfg.finish(r, &items[i]); err != nil {
+			errs[i] = err
+		}
+	}
+
+	if len(errs) > 0 {
+		return items, errs
+	}
+	return items, nil
+}
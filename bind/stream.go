@@ -0,0 +1,92 @@
+package bind
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// ItemError is the error Stream aborts with, identifying the failing item.
+type ItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *ItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// As maps ItemError to the APIError of the underlying error with its message
+// keyed by index, e.g. a 422 with {"41": {"email": "required"}}.
+func (e *ItemError) As(target any) bool {
+	t, ok := target.(**apierr.APIError)
+	if !ok {
+		return false
+	}
+	mapped := apierr.MapNested(e.Err)
+	*t = apierr.NewError(mapped.StatusCode, mapped.Type, map[string]any{strconv.Itoa(e.Index): mapped.Message})
+	return true
+}
+
+// Stream decodes a JSON array body element by element, calling fn for each
+// item once it is decoded, normalized and validated, so huge uploads are
+// processed with bounded memory. The first failing item, whether it does not
+// decode, is invalid or fn returns an error, aborts the stream with an
+// *ItemError; items before it have already been handled.
+//
+//	err := bind.Stream(r, func(e Event) error {
+//		return events.Insert(r.Context(), e)
+//	})
+//
+// Unlike JSON, Stream has no default size limit; set one with MaxBytes or middleware.MaxBody.
+func Stream[T any](r *http.Request, fn func(item T) error, opts ...Option) error {
+	cfg := newConfig(opts)
+	if err := cfg.limitBody(r, 0); err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(r.Body)
+	if cfg.strict {
+		dec.DisallowUnknownFields()
+	}
+
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('[') {
+		return apierr.NewError(http.StatusBadRequest, "json", "expected JSON array")
+	}
+
+	for i := 0; dec.More(); i++ {
+		var item T
+		if err := dec.Decode(&item); err != nil {
+			return &ItemError{Index: i, Err: unknownFieldError(err)}
+		}
+		if err := cfg.finish(r, &item); err != nil {
+			return &ItemError{Index: i, Err: err}
+		}
+		if err := fn(item); err != nil {
+			return &ItemError{Index: i, Err: err}
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return err
+		}
+		return apierr.NewError(http.StatusBadRequest, "json", "unexpected data after JSON body")
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package bind
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// Union decodes polymorphic JSON whose concrete type is selected by a
+// discriminator field, e.g. {"type":"card",...} or {"type":"iban",...}.
+// T is usually an interface the variants implement.
+type Union[T any] struct {
+	field    string
+	variants map[string]func() T
+}
+
+// NewUnion returns a Union reading the discriminator from field. variants maps
+// each discriminator value to a constructor returning a pointer to its
+// concrete type:
+//
+//	var paymentMethods = bind.NewUnion("type", map[string]func() PaymentMethod{
+//		"card": func() PaymentMethod { return &Card{} },
+//		"iban": func() PaymentMethod { return &IBAN{} },
+//	})
+func NewUnion[T any](field string, variants map[string]func() T) *Union[T] {
+	return &Union[T]{field: field, variants: variants}
+}
+
+// Decode decodes data into the variant named by its discriminator. A missing
+// or unregistered discriminator is a 400 APIError typed "unknown_type"
+// listing the valid types. Use it from UnmarshalJSON to embed a union in a
+// larger request:
+//
+//	func (p *PaymentRequest) UnmarshalJSON(b []byte) error {
+//		m, err := paymentMethods.Decode(b)
+//		p.Method = m
+//		return err
+//	}
+func (u *Union[T]) Decode(data []byte) (T, error) {
+	return u.decode(data, &config{})
+}
+
+// decode is Decode with the variant decoded like JSON decodes under cfg, so
+// Strict rejects unknown fields.
+func (u *Union[T]) decode(data []byte, cfg *config) (T, error) {
+	var zero T
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return zero, err
+	}
+
+	var name string
+	if raw, ok := probe[u.field]; ok {
+		if err := json.Unmarshal(raw, &name); err != nil {
+			return zero, u.unknown(fmt.Sprintf("%q must be a string", u.field))
+		}
+	}
+	if name == "" {
+		return zero, u.unknown(fmt.Sprintf("missing %q field", u.field))
+	}
+
+	newVariant, ok := u.variants[name]
+	if !ok {
+		return zero, u.unknown(fmt.Sprintf("unknown %s %q", u.field, name))
+	}
+
+	v := newVariant()
+	if err := cfg.unmarshal(data, v); err != nil {
+		return zero, err
+	}
+	return v, nil
+}
+
+func (u *Union[T]) unknown(reason string) *apierr.APIError {
+	valid := slices.Sorted(maps.Keys(u.variants))
+	return apierr.NewError(http.StatusBadRequest, "unknown_type",
+		reason+", expected one of: "+strings.Join(valid, ", "))
+}
+
+// Bind decodes the JSON request body like JSON does, including Strict, then
+// applies defaults to and validates the selected variant.
+func (u *Union[T]) Bind(r *http.Request, opts ...Option) (T, error) {
+	var zero T
+	cfg := newConfig(opts)
+
+	var raw json.RawMessage
+	if err := cfg.decodeJSON(r, &raw); err != nil {
+		return zero, err
+	}
+	v, err := u.decode(raw, &cfg)
+	if err != nil {
+		return zero, err
+	}
+	if err := cfg.finish(r, v); err != nil {
+		return zero, err
+	}
+	return v, nil
+}
@@ -0,0 +1,32 @@
+package bind
+
+import "github.com/piheta/apicore/validate"
+
+// ValidatorOptions configures NewValidator.
+type ValidatorOptions struct {
+	// Rules are registered in addition to the built-in rules.
+	Rules map[string]validate.Rule
+	// Translations maps locales such as "en" or "nb" to messages, merged over
+	// validate.English for "en". Custom Rules need a message here to be translated.
+	Translations map[string]validate.Translations
+}
+
+// NewValidator builds the Validator services usually want: fields named by
+// their json tag, English messages as the fallback locale, plus opts' rules
+// and translations. Binders pick the message locale from the request's
+// Accept-Language header, so 422 responses read like
+// {"email": "email must be a valid email address"}.
+//
+// Install it for every binder with validate.Default = bind.NewValidator(opts),
+// or per call with WithValidator.
+func NewValidator(opts ValidatorOptions) *validate.Validator {
+	v := validate.New(validate.Options{FieldName: validate.JSONFieldName})
+	v.SetTranslator("en", validate.English)
+	for locale, t := range opts.Translations {
+		v.SetTranslator(locale, t)
+	}
+	for name, rule := range opts.Rules {
+		v.RegisterRule(name, rule)
+	}
+	return v
+}
@@ -0,0 +1,148 @@
+package bind
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// errUnsupported reports a field type bind cannot parse into. It is a
+// programming error, so it maps to a 500 rather than blaming the client.
+var errUnsupported = errors.New("bind: unsupported field type")
+
+// lookupFunc returns the raw values for a field's tag name. opts holds the
+// rest of the tag after the name, e.g. "signed" for `cookie:"sid,signed"`.
+type lookupFunc func(name, opts string) ([]string, error)
+
+// bindValues sets every field of dst tagged with tag from the strings lookup
+// returns for the tag's name. Fields lookup has no values for are left alone.
+// Parse failures become 400 APIErrors typed errType naming the offending field;
+// lookup errors are returned as is.
+func bindValues(dst any, tag, errType string, lookup lookupFunc) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind: expected pointer to struct, got %T", dst)
+	}
+	return bindStruct(rv.Elem(), tag, errType, lookup)
+}
+
+func bindStruct(rv reflect.Value, tag, errType string, lookup lookupFunc) error {
+	t := rv.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, ok := f.Tag.Lookup(tag)
+		if !ok {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				if err := bindStruct(rv.Field(i), tag, errType, lookup); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		name, opts, _ := strings.Cut(name, ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		values, err := lookup(name, opts)
+		if err != nil {
+			return err
+		}
+		if len(values) == 0 {
+			continue
+		}
+		if err := setField(rv.Field(i), values); err != nil {
+			if errors.Is(err, errUnsupported) {
+				return err
+			}
+			return apierr.NewError(http.StatusBadRequest, errType, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	return nil
+}
+
+// setField parses values into fv. Slices take every value, other kinds the first.
+func setField(fv reflect.Value, values []string) error {
+	_, registered := lookupDecoder(fv.Type())
+	if fv.Kind() == reflect.Slice && !registered && !fv.Addr().Type().Implements(textUnmarshalerType) && fv.Type().Elem().Kind() != reflect.Uint8 {
+		s := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, v := range values {
+			if err := setScalar(s.Index(i), v); err != nil {
+				return err
+			}
+		}
+		fv.Set(s)
+		return nil
+	}
+	return setScalar(fv, values[0])
+}
+
+func setScalar(fv reflect.Value, s string) error {
+	if fv.Kind() == reflect.Pointer {
+		v := reflect.New(fv.Type().Elem())
+		if err := setScalar(v.Elem(), s); err != nil {
+			return err
+		}
+		fv.Set(v)
+		return nil
+	}
+
+	if ok, err := decodeRegistered(fv, s); ok {
+		return err
+	}
+
+	// TextUnmarshaler covers netip.Addr and UUID types.
+	if u, ok := fv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("invalid value %q", s)
+		}
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("expected boolean, got %q", s)
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected integer, got %q", s)
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected unsigned integer, got %q", s)
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected number, got %q", s)
+		}
+		fv.SetFloat(n)
+	default:
+		return fmt.Errorf("%w %s", errUnsupported, fv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,314 @@
+// Package healthcheck provides liveness and readiness handlers backed by dependency probes.
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/piheta/apicore/response"
+)
+
+// Probe checks one dependency, returning an error when it is unhealthy.
+type Probe func(ctx context.Context) error
+
+// Status is the health of a single dependency or of the service as a whole.
+type Status string
+
+// Health states reported by the handlers. A degraded service still serves
+// traffic, so readiness answers 200 with warnings instead of 503.
+const (
+	StatusUp       Status = "up"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+	// StatusDraining is reported once Drain is called, while the server
+	// finishes in-flight requests before shutting down.
+	StatusDraining Status = "draining"
+)
+
+// degradedError marks a probe failure as degraded rather than down.
+type degradedError struct {
+	err error
+}
+
+func (e *degradedError) Error() string { return e.err.Error() }
+
+func (e *degradedError) Unwrap() error { return e.err }
+
+// Degraded wraps err so the probe returning it reports StatusDegraded instead
+// of StatusDown, e.g. when a replica lags but still answers:
+//
+//	if lag > 30*time.Second {
+//		return healthcheck.Degraded(fmt.Errorf("replication lag %s", lag))
+//	}
+func Degraded(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &degradedError{err: err}
+}
+
+// Result is the outcome of the most recent run of a probe.
+type Result struct {
+	Status     Status    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs float64   `json:"duration_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+
+	expires time.Time
+}
+
+// Report is the readiness response body.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+	// Warnings lists the degraded checks as "name: error".
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Options configures a Checker.
+type Options struct {
+	// Timeout bounds each probe run unless the probe sets its own. Defaults to 2 seconds.
+	Timeout time.Duration
+	// CacheTTL is how long a probe result is reused before the probe runs again,
+	// so frequent readiness checks don't hammer dependencies. Defaults to 5 seconds.
+	CacheTTL time.Duration
+	// CacheJitter varies each cached result's lifetime randomly by up to this
+	// fraction of CacheTTL in either direction, so replicas probed in lockstep
+	// by Kubernetes don't hit dependencies at the same moment. Defaults to 0.1;
+	// negative disables it.
+	CacheJitter float64
+}
+
+// ProbeOption configures a single registered probe.
+type ProbeOption func(*probe)
+
+// WithTimeout overrides the Checker timeout for one probe.
+func WithTimeout(d time.Duration) ProbeOption {
+	return func(p *probe) {
+		p.timeout = d
+	}
+}
+
+// NonCritical makes a failing probe report StatusDegraded instead of
+// StatusDown, for dependencies the service can run without, such as a cache.
+func NonCritical() ProbeOption {
+	return func(p *probe) {
+		p.nonCritical = true
+	}
+}
+
+type probe struct {
+	name        string
+	check       Probe
+	timeout     time.Duration
+	nonCritical bool
+
+	mu     sync.Mutex
+	result Result
+}
+
+// Checker runs registered probes and serves their results.
+type Checker struct {
+	opts Options
+
+	mu     sync.RWMutex
+	probes []*probe
+
+	draining atomic.Bool
+}
+
+// New creates a Checker.
+func New(opts Options) *Checker {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Second
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = 5 * time.Second
+	}
+	if opts.CacheJitter == 0 {
+		opts.CacheJitter = 0.1
+	}
+	opts.CacheJitter = min(max(opts.CacheJitter, 0), 1)
+	return &Checker{opts: opts}
+}
+
+// Register adds a readiness probe under name, replacing any probe with the same name.
+func (c *Checker) Register(name string, check Probe, opts ...ProbeOption) {
+	p := &probe{name: name, check: check, timeout: c.opts.Timeout}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Copy on write: Check iterates the previous slice without holding the lock.
+	probes := make([]*probe, 0, len(c.probes)+1)
+	for _, existing := range c.probes {
+		if existing.name != name {
+			probes = append(probes, existing)
+		}
+	}
+	probes = append(probes, p)
+	sort.Slice(probes, func(i, j int) bool { return probes[i].name < probes[j].name })
+	c.probes = probes
+}
+
+// Drain makes readiness fail with StatusDraining from now on, without running
+// probes, so load balancers stop routing new requests to the instance before
+// it stops accepting connections. server.Server calls it on Stop when the
+// Checker is set as Options.Health.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Check runs every probe concurrently, reusing cached results that are still
+// fresh. After Drain it reports StatusDraining instead.
+func (c *Checker) Check(ctx context.Context) Report {
+	if c.draining.Load() {
+		return Report{Status: StatusDraining}
+	}
+
+	c.mu.RLock()
+	probes := c.probes
+	c.mu.RUnlock()
+
+	results := make([]Result, len(probes))
+	var wg sync.WaitGroup
+	for i, p := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = p.run(ctx, c.cacheTTL)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(probes))}
+	for i, p := range probes {
+		report.Checks[p.name] = results[i]
+		switch results[i].Status {
+		case StatusDown:
+			report.Status = StatusDown
+		case StatusDegraded:
+			if report.Status == StatusUp {
+				report.Status = StatusDegraded
+			}
+			report.Warnings = append(report.Warnings, p.name+": "+results[i].Error)
+		}
+	}
+	return report
+}
+
+// cacheTTL returns CacheTTL varied by CacheJitter.
+func (c *Checker) cacheTTL() time.Duration {
+	jitter := c.opts.CacheJitter * (2*rand.Float64() - 1)
+	return time.Duration(float64(c.opts.CacheTTL) * (1 + jitter))
+}
+
+// run returns the cached result while it is fresh, or runs the probe and
+// caches the result for ttl(). The lock makes concurrent checks share one run.
+func (p *probe) run(ctx context.Context, ttl func() time.Duration) Result {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if time.Now().Before(p.result.expires) {
+		return p.result
+	}
+
+	// A canceled readiness request must not cache its failure as the probe's
+	// result for the whole TTL, so only the probe timeout bounds the check.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), p.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := runProbe(ctx, p.check)
+
+	result := Result{
+		Status:     StatusUp,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		CheckedAt:  time.Now(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+		var degraded *degradedError
+		if p.nonCritical || errors.As(err, &degraded) {
+			result.Status = StatusDegraded
+		}
+	}
+	result.expires = result.CheckedAt.Add(ttl())
+	p.result = result
+	return result
+}
+
+// runProbe returns when the probe finishes or ctx expires, whichever comes first,
+// so a probe that ignores its context can't stall the readiness endpoint.
+func runProbe(ctx context.Context, check Probe) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// LivenessHandler reports that the process is up without consulting any probe.
+func (c *Checker) LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		_ = response.JSON(w, http.StatusOK, Report{Status: StatusUp})
+	}
+}
+
+// ReadinessHandler runs the probes and answers 200 when none are down, 503
+// otherwise or while draining, with per-dependency results in the body and
+// degraded checks listed under warnings.
+func (c *Checker) ReadinessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := c.Check(r.Context())
+		status := http.StatusOK
+		if report.Status == StatusDown || report.Status == StatusDraining {
+			status = http.StatusServiceUnavailable
+		}
+		_ = response.JSON(w, status, report)
+	}
+}
+
+var defaultChecker = New(Options{})
+
+// Register adds a probe to the default Checker.
+func Register(name string, check Probe, opts ...ProbeOption) {
+	defaultChecker.Register(name, check, opts...)
+}
+
+// Drain makes the default Checker's readiness fail. See Checker.Drain.
+func Drain() {
+	defaultChecker.Drain()
+}
+
+// Default returns the Checker used by the package-level functions, e.g. for
+// server.Options.Health.
+func Default() *Checker {
+	return defaultChecker
+}
+
+// Liveness is the default Checker's liveness handler, typically mounted at /healthz.
+func Liveness(w http.ResponseWriter, r *http.Request) {
+	defaultChecker.LivenessHandler()(w, r)
+}
+
+// Readiness is the default Checker's readiness handler, typically mounted at /readyz.
+func Readiness(w http.ResponseWriter, r *http.Request) {
+	defaultChecker.ReadinessHandler()(w, r)
+}
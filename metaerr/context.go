@@ -0,0 +1,51 @@
+package metaerr
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	contextMu    sync.RWMutex
+	contextFuncs []func(ctx context.Context) []any
+)
+
+// RegisterContext adds fn to the sources of context metadata: key-value pairs
+// derived from a request context, such as trace and span IDs, that belong on
+// every log line about the request. middleware.Trace registers trace_id and
+// span_id this way.
+func RegisterContext(fn func(ctx context.Context) []any) {
+	contextMu.Lock()
+	defer contextMu.Unlock()
+	contextFuncs = append(contextFuncs, fn)
+}
+
+// ContextMetadata returns the key-value pairs of every registered source for ctx.
+func ContextMetadata(ctx context.Context) []any {
+	contextMu.RLock()
+	defer contextMu.RUnlock()
+
+	var pairs []any
+	for _, fn := range contextFuncs {
+		p := fn(ctx)
+		if len(p)%2 != 0 {
+			p = p[:len(p)-1]
+		}
+		pairs = append(pairs, p...)
+	}
+	return pairs
+}
+
+// WithContext wraps err with the context metadata of ctx, for errors that
+// outlive the request, e.g. those handed to a background job or an error
+// tracker, so they can still be tied to its trace.
+func WithContext(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	pairs := ContextMetadata(ctx)
+	if len(pairs) == 0 {
+		return err
+	}
+	return &errMetadata{err: err, metadata: pairs}
+}
@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// AsyncOptions configures NewAsyncHandler.
+type AsyncOptions struct {
+	// QueueSize bounds the records waiting to be written. When it is full the
+	// oldest record is dropped. Defaults to 1024.
+	QueueSize int
+	// OnDrop, when set, is called with each dropped record, e.g. to count drops.
+	// It runs on the logging goroutine and must not block.
+	OnDrop func(r slog.Record)
+}
+
+// AsyncHandler is a slog.Handler that queues records and writes them to the
+// wrapped handler on a background goroutine, so slow log I/O (a full disk, a
+// stalled pipe or a remote collector) cannot add latency to requests. Use it as
+// the handler of LoggerOptions.Logger and flush it when the server stops:
+//
+//	async := middleware.NewAsyncHandler(slog.NewJSONHandler(os.Stdout, nil), middleware.AsyncOptions{})
+//	srv.Use(middleware.RequestLoggerWith(middleware.LoggerOptions{Logger: slog.New(async)}))
+//	srv.OnShutdown(async.Close)
+type AsyncHandler struct {
+	handler slog.Handler
+	q       *asyncQueue
+}
+
+// asyncEntry is a queued record with the handler, including attributes and
+// groups added with WithAttrs and WithGroup, that writes it.
+type asyncEntry struct {
+	ctx     context.Context
+	handler slog.Handler
+	record  slog.Record
+}
+
+// asyncQueue is the ring buffer and writer goroutine shared by an AsyncHandler
+// and the handlers derived from it.
+type asyncQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	buf     []asyncEntry
+	head    int
+	n       int
+	busy    bool
+	closed  bool
+	onDrop  func(slog.Record)
+	dropped atomic.Uint64
+	done    chan struct{}
+}
+
+// NewAsyncHandler returns an AsyncHandler writing to h.
+func NewAsyncHandler(h slog.Handler, opts AsyncOptions) *AsyncHandler {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1024
+	}
+	q := &asyncQueue{buf: make([]asyncEntry, opts.QueueSize), onDrop: opts.OnDrop, done: make(chan struct{})}
+	q.cond = sync.NewCond(&q.mu)
+	go q.run()
+	return &AsyncHandler{handler: h, q: q}
+}
+
+// Enabled reports whether the wrapped handler handles level.
+func (h *AsyncHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle queues r without waiting for it to be written. Records handled after
+// Close are written synchronously.
+func (h *AsyncHandler) Handle(ctx context.Context, r slog.Record) error {
+	entry := asyncEntry{ctx: context.WithoutCancel(ctx), handler: h.handler, record: r.Clone()}
+
+	q := h.q
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return h.handler.Handle(ctx, r)
+	}
+	var dropped *slog.Record
+	if q.n == len(q.buf) {
+		old := q.buf[q.head].record
+		dropped = &old
+		q.buf[q.head] = asyncEntry{}
+		q.head = (q.head + 1) % len(q.buf)
+		q.n--
+		q.dropped.Add(1)
+	}
+	q.buf[(q.head+q.n)%len(q.buf)] = entry
+	q.n++
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	if dropped != nil && q.onDrop != nil {
+		q.onDrop(*dropped)
+	}
+	return nil
+}
+
+// WithAttrs returns a handler sharing h's queue that adds attrs.
+func (h *AsyncHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &AsyncHandler{handler: h.handler.WithAttrs(attrs), q: h.q}
+}
+
+// WithGroup returns a handler sharing h's queue that opens group name.
+func (h *AsyncHandler) WithGroup(name string) slog.Handler {
+	return &AsyncHandler{handler: h.handler.WithGroup(name), q: h.q}
+}
+
+// Dropped returns the number of records dropped because the queue was full.
+func (h *AsyncHandler) Dropped() uint64 {
+	return h.q.dropped.Load()
+}
+
+// Flush waits until every record queued so far has been written, or ctx is done.
+func (h *AsyncHandler) Flush(ctx context.Context) error {
+	q := h.q
+	idle := make(chan struct{})
+	go func() {
+		q.mu.Lock()
+		for (q.n > 0 || q.busy) && ctx.Err() == nil {
+			q.cond.Wait()
+		}
+		q.mu.Unlock()
+		close(idle)
+	}()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		// Wake the waiter so it observes ctx and exits.
+		q.mu.Lock()
+		q.cond.Broadcast()
+		q.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Close writes the queued records and stops the background goroutine, waiting
+// until ctx is done at most. Its signature matches server.Server.OnShutdown.
+func (h *AsyncHandler) Close(ctx context.Context) error {
+	q := h.q
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run writes queued records until the queue is closed and drained.
+func (q *asyncQueue) run() {
+	defer close(q.done)
+	for {
+		q.mu.Lock()
+		for q.n == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if q.n == 0 {
+			q.mu.Unlock()
+			return
+		}
+		entry := q.buf[q.head]
+		q.buf[q.head] = asyncEntry{}
+		q.head = (q.head + 1) % len(q.buf)
+		q.n--
+		q.busy = true
+		q.mu.Unlock()
+
+		_ = entry.handler.Handle(entry.ctx, entry.record)
+
+		q.mu.Lock()
+		q.busy = false
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// RequireScopes allows requests whose token grants every listed scope. It must run
+// after Protected or OIDC; requests without claims get a 401 and requests missing
+// a scope get a 403 APIError naming it.
+func RequireScopes(scopes ...string) func(http.Handler) http.Handler {
+	return requireClaims("scope", Claims.Scopes, scopes)
+}
+
+// RequireRoles allows requests whose token carries every listed role in its
+// "roles" claim, with the same error behavior as RequireScopes.
+func RequireRoles(roles ...string) func(http.Handler) http.Handler {
+	return requireClaims("role", Claims.Roles, roles)
+}
+
+func requireClaims(kind string, granted func(Claims) []string, required []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetClaims(r.Context())
+			if !ok {
+				writeError(w, r, apierr.NewError(http.StatusUnauthorized, "unauthorized", "authentication required"))
+				return
+			}
+
+			have := granted(claims)
+			for _, want := range required {
+				if !slices.Contains(have, want) {
+					writeError(w, r, apierr.NewError(http.StatusForbidden, "forbidden", "missing "+kind+": "+want))
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
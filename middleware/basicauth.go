@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// CredentialVerifier reports whether a username and password are valid.
+type CredentialVerifier func(username, password string) bool
+
+// StaticCredentials returns a CredentialVerifier accepting a single username and
+// password, compared in constant time.
+func StaticCredentials(username, password string) CredentialVerifier {
+	wantUser := sha256.Sum256([]byte(username))
+	wantPass := sha256.Sum256([]byte(password))
+	return func(u, p string) bool {
+		gotUser := sha256.Sum256([]byte(u))
+		gotPass := sha256.Sum256([]byte(p))
+		userOK := subtle.ConstantTimeCompare(gotUser[:], wantUser[:])
+		passOK := subtle.ConstantTimeCompare(gotPass[:], wantPass[:])
+		return userOK&passOK == 1
+	}
+}
+
+// BasicAuth protects handlers with HTTP Basic authentication, answering with a
+// 401 APIError and a WWW-Authenticate challenge for realm when verify rejects
+// the credentials.
+func BasicAuth(realm string, verify CredentialVerifier) func(http.Handler) http.Handler {
+	challenge := "Basic realm=" + strconv.Quote(realm) + `, charset="UTF-8"`
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			username, password, ok := r.BasicAuth()
+			if !ok || !verify(username, password) {
+				w.Header().Set("WWW-Authenticate", challenge)
+				writeError(w, r, apierr.NewError(http.StatusUnauthorized, "unauthorized", "invalid credentials"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DebugBodyHeader carries the signed token that enables BodyLogger for a single
+// request when BodyLogOptions.DebugSecret is set.
+const DebugBodyHeader = "X-Debug-Body"
+
+const redactedValue = "[REDACTED]"
+
+// BodyLogOptions configures BodyLogger.
+type BodyLogOptions struct {
+	// Logger receives the body logs. Defaults to the logger set with
+	// SetLogger, or slog.Default(), at log time.
+	Logger *slog.Logger
+	// MaxBytes caps how much of each body is captured. Defaults to 4096.
+	MaxBytes int
+	// ContentTypes is the allowlist of media types whose bodies are logged.
+	// Entries ending in "/*" match a whole type. Defaults to JSON, urlencoded
+	// forms and text/*.
+	ContentTypes []string
+	// Redact lists dotted paths of JSON fields to mask, e.g. "password" or
+	// "card.number". "*" matches any key and arrays are traversed transparently,
+	// so "items.token" masks the token of every item. Single-segment paths also
+	// mask urlencoded form fields. Bodies that cannot be parsed or were cut off
+	// at MaxBytes are omitted rather than logged unredacted.
+	Redact []string
+	// DebugSecret, when set, restricts logging to requests carrying a valid
+	// X-Debug-Body token issued by SignDebugToken. Without it every request
+	// through the middleware is logged, so wrap only the routes being debugged.
+	DebugSecret []byte
+}
+
+var defaultBodyLogTypes = []string{
+	"application/json",
+	"application/x-www-form-urlencoded",
+	"text/*",
+}
+
+// SignDebugToken returns an X-Debug-Body token valid until expires.
+func SignDebugToken(secret []byte, expires time.Time) string {
+	ts := strconv.FormatInt(expires.Unix(), 10)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(ts))
+	return ts + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// validDebugToken reports whether token was signed with secret and has not expired.
+func validDebugToken(secret []byte, token string) bool {
+	ts, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || time.Now().After(time.Unix(unix, 0)) {
+		return false
+	}
+	decoded, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(ts))
+	return hmac.Equal(decoded, mac.Sum(nil))
+}
+
+// BodyLogger logs request and response bodies for reproducing integration bugs.
+// Bodies are size-capped, filtered by content type and redacted according to
+// opts. It is opt-in: mount it on individual routes, or everywhere with a
+// DebugSecret so only requests carrying a signed debug header are logged.
+func BodyLogger(opts BodyLogOptions) func(http.Handler) http.Handler {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 4096
+	}
+	if len(opts.ContentTypes) == 0 {
+		opts.ContentTypes = defaultBodyLogTypes
+	}
+	redact := make([][]string, len(opts.Redact))
+	for i, path := range opts.Redact {
+		redact[i] = strings.Split(path, ".")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(opts.DebugSecret) > 0 && !validDebugToken(opts.DebugSecret, r.Header.Get(DebugBodyHeader)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var reqBody []byte
+			reqType := r.Header.Get("Content-Type")
+			if r.Body != nil && r.Body != http.NoBody && matchMediaType(reqType, opts.ContentTypes) {
+				reqBody, _ = io.ReadAll(io.LimitReader(r.Body, int64(opts.MaxBytes)+1))
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(reqBody), r.Body), r.Body}
+			}
+
+			bw := &bodyLogWriter{ResponseWriter: w, statusCode: http.StatusOK, max: opts.MaxBytes}
+			next.ServeHTTP(bw, r)
+
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", bw.statusCode),
+			}
+			if id := GetRequestID(r.Context()); id != "" {
+				attrs = append(attrs, slog.String("request_id", id))
+			}
+			if reqBody != nil {
+				attrs = append(attrs, slog.String("request_body", renderBody(reqType, reqBody, opts.MaxBytes, redact)))
+			}
+			if respType := w.Header().Get("Content-Type"); bw.buf.Len() > 0 && matchMediaType(respType, opts.ContentTypes) {
+				attrs = append(attrs, slog.String("response_body", renderBody(respType, bw.buf.Bytes(), opts.MaxBytes, redact)))
+			}
+
+			loggerOr(opts.Logger).LogAttrs(r.Context(), slog.LevelInfo, "BODY", attrs...)
+		})
+	}
+}
+
+// renderBody returns the loggable form of a captured body. data holds up to
+// maxBytes+1 bytes so truncation can be detected.
+func renderBody(contentType string, data []byte, maxBytes int, redact [][]string) string {
+	truncated := len(data) > maxBytes
+	if truncated {
+		data = data[:maxBytes]
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if len(redact) > 0 && (mediaType == "application/json" || mediaType == "application/x-www-form-urlencoded") {
+		if truncated {
+			return "[omitted: body exceeds " + strconv.Itoa(maxBytes) + " bytes and cannot be redacted]"
+		}
+		redacted, ok := redactBody(mediaType, data, redact)
+		if !ok {
+			return "[omitted: body could not be parsed for redaction]"
+		}
+		return redacted
+	}
+
+	if truncated {
+		return string(data) + "...[truncated]"
+	}
+	return string(data)
+}
+
+func redactBody(mediaType string, data []byte, redact [][]string) (string, bool) {
+	if mediaType == "application/x-www-form-urlencoded" {
+		values, err := url.ParseQuery(string(data))
+		if err != nil {
+			return "", false
+		}
+		for _, path := range redact {
+			if len(path) == 1 && values.Has(path[0]) {
+				values.Set(path[0], redactedValue)
+			}
+		}
+		return values.Encode(), true
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return "", false
+	}
+	for _, path := range redact {
+		redactPath(v, path)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return "", false
+	}
+	return string(out), true
+}
+
+// redactPath masks every value under path in a decoded JSON document.
+func redactPath(v any, path []string) {
+	switch node := v.(type) {
+	case []any:
+		for _, item := range node {
+			redactPath(item, path)
+		}
+	case map[string]any:
+		for key, child := range node {
+			if path[0] != "*" && path[0] != key {
+				continue
+			}
+			if len(path) == 1 {
+				node[key] = redactedValue
+			} else {
+				redactPath(child, path[1:])
+			}
+		}
+	}
+}
+
+// bodyLogWriter passes the response through while capturing its first max+1 bytes.
+type bodyLogWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	buf         bytes.Buffer
+	max         int
+}
+
+func (bw *bodyLogWriter) WriteHeader(statusCode int) {
+	if !bw.wroteHeader {
+		bw.wroteHeader = true
+		bw.statusCode = statusCode
+	}
+	bw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (bw *bodyLogWriter) Write(b []byte) (int, error) {
+	bw.wroteHeader = true
+	if room := bw.max + 1 - bw.buf.Len(); room > 0 {
+		bw.buf.Write(b[:min(room, len(b))])
+	}
+	return bw.ResponseWriter.Write(b)
+}
+
+func (bw *bodyLogWriter) Flush() {
+	if flusher, ok := bw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (bw *bodyLogWriter) Unwrap() http.ResponseWriter {
+	return bw.ResponseWriter
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const canonicalEventContextKey contextKey = "CanonicalEvent"
+
+// CanonicalLogFields are the fields logged when LoggerOptions.Canonical is set
+// and LoggerOptions.Fields is empty.
+var CanonicalLogFields = []LogField{FieldStatus, FieldDuration, FieldIP, FieldMethod, FieldPath, FieldBytes, FieldUserAgent, FieldRequestID, FieldVersion}
+
+// canonicalEvent collects the attributes and timings handlers contribute to the
+// canonical log line of a request. It is locked because handlers may record
+// timings from several goroutines.
+type canonicalEvent struct {
+	mu      sync.Mutex
+	attrs   []any
+	timings []timing
+}
+
+type timing struct {
+	name  string
+	total time.Duration
+	count int
+}
+
+// AddLogAttrs adds key-value pairs, e.g. "tenant", tenantID or "cart_items", n,
+// to the canonical log line of the request. It does nothing unless the request
+// is logged by RequestLoggerWith with Canonical set.
+func AddLogAttrs(ctx context.Context, args ...any) {
+	ev, ok := ctx.Value(canonicalEventContextKey).(*canonicalEvent)
+	if !ok {
+		return
+	}
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
+	ev.attrs = append(ev.attrs, args...)
+}
+
+// RecordTiming adds d to the time the request spent in name, e.g. "db" or
+// "cache". The canonical log line reports the total as <name>_ms and the number
+// of calls as <name>_count. It does nothing without a canonical logger.
+func RecordTiming(ctx context.Context, name string, d time.Duration) {
+	ev, ok := ctx.Value(canonicalEventContextKey).(*canonicalEvent)
+	if !ok {
+		return
+	}
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
+	for i := range ev.timings {
+		if ev.timings[i].name == name {
+			ev.timings[i].total += d
+			ev.timings[i].count++
+			return
+		}
+	}
+	ev.timings = append(ev.timings, timing{name: name, total: d, count: 1})
+}
+
+// StartTiming starts timing a call for RecordTiming and returns the function
+// that records it, e.g. in a repository:
+//
+//	defer middleware.StartTiming(ctx, "db")()
+func StartTiming(ctx context.Context, name string) func() {
+	start := time.Now()
+	return func() {
+		RecordTiming(ctx, name, time.Since(start))
+	}
+}
+
+// eventAttrs returns the attributes and timings recorded for the request.
+func eventAttrs(ctx context.Context) []any {
+	ev, ok := ctx.Value(canonicalEventContextKey).(*canonicalEvent)
+	if !ok {
+		return nil
+	}
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
+
+	attrs := append([]any(nil), ev.attrs...)
+	for _, t := range ev.timings {
+		ms := float64(t.total.Microseconds()) / 1000
+		attrs = append(attrs, t.name+"_ms", fmt.Sprintf("%.2f", ms), t.name+"_count", t.count)
+	}
+	return attrs
+}
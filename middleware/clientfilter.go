@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// ClientFilterOptions configures ClientFilter.
+type ClientFilterOptions struct {
+	// BlockedUserAgents rejects requests whose User-Agent matches any pattern.
+	BlockedUserAgents []*regexp.Regexp
+	// AllowedUserAgents, when non-empty, rejects requests whose User-Agent
+	// matches none of the patterns. Blocked patterns are checked first.
+	AllowedUserAgents []*regexp.Regexp
+	// VersionHeader names the header carrying the client's semantic version,
+	// e.g. "X-Client-Version". Version checks are disabled when empty.
+	VersionHeader string
+	// MinVersion is the oldest accepted client version, e.g. "2.4.0".
+	MinVersion string
+}
+
+// ClientFilter rejects requests from unwanted or outdated clients. Blocked or
+// unlisted User-Agents get a 403 APIError; a missing or older client version
+// gets a 426 APIError telling the client to upgrade. It panics if MinVersion is
+// not a valid semantic version.
+func ClientFilter(opts ClientFilterOptions) func(http.Handler) http.Handler {
+	var minVersion semver
+	if opts.VersionHeader != "" && opts.MinVersion != "" {
+		v, ok := parseSemver(opts.MinVersion)
+		if !ok {
+			panic("middleware: invalid MinVersion " + strconv.Quote(opts.MinVersion))
+		}
+		minVersion = v
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !userAgentAllowed(r.UserAgent(), opts) {
+				writeError(w, r, apierr.NewError(http.StatusForbidden, "forbidden_client", "client not allowed"))
+				return
+			}
+
+			if opts.VersionHeader != "" && opts.MinVersion != "" {
+				v, ok := parseSemver(r.Header.Get(opts.VersionHeader))
+				if !ok || v.less(minVersion) {
+					writeError(w, r, apierr.NewError(http.StatusUpgradeRequired, "upgrade_required", "client version "+opts.MinVersion+" or newer is required"))
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func userAgentAllowed(ua string, opts ClientFilterOptions) bool {
+	for _, re := range opts.BlockedUserAgents {
+		if re.MatchString(ua) {
+			return false
+		}
+	}
+	if len(opts.AllowedUserAgents) == 0 {
+		return true
+	}
+	for _, re := range opts.AllowedUserAgents {
+		if re.MatchString(ua) {
+			return true
+		}
+	}
+	return false
+}
+
+// semver is a parsed MAJOR.MINOR.PATCH[-PRERELEASE] version. Build metadata is ignored.
+type semver struct {
+	core       [3]int
+	prerelease string
+}
+
+// parseSemver parses versions like "2.4.1", "v2.4" or "2.4.1-beta.2". Missing
+// minor and patch components default to zero.
+func parseSemver(s string) (semver, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, pre, _ := strings.Cut(s, "-")
+
+	parts := strings.Split(s, ".")
+	if s == "" || len(parts) > 3 {
+		return semver{}, false
+	}
+
+	v := semver{prerelease: pre}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return semver{}, false
+		}
+		v.core[i] = n
+	}
+	return v, true
+}
+
+// less reports whether v precedes o. A prerelease precedes its release, and
+// prereleases of the same core version are compared per identifier.
+func (v semver) less(o semver) bool {
+	for i := range v.core {
+		if v.core[i] != o.core[i] {
+			return v.core[i] < o.core[i]
+		}
+	}
+	switch {
+	case v.prerelease == o.prerelease:
+		return false
+	case v.prerelease == "":
+		return false
+	case o.prerelease == "":
+		return true
+	}
+	return prereleaseLess(v.prerelease, o.prerelease)
+}
+
+func prereleaseLess(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			return an < bn
+		case aErr == nil:
+			return true
+		case bErr == nil:
+			return false
+		}
+		return as[i] < bs[i]
+	}
+	return len(as) < len(bs)
+}
@@ -0,0 +1,229 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Encoder wraps w in a writer producing one content coding.
+type Encoder func(w io.Writer) io.WriteCloser
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Encoder{
+		"gzip": func(w io.Writer) io.WriteCloser {
+			return gzip.NewWriter(w)
+		},
+		"deflate": func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		},
+	}
+)
+
+// RegisterCodec makes a content coding available to Compress, e.g. "br" or "zstd"
+// backed by a third-party encoder. Registering an existing name replaces it.
+func RegisterCodec(name string, enc Encoder) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[strings.ToLower(name)] = enc
+}
+
+// CompressOptions configures the Compress middleware.
+type CompressOptions struct {
+	// MinSize is the smallest body, in bytes, worth compressing. Defaults to 1024.
+	MinSize int
+	// Codecs lists allowed content codings in server preference order. Defaults to gzip.
+	Codecs []string
+	// ContentTypes is the allowlist of media types to compress. Entries ending in
+	// "/*" match a whole type. Defaults to JSON, JavaScript, XML and text/*.
+	ContentTypes []string
+}
+
+var defaultCompressTypes = []string{
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+	"text/*",
+}
+
+// Compress compresses response bodies according to opts. Bodies below MinSize,
+// disallowed content types and responses that already carry a Content-Encoding
+// are passed through untouched.
+func Compress(opts CompressOptions) func(http.Handler) http.Handler {
+	if opts.MinSize <= 0 {
+		opts.MinSize = 1024
+	}
+	if len(opts.Codecs) == 0 {
+		opts.Codecs = []string{"gzip"}
+	}
+	if len(opts.ContentTypes) == 0 {
+		opts.ContentTypes = defaultCompressTypes
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			codec, enc := negotiateCodec(r.Header.Get("Accept-Encoding"), opts.Codecs)
+			if enc == nil || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, opts: &opts, codec: codec, enc: enc, statusCode: http.StatusOK}
+			defer cw.finish()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateCodec picks the first allowed codec the client accepts with a non-zero q-value.
+func negotiateCodec(acceptEncoding string, allowed []string) (string, Encoder) {
+	if acceptEncoding == "" {
+		return "", nil
+	}
+
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		accepted[name] = q > 0
+	}
+
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	for _, name := range allowed {
+		name = strings.ToLower(name)
+		ok, listed := accepted[name]
+		if !listed {
+			ok = accepted["*"]
+		}
+		if enc, registered := codecs[name]; ok && registered {
+			return name, enc
+		}
+	}
+	return "", nil
+}
+
+type compressWriter struct {
+	http.ResponseWriter
+	opts       *CompressOptions
+	codec      string
+	enc        Encoder
+	statusCode int
+	buf        bytes.Buffer
+	decided    bool
+	cw         io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(statusCode int) {
+	if !cw.decided {
+		cw.statusCode = statusCode
+		return
+	}
+	cw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.decided {
+		if cw.cw != nil {
+			return cw.cw.Write(b)
+		}
+		return cw.ResponseWriter.Write(b)
+	}
+
+	cw.buf.Write(b)
+	if cw.buf.Len() >= cw.opts.MinSize {
+		if err := cw.decide(); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		_ = cw.decide()
+	}
+	if f, ok := cw.cw.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// decide commits to compressing or not based on what has been buffered so far,
+// then writes the header and the buffered bytes.
+func (cw *compressWriter) decide() error {
+	cw.decided = true
+
+	h := cw.Header()
+	if cw.buf.Len() >= cw.opts.MinSize && h.Get("Content-Encoding") == "" && cw.allowedType(h.Get("Content-Type")) {
+		h.Set("Content-Encoding", cw.codec)
+		h.Del("Content-Length")
+		cw.ResponseWriter.WriteHeader(cw.statusCode)
+		cw.cw = cw.enc(cw.ResponseWriter)
+		_, err := cw.cw.Write(cw.buf.Bytes())
+		return err
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.statusCode)
+	_, err := cw.ResponseWriter.Write(cw.buf.Bytes())
+	return err
+}
+
+func (cw *compressWriter) finish() {
+	if !cw.decided {
+		_ = cw.decide()
+	}
+	if cw.cw != nil {
+		_ = cw.cw.Close()
+	}
+}
+
+func (cw *compressWriter) allowedType(contentType string) bool {
+	if contentType == "" {
+		contentType = http.DetectContentType(cw.buf.Bytes())
+	}
+	return matchMediaType(contentType, cw.opts.ContentTypes)
+}
+
+// matchMediaType reports whether contentType matches one of allowed, where
+// entries ending in "/*" match a whole type.
+func matchMediaType(contentType string, allowed []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, a := range allowed {
+		if prefix, ok := strings.CutSuffix(a, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == a {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// RequireContentType rejects requests with a body whose media type is not one of
+// types with a 415 APIError. Parameters such as charset are ignored, so
+// "application/json; charset=utf-8" satisfies "application/json". Requests
+// without a body pass through.
+func RequireContentType(types ...string) func(http.Handler) http.Handler {
+	allowed := make([]string, len(types))
+	for i, t := range types {
+		allowed[i] = strings.ToLower(t)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength == 0 || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err == nil {
+				for _, t := range allowed {
+					if mediaType == t {
+						next.ServeHTTP(w, r)
+						return
+					}
+				}
+			}
+
+			writeError(w, r, apierr.NewError(http.StatusUnsupportedMediaType, "unsupported_media_type",
+				"content type must be "+strings.Join(types, " or ")))
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/metaerr"
+)
+
+var logger atomic.Pointer[slog.Logger]
+
+// SetLogger sets the logger used by this package, and by apierr.MapError,
+// wherever no logger is configured explicitly, e.g. in LoggerOptions. It lets
+// applications route package logs to their own handler, or capture them in
+// tests, without replacing slog.Default(). A nil logger restores the default.
+func SetLogger(l *slog.Logger) {
+	logger.Store(l)
+	apierr.SetLogger(l)
+}
+
+// loggerOr returns l, or the package logger when l is nil.
+func loggerOr(l *slog.Logger) *slog.Logger {
+	if l != nil {
+		return l
+	}
+	if l := logger.Load(); l != nil {
+		return l
+	}
+	return slog.Default()
+}
+
+// LoggerContextKey is the key for storing the request-scoped logger in request context.
+const LoggerContextKey contextKey = "Logger"
+
+// Log returns the request-scoped logger stored by InjectLogger, or the package
+// logger when there is none, so handler and repository logs correlate with the access log.
+func Log(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(LoggerContextKey).(*slog.Logger); ok {
+		return logger
+	}
+	return loggerOr(nil)
+}
+
+// InjectLogger stores a logger derived from base (the package logger when nil) in the
+// request context, pre-populated with request_id, method, route, the
+// authenticated user and, under Trace, trace_id and span_id. Route, user and
+// trace are resolved when a line is logged, so they reflect routing,
+// authentication and tracing performed further down the chain.
+func InjectLogger(base *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger := loggerOr(base)
+
+			attrs := []any{slog.String("method", r.Method)}
+			if id := GetRequestID(r.Context()); id != "" {
+				attrs = append(attrs, slog.String("request_id", id))
+			}
+			logger = slog.New(&requestHandler{Handler: logger.Handler(), r: r}).With(attrs...)
+
+			*r = *r.WithContext(context.WithValue(r.Context(), LoggerContextKey, logger))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestHandler adds route, user and context metadata such as trace_id when a
+// record is handled rather than when the logger is built, since they are only
+// known after routing, authentication and tracing have run.
+type requestHandler struct {
+	slog.Handler
+	r *http.Request
+}
+
+func (h *requestHandler) Handle(ctx context.Context, record slog.Record) error {
+	if h.r.Pattern != "" {
+		record.AddAttrs(slog.String("route", h.r.Pattern))
+	}
+	if claims, ok := GetClaims(h.r.Context()); ok {
+		record.AddAttrs(slog.String("user", claims.Subject()))
+	}
+	record.Add(metaerr.ContextMetadata(h.r.Context())...)
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *requestHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &requestHandler{Handler: h.Handler.WithAttrs(attrs), r: h.r}
+}
+
+func (h *requestHandler) WithGroup(name string) slog.Handler {
+	return &requestHandler{Handler: h.Handler.WithGroup(name), r: h.r}
+}
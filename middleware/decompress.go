@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// Decompress transparently decodes gzip and deflate request bodies according to
+// Content-Encoding. The decompressed body is capped at maxSize bytes to defuse
+// zip bombs; exceeding it yields a 413 APIError once the handler reads past it.
+func Decompress(maxSize int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+			if encoding == "" || encoding == "identity" || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var (
+				decoded io.ReadCloser
+				err     error
+			)
+			switch encoding {
+			case "gzip", "x-gzip":
+				decoded, err = gzip.NewReader(r.Body)
+			case "deflate":
+				decoded, err = zlib.NewReader(r.Body)
+			default:
+				writeError(w, r, apierr.NewError(http.StatusUnsupportedMediaType, "unsupported_encoding", "unsupported content encoding: "+encoding))
+				return
+			}
+			if err != nil {
+				writeError(w, r, apierr.NewError(http.StatusBadRequest, "invalid_encoding", "malformed "+encoding+" body"))
+				return
+			}
+			defer func() { _ = decoded.Close() }()
+
+			r.Body = http.MaxBytesReader(w, decoded, maxSize)
+			r.ContentLength = -1
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
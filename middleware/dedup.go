@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"net/http"
+	"sync"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// RequestKey derives the key under which identical requests are shared: the
+// method and request URI plus a digest of the headers that commonly change the
+// response (credentials and content negotiation), so different users never
+// share a response.
+func RequestKey(r *http.Request) string {
+	h := sha256.New()
+	for _, name := range []string{"Authorization", "Cookie", "Accept", "Accept-Encoding", "Accept-Language"} {
+		for _, v := range r.Header.Values(name) {
+			h.Write([]byte(name + ":" + v + "\n"))
+		}
+	}
+	return r.Method + " " + r.Host + r.URL.RequestURI() + " " + hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// Dedup collapses identical concurrent GET requests onto a single execution of
+// next and fans the buffered response out to every waiting client. key derives
+// the identity of a request and defaults to RequestKey. The shared execution
+// runs on the first request's context without its cancellation, so one client
+// disconnecting does not fail the others. The error the shared execution
+// reported through MapError is set on every request sharing it, so an outer
+// RequestLogger logs it for each of them.
+func Dedup(key KeyFunc) func(http.Handler) http.Handler {
+	if key == nil {
+		key = RequestKey
+	}
+	var g flightGroup
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			resp, ok := g.do(key(r), func() *bufferedResponse {
+				br := &bufferedResponse{header: make(http.Header), statusCode: http.StatusOK}
+				inner := r.WithContext(context.WithoutCancel(r.Context()))
+				next.ServeHTTP(br, inner)
+				br.err, _ = inner.Context().Value(apierr.OriginalErrorContextKey).(error)
+				return br
+			})
+			if !ok {
+				// The shared execution panicked; let this request fail on its own.
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if resp.err != nil {
+				*r = *r.WithContext(context.WithValue(r.Context(), apierr.OriginalErrorContextKey, resp.err))
+			}
+			maps.Copy(w.Header(), resp.header)
+			w.WriteHeader(resp.statusCode)
+			_, _ = w.Write(resp.body.Bytes())
+		})
+	}
+}
+
+// flightGroup is a minimal singleflight keyed by request.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done chan struct{}
+	resp *bufferedResponse
+}
+
+// do runs fn once per key among concurrent callers and returns its result to
+// all of them. ok is false for waiters when fn panicked; the panic propagates
+// to the caller that ran fn.
+func (g *flightGroup) do(key string, fn func() *bufferedResponse) (resp *bufferedResponse, ok bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if c, found := g.calls[key]; found {
+		g.mu.Unlock()
+		<-c.done
+		return c.resp, c.resp != nil
+	}
+	c := &flightCall{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	c.resp = fn()
+	return c.resp, true
+}
+
+// bufferedResponse records a complete response so it can be replayed.
+type bufferedResponse struct {
+	header      http.Header
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+	// err is the original error MapError recorded while producing the response.
+	err error
+}
+
+func (br *bufferedResponse) Header() http.Header {
+	return br.header
+}
+
+func (br *bufferedResponse) WriteHeader(statusCode int) {
+	if !br.wroteHeader {
+		br.wroteHeader = true
+		br.statusCode = statusCode
+	}
+}
+
+func (br *bufferedResponse) Write(b []byte) (int, error) {
+	br.wroteHeader = true
+	return br.body.Write(b)
+}
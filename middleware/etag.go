@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ETag buffers GET and HEAD responses up to maxSize bytes, tags successful ones
+// with a strong ETag derived from the body (unless the handler set one) and
+// answers matching If-None-Match requests with 304 Not Modified. Larger
+// responses, and responses the handler flushes, are streamed through untouched.
+func ETag(maxSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ew := &etagWriter{ResponseWriter: w, maxSize: maxSize, statusCode: http.StatusOK}
+			next.ServeHTTP(ew, r)
+			if ew.passthrough {
+				return
+			}
+
+			h := w.Header()
+			if ew.statusCode == http.StatusOK {
+				etag := h.Get("ETag")
+				if etag == "" {
+					sum := sha256.Sum256(ew.buf.Bytes())
+					etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+					h.Set("ETag", etag)
+				}
+				if etagMatches(r.Header.Get("If-None-Match"), etag) {
+					h.Del("Content-Length")
+					h.Del("Content-Type")
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				if h.Get("Content-Length") == "" {
+					h.Set("Content-Length", strconv.Itoa(ew.buf.Len()))
+				}
+			}
+
+			w.WriteHeader(ew.statusCode)
+			_, _ = w.Write(ew.buf.Bytes())
+		})
+	}
+}
+
+// etagMatches implements the weak comparison If-None-Match requires.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+type etagWriter struct {
+	http.ResponseWriter
+	maxSize     int
+	statusCode  int
+	buf         bytes.Buffer
+	passthrough bool
+}
+
+func (ew *etagWriter) WriteHeader(statusCode int) {
+	if ew.passthrough {
+		ew.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	ew.statusCode = statusCode
+}
+
+func (ew *etagWriter) Write(b []byte) (int, error) {
+	if ew.passthrough {
+		return ew.ResponseWriter.Write(b)
+	}
+	if ew.buf.Len()+len(b) <= ew.maxSize {
+		return ew.buf.Write(b)
+	}
+
+	// Too large to tag: flush what we have and stream the rest.
+	if err := ew.startPassthrough(); err != nil {
+		return 0, err
+	}
+	return ew.ResponseWriter.Write(b)
+}
+
+// Flush gives up tagging, since a streamed response can't be hashed before it
+// is sent, writes what is buffered and flushes the underlying writer.
+func (ew *etagWriter) Flush() {
+	if !ew.passthrough {
+		if err := ew.startPassthrough(); err != nil {
+			return
+		}
+	}
+	if flusher, ok := ew.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// startPassthrough writes the header and the buffered body, after which writes
+// go straight to the underlying writer.
+func (ew *etagWriter) startPassthrough() error {
+	ew.passthrough = true
+	ew.ResponseWriter.WriteHeader(ew.statusCode)
+	_, err := ew.ResponseWriter.Write(ew.buf.Bytes())
+	return err
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (ew *etagWriter) Unwrap() http.ResponseWriter {
+	return ew.ResponseWriter
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// FlagProvider reports whether a feature flag is enabled for a request. The
+// request is passed so providers can target flags by user, tenant or header.
+type FlagProvider interface {
+	Enabled(r *http.Request, flag string) (bool, error)
+}
+
+// FlagProviderFunc adapts a function to a FlagProvider.
+type FlagProviderFunc func(r *http.Request, flag string) (bool, error)
+
+// Enabled calls f(r, flag).
+func (f FlagProviderFunc) Enabled(r *http.Request, flag string) (bool, error) {
+	return f(r, flag)
+}
+
+// StaticFlags is a FlagProvider backed by a fixed map, e.g. loaded from a config file.
+type StaticFlags map[string]bool
+
+// Enabled reports the flag's value; unknown flags are disabled.
+func (f StaticFlags) Enabled(_ *http.Request, flag string) (bool, error) {
+	return f[flag], nil
+}
+
+// EnvFlags returns a FlagProvider reading flags from environment variables named
+// prefix + the upper-cased flag, with dashes and dots replaced by underscores.
+// Values are parsed with strconv.ParseBool; unset or invalid values are disabled.
+func EnvFlags(prefix string) FlagProvider {
+	replacer := strings.NewReplacer("-", "_", ".", "_")
+	return FlagProviderFunc(func(_ *http.Request, flag string) (bool, error) {
+		enabled, _ := strconv.ParseBool(os.Getenv(prefix + replacer.Replace(strings.ToUpper(flag))))
+		return enabled, nil
+	})
+}
+
+// FeatureGateOption configures FeatureGate.
+type FeatureGateOption func(*featureGateConfig)
+
+type featureGateConfig struct {
+	forbidden bool
+	logger    *slog.Logger
+}
+
+// GateLogger logs provider errors to l instead of the logger set with SetLogger.
+func GateLogger(l *slog.Logger) FeatureGateOption {
+	return func(c *featureGateConfig) {
+		c.logger = l
+	}
+}
+
+// GateForbidden answers disabled features with 403 instead of 404, for routes
+// whose existence need not be hidden.
+func GateForbidden() FeatureGateOption {
+	return func(c *featureGateConfig) {
+		c.forbidden = true
+	}
+}
+
+// FeatureGate only lets requests through when flag is enabled by provider.
+// Otherwise it answers with a 404 APIError so unreleased routes look absent.
+// Provider errors are logged and treated as disabled.
+func FeatureGate(flag string, provider FlagProvider, opts ...FeatureGateOption) func(http.Handler) http.Handler {
+	var cfg featureGateConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			enabled, err := provider.Enabled(r, flag)
+			if err != nil {
+				loggerOr(cfg.logger).Warn("feature flag lookup failed, treating as disabled", "flag", flag, "error", err)
+			}
+			if err != nil || !enabled {
+				if cfg.forbidden {
+					writeError(w, r, apierr.NewError(http.StatusForbidden, "feature_disabled", "feature not available"))
+				} else {
+					writeError(w, r, apierr.NewError(http.StatusNotFound, "not_found", "not found"))
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// CountryContextKey is the key for storing the resolved client country in request context.
+const CountryContextKey contextKey = "Country"
+
+// GetCountry returns the ISO 3166-1 alpha-2 country code resolved by GeoBlock,
+// or "" when unknown.
+func GetCountry(ctx context.Context) string {
+	country, _ := ctx.Value(CountryContextKey).(string)
+	return country
+}
+
+// GeoResolver maps an IP address to an ISO 3166-1 alpha-2 country code, e.g.
+// backed by a MaxMind or IP2Location database. It returns "" for addresses it
+// cannot place.
+type GeoResolver interface {
+	Country(ctx context.Context, ip netip.Addr) (string, error)
+}
+
+// GeoResolverFunc adapts a function to a GeoResolver.
+type GeoResolverFunc func(ctx context.Context, ip netip.Addr) (string, error)
+
+// Country calls f(ctx, ip).
+func (f GeoResolverFunc) Country(ctx context.Context, ip netip.Addr) (string, error) {
+	return f(ctx, ip)
+}
+
+// GeoBlockOption configures GeoBlock.
+type GeoBlockOption func(*geoBlockConfig)
+
+type geoBlockConfig struct {
+	allowUnknown bool
+}
+
+// GeoAllowUnknown lets through clients whose country cannot be resolved,
+// including private addresses and resolver failures.
+func GeoAllowUnknown() GeoBlockOption {
+	return func(c *geoBlockConfig) {
+		c.allowUnknown = true
+	}
+}
+
+// GeoBlock restricts access to clients in allowedCountries, resolving the
+// country of ClientIP with db. Clients from other countries get a 451 APIError
+// typed "geo_blocked"; clients whose country is unknown get a 403 unless
+// GeoAllowUnknown is set. The country is stored in the request context and
+// logged by RequestLogger.
+func GeoBlock(db GeoResolver, allowedCountries []string, opts ...GeoBlockOption) func(http.Handler) http.Handler {
+	var cfg geoBlockConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	allowed := make([]string, len(allowedCountries))
+	for i, c := range allowedCountries {
+		allowed[i] = strings.ToUpper(c)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var country string
+			if ip, err := netip.ParseAddr(ClientIP(r)); err == nil {
+				country, err = db.Country(r.Context(), ip.Unmap())
+				if err != nil {
+					country = ""
+				}
+			}
+			country = strings.ToUpper(country)
+
+			// Replace the request in place so RequestLogger records the country.
+			*r = *r.WithContext(context.WithValue(r.Context(), CountryContextKey, country))
+
+			switch {
+			case country == "" && !cfg.allowUnknown:
+				writeError(w, r, apierr.NewError(http.StatusForbidden, "geo_unknown", "client location could not be determined"))
+				return
+			case country != "" && !slices.Contains(allowed, country):
+				writeError(w, r, apierr.NewError(http.StatusUnavailableForLegalReasons, "geo_blocked", "not available in your region"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/piheta/apicore/response"
+)
+
+// LatencyHistograms counts request durations per route and status class
+// ("2xx", "5xx", ...) in fixed buckets, so latency SLIs are available without
+// a metrics library. Set it as LoggerOptions.Histograms, then serve it with
+// Handler or publish it with expvar:
+//
+//	hist := middleware.NewLatencyHistograms()
+//	expvar.Publish("http_latency", hist)
+//	r := router.New(middleware.RequestLoggerWith(middleware.LoggerOptions{Histograms: hist}))
+type LatencyHistograms struct {
+	bounds []time.Duration
+
+	mu     sync.Mutex
+	series map[histogramKey]*histogram
+}
+
+type histogramKey struct {
+	route string
+	class string
+}
+
+type histogram struct {
+	counts []int64 // per bound, then beyond the last
+	count  int64
+	sum    time.Duration
+}
+
+// HistogramBucket is one cumulative bucket: Count requests took at most LeMs
+// milliseconds. The last bucket has LeMs of -1 and counts every request.
+type HistogramBucket struct {
+	LeMs  float64 `json:"le_ms"`
+	Count int64   `json:"count"`
+}
+
+// HistogramSnapshot is the histogram of one route and status class.
+type HistogramSnapshot struct {
+	Route   string            `json:"route"`
+	Class   string            `json:"class"`
+	Count   int64             `json:"count"`
+	SumMs   float64           `json:"sum_ms"`
+	Buckets []HistogramBucket `json:"buckets"`
+}
+
+// NewLatencyHistograms creates histograms with the given bucket upper bounds,
+// which are sorted. It defaults to 1ms to 10s in roughly 1-2.5-5 steps.
+func NewLatencyHistograms(bounds ...time.Duration) *LatencyHistograms {
+	if len(bounds) == 0 {
+		bounds = latencyBounds
+	}
+	bounds = slices.Clone(bounds)
+	slices.Sort(bounds)
+	return &LatencyHistograms{bounds: slices.Compact(bounds), series: make(map[histogramKey]*histogram)}
+}
+
+// Observe records one request of route that answered status in d.
+func (h *LatencyHistograms) Observe(route string, status int, d time.Duration) {
+	key := histogramKey{route: route, class: strconv.Itoa(status/100) + "xx"}
+	i, _ := slices.BinarySearch(h.bounds, d)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{counts: make([]int64, len(h.bounds)+1)}
+		h.series[key] = s
+	}
+	s.counts[i]++
+	s.count++
+	s.sum += d
+}
+
+// Snapshot returns every histogram, sorted by route and class.
+func (h *LatencyHistograms) Snapshot() []HistogramSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	snaps := make([]HistogramSnapshot, 0, len(h.series))
+	for key, s := range h.series {
+		snap := HistogramSnapshot{
+			Route:   key.route,
+			Class:   key.class,
+			Count:   s.count,
+			SumMs:   float64(s.sum.Microseconds()) / 1000,
+			Buckets: make([]HistogramBucket, len(s.counts)),
+		}
+		var cumulative int64
+		for i, n := range s.counts {
+			cumulative += n
+			le := -1.0
+			if i < len(h.bounds) {
+				le = float64(h.bounds[i].Microseconds()) / 1000
+			}
+			snap.Buckets[i] = HistogramBucket{LeMs: le, Count: cumulative}
+		}
+		snaps = append(snaps, snap)
+	}
+	slices.SortFunc(snaps, func(a, b HistogramSnapshot) int {
+		if c := strings.Compare(a.Route, b.Route); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Class, b.Class)
+	})
+	return snaps
+}
+
+// Handler serves Snapshot as JSON. Register it behind authentication.
+func (h *LatencyHistograms) Handler() http.Handler {
+	return Public(func(w http.ResponseWriter, _ *http.Request) error {
+		return response.JSON(w, http.StatusOK, h.Snapshot())
+	})
+}
+
+// String returns Snapshot as JSON, implementing expvar.Var.
+func (h *LatencyHistograms) String() string {
+	b, _ := json.Marshal(h.Snapshot())
+	return string(b)
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// ErrorHooks receives server failures so services can page on-call or push to
+// incident tooling without wrapping the logger.
+type ErrorHooks interface {
+	// OnPanic is called for every panic raised by a handler.
+	OnPanic(r *http.Request, recovered any, stack []string)
+	// On5xx is called for every other response with a 5xx status. err is the
+	// original error recorded by MapError, or nil when the handler wrote the
+	// status itself.
+	On5xx(r *http.Request, status int, err error)
+}
+
+// ErrorHookFuncs adapts a pair of functions to ErrorHooks. Nil fields are skipped.
+type ErrorHookFuncs struct {
+	Panic       func(r *http.Request, recovered any, stack []string)
+	ServerError func(r *http.Request, status int, err error)
+}
+
+// OnPanic calls f.Panic if set.
+func (f ErrorHookFuncs) OnPanic(r *http.Request, recovered any, stack []string) {
+	if f.Panic != nil {
+		f.Panic(r, recovered, stack)
+	}
+}
+
+// On5xx calls f.ServerError if set.
+func (f ErrorHookFuncs) On5xx(r *http.Request, status int, err error) {
+	if f.ServerError != nil {
+		f.ServerError(r, status, err)
+	}
+}
+
+// Hooks invokes hooks for panics and 5xx responses of next. It works on either
+// side of Recover: panics already converted by an inner Recover are reported
+// through OnPanic, and panics passing through Hooks are reported and re-raised
+// for an outer Recover to answer.
+func Hooks(hooks ErrorHooks) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rr := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+
+			defer func() {
+				if v := recover(); v != nil {
+					if v != http.ErrAbortHandler {
+						hooks.OnPanic(r, v, captureStack())
+					}
+					panic(v)
+				}
+			}()
+
+			next.ServeHTTP(rr.wrap(), r)
+
+			if rr.statusCode < http.StatusInternalServerError {
+				return
+			}
+			err, _ := r.Context().Value(apierr.OriginalErrorContextKey).(error)
+			var pe *panicError
+			if errors.As(err, &pe) {
+				hooks.OnPanic(r, pe.value, pe.stack)
+				return
+			}
+			hooks.On5xx(r, rr.statusCode, err)
+		})
+	}
+}
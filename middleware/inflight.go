@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// MaxInFlight limits concurrently executing requests to n. Up to queueDepth
+// further requests wait at most wait for a free slot; everything beyond that is
+// shed immediately with a 503 APIError and Retry-After, keeping latency bounded
+// under overload instead of letting goroutines pile up.
+func MaxInFlight(n, queueDepth int, wait time.Duration) func(http.Handler) http.Handler {
+	slots := make(chan struct{}, n)
+	var queued atomic.Int64
+	retryAfter := strconv.Itoa(max(1, int(math.Ceil(wait.Seconds()))))
+
+	shed := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", retryAfter)
+		writeError(w, r, apierr.NewError(http.StatusServiceUnavailable, "overloaded", "server is overloaded, retry later"))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case slots <- struct{}{}:
+			default:
+				if queued.Add(1) > int64(queueDepth) {
+					queued.Add(-1)
+					shed(w, r)
+					return
+				}
+
+				timer := time.NewTimer(wait)
+				select {
+				case slots <- struct{}{}:
+					timer.Stop()
+					queued.Add(-1)
+				case <-timer.C:
+					queued.Add(-1)
+					shed(w, r)
+					return
+				case <-r.Context().Done():
+					timer.Stop()
+					queued.Add(-1)
+					writeError(w, r, r.Context().Err())
+					return
+				}
+			}
+
+			defer func() { <-slots }()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
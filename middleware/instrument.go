@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const chainTimingsContextKey contextKey = "ChainTimings"
+
+// NamedMiddleware is a Middleware labeled for InstrumentedChain.
+type NamedMiddleware struct {
+	Name       string
+	Middleware Middleware
+}
+
+// Named labels mw for InstrumentedChain.
+func Named(name string, mw Middleware) NamedMiddleware {
+	return NamedMiddleware{Name: name, Middleware: mw}
+}
+
+// LatencyObserver receives the time a request spent inside one named
+// middleware, excluding the layers and handler it wrapped, e.g. to feed a
+// histogram labeled by name.
+type LatencyObserver func(r *http.Request, name string, self time.Duration)
+
+// chainTimings accumulates, per layer, the nanoseconds spent below that layer.
+// Counters are atomic because middleware like Timeout runs inner layers on
+// another goroutine.
+type chainTimings struct {
+	inner []atomic.Int64
+}
+
+// InstrumentedChain is Chain with per-middleware latency measurement: after each
+// request, observe is called once per middleware with its self time, so the
+// layer adding tail latency (auth, logging, compression) stands out. Time spent
+// in h itself is reported under the name "handler".
+func InstrumentedChain(h http.Handler, observe LatencyObserver, mws ...NamedMiddleware) http.Handler {
+	handler := timedLayer(h, len(mws), "handler", observe)
+	for i := len(mws) - 1; i >= 0; i-- {
+		handler = timedLayer(mws[i].Middleware(innerProbe(handler, i)), i, mws[i].Name, observe)
+	}
+
+	n := len(mws)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Replace the request in place so middleware outside the chain still
+		// observes context changes made inside it.
+		*r = *r.WithContext(context.WithValue(r.Context(), chainTimingsContextKey, &chainTimings{inner: make([]atomic.Int64, n+1)}))
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// timedLayer measures the total time of h and reports it minus the time
+// recorded below layer i by its innerProbe.
+func timedLayer(h http.Handler, i int, name string, observe LatencyObserver) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timings, _ := r.Context().Value(chainTimingsContextKey).(*chainTimings)
+		start := time.Now()
+		h.ServeHTTP(w, r)
+		total := time.Since(start)
+		if timings == nil {
+			return
+		}
+		observe(r, name, max(total-time.Duration(timings.inner[i].Load()), 0))
+	})
+}
+
+// innerProbe records how long the layers below middleware i take.
+func innerProbe(next http.Handler, i int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		if timings, ok := r.Context().Value(chainTimingsContextKey).(*chainTimings); ok {
+			timings.inner[i].Add(int64(time.Since(start)))
+		}
+	})
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/piheta/apicore/response"
+)
+
+// JSONOptions applies default response.JSON options to every handler it wraps,
+// e.g. JSONOptions(response.HijackPrefix()) for a group of legacy browser-facing routes.
+func JSONOptions(opts ...response.Option) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(response.WithDefaults(w, opts...), r)
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// JWKS fetches and caches the keys of a remote JSON Web Key Set. Keys are
+// refreshed after RefreshInterval, or early when a token references an unknown
+// key ID (at most once per minute), so key rotation is picked up automatically.
+// Failed fetches are retried with backoff; meanwhile lookups fail fast with the
+// last error, or are served from the stale keys.
+type JWKS struct {
+	url             string
+	client          *http.Client
+	refreshInterval time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+	retry     backoff
+}
+
+// NewJWKS creates a JWKS for url refreshing every refreshInterval (one hour when zero).
+// A nil client uses a client with a 10 second timeout.
+func NewJWKS(url string, refreshInterval time.Duration, client *http.Client) *JWKS {
+	if refreshInterval <= 0 {
+		refreshInterval = time.Hour
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &JWKS{url: url, client: client, refreshInterval: refreshInterval}
+}
+
+// minRefetchInterval throttles refreshes triggered by unknown key IDs, and
+// caps the backoff between retries of a failing fetch.
+const minRefetchInterval = time.Minute
+
+// backoff throttles retries of a failing fetch, so requests arriving while an
+// endpoint is down fail fast with the last error instead of each waiting for
+// their own fetch. The delay doubles from one second up to minRefetchInterval.
+// It is guarded by its owner's mutex.
+type backoff struct {
+	next  time.Time
+	delay time.Duration
+	err   error
+}
+
+// wait returns the last error while retries are throttled, nil once a fetch may run.
+func (b *backoff) wait() error {
+	if time.Now().Before(b.next) {
+		return b.err
+	}
+	return nil
+}
+
+// done records the outcome of a fetch made with ctx. Failures caused by the
+// caller canceling ctx are not the endpoint's fault and don't delay retries.
+func (b *backoff) done(ctx context.Context, err error) {
+	if err == nil {
+		*b = backoff{}
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	b.delay = min(max(2*b.delay, time.Second), minRefetchInterval)
+	b.next = time.Now().Add(b.delay)
+	b.err = err
+}
+
+// Key returns the public key with the given key ID.
+func (j *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	age := time.Since(j.fetchedAt)
+	key, ok := j.keys[kid]
+	if ok && age < j.refreshInterval {
+		return key, nil
+	}
+
+	if j.keys == nil || age >= j.refreshInterval || age >= minRefetchInterval {
+		err := j.retry.wait()
+		if err == nil {
+			err = j.refresh(ctx)
+			j.retry.done(ctx, err)
+		}
+		if err != nil {
+			if ok {
+				// Serve the stale key rather than failing while the endpoint is down.
+				return key, nil
+			}
+			return nil, err
+		}
+	}
+
+	if key, ok := j.keys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (j *JWKS) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return fmt.Errorf("fetching JWKS: %w", err)
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetching JWKS: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decoding JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+
+	j.keys = keys
+	j.fetchedAt = time.Now()
+	return nil
+}
+
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,324 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// ClaimsContextKey is the key for storing validated token claims in request context.
+const ClaimsContextKey contextKey = "Claims"
+
+type contextKey string
+
+// Claims holds the payload of a validated JWT.
+type Claims map[string]any
+
+// Subject returns the "sub" claim.
+func (c Claims) Subject() string {
+	s, _ := c["sub"].(string)
+	return s
+}
+
+// Issuer returns the "iss" claim.
+func (c Claims) Issuer() string {
+	s, _ := c["iss"].(string)
+	return s
+}
+
+// Audience returns the "aud" claim, which may be a single string or a list.
+func (c Claims) Audience() []string {
+	if aud, ok := c["aud"].(string); ok {
+		return []string{aud}
+	}
+	return c.stringList("aud")
+}
+
+// Scopes returns the granted scopes from the space-separated "scope" claim or the "scp" claim.
+func (c Claims) Scopes() []string {
+	if scope, ok := c["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+	return c.stringList("scp")
+}
+
+// Roles returns the "roles" claim.
+func (c Claims) Roles() []string {
+	return c.stringList("roles")
+}
+
+// stringList reads a claim holding either a space-separated string or a list of strings.
+func (c Claims) stringList(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// GetClaims returns the claims placed in context by Protected or OIDC.
+func GetClaims(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(ClaimsContextKey).(Claims)
+	return claims, ok
+}
+
+// JWTConfig configures token validation for Protected.
+type JWTConfig struct {
+	// Algorithms lists the accepted "alg" values, e.g. "HS256", "RS256", "ES256".
+	// Defaults to HS256 when Secret is set and RS256/ES256 otherwise.
+	Algorithms []string
+	// Secret is the shared key for HMAC algorithms.
+	Secret []byte
+	// PublicKeys maps key IDs to *rsa.PublicKey or *ecdsa.PublicKey. A key stored
+	// under "" is used for tokens without a "kid" header.
+	PublicKeys map[string]crypto.PublicKey
+	// JWKS resolves keys from a remote JSON Web Key Set. Share one instance across
+	// routes so the key cache is shared too.
+	JWKS *JWKS
+	// Issuer, when set, must match the "iss" claim.
+	Issuer string
+	// Audience, when set, must appear in the "aud" claim.
+	Audience string
+	// Leeway tolerates clock skew when checking "exp" and "nbf".
+	Leeway time.Duration
+}
+
+// Protected is Public for authenticated routes: it validates the Bearer JWT,
+// stores its claims in the request context and answers with a 401 APIError
+// when the token is missing or invalid.
+func Protected(h APIFunc, cfg JWTConfig) http.HandlerFunc {
+	if len(cfg.Algorithms) == 0 {
+		if len(cfg.Secret) > 0 {
+			cfg.Algorithms = []string{"HS256"}
+		} else {
+			cfg.Algorithms = []string{"RS256", "ES256"}
+		}
+	}
+
+	public := Public(h)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if authenticate(w, r, cfg.verify) {
+			public(w, r)
+		}
+	}
+}
+
+// authenticate validates the request's Bearer token with verify and stores the
+// claims in the request context, writing a 401 APIError and returning false on failure.
+func authenticate(w http.ResponseWriter, r *http.Request, verify func(context.Context, string) (Claims, error)) bool {
+	token, ok := bearerToken(r)
+	if !ok {
+		unauthorized(w, r, "missing bearer token", nil)
+		return false
+	}
+
+	claims, err := verify(r.Context(), token)
+	if err != nil {
+		unauthorized(w, r, tokenErrorMessage(err), err)
+		return false
+	}
+
+	// Replace the request in place so outer middleware like RequestLogger
+	// still observes the context changes made further down the chain.
+	*r = *r.WithContext(context.WithValue(r.Context(), ClaimsContextKey, claims))
+	return true
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// unauthorized writes a 401 APIError with a Bearer challenge. The cause is kept
+// for RequestLogger but never exposed to the client.
+func unauthorized(w http.ResponseWriter, r *http.Request, msg string, cause error) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	apiErr := apierr.NewError(http.StatusUnauthorized, "unauthorized", msg)
+	if cause == nil {
+		writeError(w, r, apiErr)
+		return
+	}
+	writeError(w, r, fmt.Errorf("%w: %w", apiErr, cause))
+}
+
+// tokenErrorMessage returns the client-facing message for a validation failure.
+func tokenErrorMessage(err error) string {
+	if errors.Is(err, errTokenExpired) {
+		return "token expired"
+	}
+	return "invalid token"
+}
+
+var (
+	errMalformedToken = errors.New("malformed token")
+	errTokenSignature = errors.New("invalid token signature")
+	errTokenExpired   = errors.New("token expired")
+)
+
+// verify checks the token signature and registered claims, returning its claims.
+func (cfg *JWTConfig) verify(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errMalformedToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, errMalformedToken
+	}
+	if !slices.Contains(cfg.Algorithms, header.Alg) {
+		return nil, fmt.Errorf("unsupported token algorithm %q", header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errMalformedToken
+	}
+	if err := cfg.verifySignature(ctx, header.Alg, header.Kid, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, errMalformedToken
+	}
+	if err := cfg.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func (cfg *JWTConfig) validateClaims(claims Claims) error {
+	now := time.Now()
+	if exp, ok := claims["exp"].(float64); ok && now.After(time.Unix(int64(exp), 0).Add(cfg.Leeway)) {
+		return errTokenExpired
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Before(time.Unix(int64(nbf), 0).Add(-cfg.Leeway)) {
+		return errors.New("token not yet valid")
+	}
+	if cfg.Issuer != "" && claims.Issuer() != cfg.Issuer {
+		return errors.New("invalid token issuer")
+	}
+	if cfg.Audience != "" && !slices.Contains(claims.Audience(), cfg.Audience) {
+		return errors.New("invalid token audience")
+	}
+	return nil
+}
+
+func (cfg *JWTConfig) verifySignature(ctx context.Context, alg, kid, signingInput string, sig []byte) error {
+	hashFunc, newHash := algorithmHash(alg)
+	if newHash == nil {
+		return fmt.Errorf("unsupported token algorithm %q", alg)
+	}
+
+	if strings.HasPrefix(alg, "HS") {
+		mac := hmac.New(newHash, cfg.Secret)
+		mac.Write([]byte(signingInput))
+		if len(cfg.Secret) == 0 || !hmac.Equal(sig, mac.Sum(nil)) {
+			return errTokenSignature
+		}
+		return nil
+	}
+
+	key, err := cfg.publicKey(ctx, kid)
+	if err != nil {
+		return err
+	}
+
+	h := newHash()
+	h.Write([]byte(signingInput))
+	digest := h.Sum(nil)
+
+	switch {
+	case strings.HasPrefix(alg, "RS"):
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPKCS1v15(pub, hashFunc, digest, sig) != nil {
+			return errTokenSignature
+		}
+	case strings.HasPrefix(alg, "PS"):
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPSS(pub, hashFunc, digest, sig, nil) != nil {
+			return errTokenSignature
+		}
+	case strings.HasPrefix(alg, "ES"):
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errTokenSignature
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errTokenSignature
+		}
+		rInt := new(big.Int).SetBytes(sig[:size])
+		sInt := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, digest, rInt, sInt) {
+			return errTokenSignature
+		}
+	default:
+		return fmt.Errorf("unsupported token algorithm %q", alg)
+	}
+	return nil
+}
+
+func (cfg *JWTConfig) publicKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if key, ok := cfg.PublicKeys[kid]; ok {
+		return key, nil
+	}
+	if cfg.JWKS != nil {
+		return cfg.JWKS.Key(ctx, kid)
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func algorithmHash(alg string) (crypto.Hash, func() hash.Hash) {
+	if len(alg) != 5 {
+		return 0, nil
+	}
+	switch alg[2:] {
+	case "256":
+		return crypto.SHA256, sha256.New
+	case "384":
+		return crypto.SHA384, sha512.New384
+	case "512":
+		return crypto.SHA512, sha512.New
+	}
+	return 0, nil
+}
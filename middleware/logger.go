@@ -0,0 +1,262 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/metaerr"
+)
+
+// LogField names an attribute RequestLoggerWith can emit for each request.
+type LogField string
+
+// Fields available to RequestLoggerWith.
+const (
+	FieldStatus    LogField = "status"
+	FieldDuration  LogField = "ms"
+	FieldIP        LogField = "ip"
+	FieldMethod    LogField = "method"
+	FieldPath      LogField = "path"
+	FieldBytes     LogField = "bytes"
+	FieldUserAgent LogField = "user_agent"
+	FieldRequestID LogField = "request_id"
+	// FieldVersion logs LoggerOptions.Version, so lines can be attributed to a
+	// deploy. It is omitted when the version is unknown.
+	FieldVersion LogField = "version"
+)
+
+// DefaultLogFields are the fields logged when LoggerOptions.Fields is empty.
+var DefaultLogFields = []LogField{FieldStatus, FieldDuration, FieldIP, FieldMethod, FieldPath, FieldBytes, FieldVersion}
+
+// LoggerOptions configures RequestLoggerWith.
+type LoggerOptions struct {
+	// Logger receives the request logs. Defaults to the logger set with
+	// SetLogger, or slog.Default(), at log time. Wrap its handler with
+	// NewAsyncHandler to keep log I/O off the request path.
+	Logger *slog.Logger
+	// Sinks, when set, replace Logger with a fan-out to each sink, each with its
+	// own level filter, e.g. everything to a rotating file but only errors to syslog.
+	Sinks []LogSink
+	// Fields selects and orders the logged attributes. Defaults to DefaultLogFields.
+	Fields []LogField
+	// Include, when set, logs only requests it returns true for.
+	Include func(r *http.Request) bool
+	// Exclude, when set, skips requests it returns true for.
+	Exclude func(r *http.Request) bool
+	// SampleRates maps status classes (2 for 2xx, 3 for 3xx, ...) to the fraction
+	// of requests logged, e.g. {2: 0.01} logs 1% of successes. Unlisted classes
+	// are always logged. Sampled lines carry a sample_rate attribute for reweighting.
+	SampleRates map[int]float64
+	// SlowThreshold, when set, logs requests taking longer at Warn or above with
+	// slow=true, bypassing sampling.
+	SlowThreshold time.Duration
+	// OnSlow is called for every slow request, e.g. to page or count them.
+	OnSlow func(r *http.Request, duration time.Duration)
+	// Canonical logs one wide "canonical-log-line" event per request instead of
+	// the REQ line: the request fields (CanonicalLogFields by default), the
+	// authenticated user, the tenant, error metadata, and everything handlers
+	// recorded with AddLogAttrs, RecordTiming and StartTiming, such as the
+	// time spent in the database and cache.
+	Canonical bool
+	// Tenant, when set, returns the tenant logged on canonical lines, e.g. from
+	// a host label or a claim.
+	Tenant func(r *http.Request) string
+	// Format selects structured slog records (the default) or Apache-style
+	// FormatCommon and FormatCombined text lines, for log ingestion expecting
+	// access logs. Fields, Canonical and the slog settings do not apply to text
+	// lines; filtering and sampling do.
+	Format LogFormat
+	// Output receives text lines. Defaults to os.Stdout.
+	Output io.Writer
+	// Version is logged as FieldVersion. Defaults to ReadBuildInfo().Version.
+	Version string
+	// Histograms, when set, records the duration of every request passing
+	// Include and Exclude, sampled out or not, by route and status class.
+	Histograms *LatencyHistograms
+}
+
+// RequestLogger logs every HTTP request with method, path, status, and duration.
+// Use RequestLoggerWith to filter requests, e.g. Exclude: SkipPaths("/healthz").
+func RequestLogger(next http.Handler) http.Handler {
+	return RequestLoggerWith(LoggerOptions{})(next)
+}
+
+// SkipPaths returns a predicate matching requests for any of the given exact
+// paths, for use as LoggerOptions.Exclude to silence health checks and probes.
+func SkipPaths(paths ...string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		return slices.Contains(paths, r.URL.Path)
+	}
+}
+
+// RequestLoggerWith returns a request logger configured by opts.
+func RequestLoggerWith(opts LoggerOptions) func(http.Handler) http.Handler {
+	if len(opts.Fields) == 0 {
+		opts.Fields = DefaultLogFields
+		if opts.Canonical {
+			opts.Fields = CanonicalLogFields
+		}
+	}
+	if len(opts.Sinks) > 0 {
+		opts.Logger = slog.New(NewSinkHandler(opts.Sinks...))
+	}
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+	if opts.Version == "" {
+		opts.Version = ReadBuildInfo().Version
+	}
+	out := &lockedWriter{w: opts.Output}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rr := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+			if opts.Canonical && opts.Format == FormatSlog {
+				*r = *r.WithContext(context.WithValue(r.Context(), canonicalEventContextKey, &canonicalEvent{}))
+			}
+
+			next.ServeHTTP(rr.wrap(), r)
+
+			if opts.Include != nil && !opts.Include(r) {
+				return
+			}
+			if opts.Exclude != nil && opts.Exclude(r) {
+				return
+			}
+
+			duration := time.Since(start)
+			if opts.Histograms != nil {
+				opts.Histograms.Observe(routeLabel(r), rr.statusCode, duration)
+			}
+			slow := opts.SlowThreshold > 0 && duration > opts.SlowThreshold
+			if slow && opts.OnSlow != nil {
+				opts.OnSlow(r, duration)
+			}
+
+			rate, sampled := opts.SampleRates[rr.statusCode/100]
+			if sampled && !slow && rand.Float64() >= rate {
+				return
+			}
+
+			if opts.Format != FormatSlog {
+				_, _ = io.WriteString(out, accessLine(opts.Format, r, rr, start))
+				return
+			}
+			opts.log(r, rr, duration, rate, sampled && !slow, slow)
+		})
+	}
+}
+
+func (opts *LoggerOptions) log(r *http.Request, rr *responseRecorder, duration time.Duration, sampleRate float64, sampled, slow bool) {
+	status := rr.statusCode
+
+	attrs := make([]any, 0, len(opts.Fields)+7)
+	for _, field := range opts.Fields {
+		if field == FieldVersion {
+			if opts.Version != "" {
+				attrs = append(attrs, slog.String(string(field), opts.Version))
+			}
+			continue
+		}
+		if attr, ok := fieldAttr(field, r, rr, duration); ok {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	if sampled {
+		attrs = append(attrs, slog.Float64("sample_rate", sampleRate))
+	}
+	if variant, ok := GetVariant(r.Context()); ok {
+		attrs = append(attrs, slog.String("experiment", variant.Experiment), slog.String("variant", variant.Name))
+	}
+	if pattern := GetRoutePattern(r.Context()); pattern != "" {
+		attrs = append(attrs, slog.String("route", pattern))
+	}
+	if country := GetCountry(r.Context()); country != "" {
+		attrs = append(attrs, slog.String("country", country))
+	}
+	attrs = append(attrs, metaerr.ContextMetadata(r.Context())...)
+	msg := "REQ"
+	if opts.Canonical {
+		msg = "canonical-log-line"
+		if claims, ok := GetClaims(r.Context()); ok {
+			attrs = append(attrs, slog.String("user", claims.Subject()))
+		}
+		if opts.Tenant != nil {
+			if tenant := opts.Tenant(r); tenant != "" {
+				attrs = append(attrs, slog.String("tenant", tenant))
+			}
+		}
+		attrs = append(attrs, eventAttrs(r.Context())...)
+	}
+
+	level := slog.LevelInfo
+
+	// Log based on status code
+	if status >= http.StatusBadRequest {
+		// Include original error details and metadata if available
+		if originalErr, ok := r.Context().Value(apierr.OriginalErrorContextKey).(error); ok {
+			attrs = append(attrs, slog.String("error_detail", originalErr.Error()))
+
+			// Add structured metadata from the original error
+			metadata := metaerr.GetMetadata(originalErr)
+			attrs = append(attrs, metadata...)
+		}
+
+		attrs = append(attrs, slog.String("error", http.StatusText(status)))
+
+		level = slog.LevelWarn
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+	}
+
+	if slow {
+		attrs = append(attrs, slog.Bool("slow", true))
+		level = max(level, slog.LevelWarn)
+	}
+
+	loggerOr(opts.Logger).Log(r.Context(), level, msg, attrs...)
+}
+
+func fieldAttr(field LogField, r *http.Request, rr *responseRecorder, duration time.Duration) (slog.Attr, bool) {
+	switch field {
+	case FieldStatus:
+		return slog.Int(string(field), rr.statusCode), true
+	case FieldDuration:
+		durationMs := float64(duration.Microseconds()) / 1000
+		return slog.String(string(field), fmt.Sprintf("%.2f", durationMs)), true
+	case FieldIP:
+		if ip, ok := r.Context().Value(ClientIPContextKey).(string); ok {
+			return slog.String(string(field), ip), true
+		}
+		return slog.String(string(field), r.RemoteAddr), true
+	case FieldMethod:
+		return slog.String(string(field), r.Method), true
+	case FieldPath:
+		path := r.URL.Path
+		if r.URL.RawQuery != "" {
+			path += "?" + r.URL.RawQuery
+		}
+		return slog.String(string(field), path), true
+	case FieldBytes:
+		return slog.Int64(string(field), rr.bytesWritten), true
+	case FieldUserAgent:
+		return slog.String(string(field), r.UserAgent()), true
+	case FieldRequestID:
+		if id := GetRequestID(r.Context()); id != "" {
+			return slog.String(string(field), id), true
+		}
+		return slog.String(string(field), r.Header.Get(RequestIDHeader)), true
+	}
+	return slog.Attr{}, false
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/response"
+)
+
+// LogLevel is the level variable LogLevelHandler switches by default. Give it
+// to the handlers of the loggers passed to SetLogger and LoggerOptions, or to
+// LogSink.MinLevel, so request and package logs follow it:
+//
+//	h := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: middleware.LogLevel})
+//	middleware.SetLogger(slog.New(h))
+var LogLevel = new(slog.LevelVar)
+
+// logLevelState is the body LogLevelHandler answers with.
+type logLevelState struct {
+	Level string     `json:"level"`
+	Until *time.Time `json:"until,omitempty"`
+}
+
+// logLevelChange is the body LogLevelHandler accepts. For is a duration such
+// as "15m" after which the previous level is restored.
+type logLevelChange struct {
+	Level string `json:"level"`
+	For   string `json:"for"`
+}
+
+// LogLevelHandler serves and switches lv, or LogLevel when lv is nil, so DEBUG
+// logging can be enabled during an incident without redeploying. GET answers
+// {"level":"INFO"}; PUT accepts {"level":"debug","for":"15m"}, where the
+// optional "for" restores the previous level after that duration. Register it
+// behind authentication, e.g.
+//
+//	r.HandleHTTP("", "/debug/loglevel", middleware.LogLevelHandler(nil), adminOnly)
+func LogLevelHandler(lv *slog.LevelVar) http.Handler {
+	if lv == nil {
+		lv = LogLevel
+	}
+	var (
+		mu       sync.Mutex
+		revert   *time.Timer
+		revertTo slog.Level
+		until    time.Time
+	)
+	state := func() logLevelState {
+		s := logLevelState{Level: lv.Level().String()}
+		if revert != nil {
+			s.Until = &until
+		}
+		return s
+	}
+
+	return Public(func(w http.ResponseWriter, r *http.Request) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			return response.JSON(w, http.StatusOK, state())
+		case http.MethodPut:
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT")
+			return apierr.NewError(http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		}
+
+		var change logLevelChange
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&change); err != nil {
+			return err
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(change.Level)); err != nil {
+			return apierr.NewError(http.StatusBadRequest, "invalid_level", "level must be DEBUG, INFO, WARN or ERROR")
+		}
+		var d time.Duration
+		if change.For != "" {
+			var err error
+			if d, err = time.ParseDuration(change.For); err != nil || d <= 0 {
+				return apierr.NewError(http.StatusBadRequest, "invalid_duration", `for must be a positive duration such as "15m"`)
+			}
+		}
+
+		// A temporary change restores the level from before the first one, so
+		// extending an incident window does not make DEBUG permanent.
+		previous := lv.Level()
+		if revert != nil && revert.Stop() {
+			previous = revertTo
+		}
+		revert = nil
+		lv.Set(level)
+		if d > 0 {
+			revertTo = previous
+			until = time.Now().Add(d)
+			var t *time.Timer
+			t = time.AfterFunc(d, func() {
+				mu.Lock()
+				defer mu.Unlock()
+				if revert == t {
+					lv.Set(previous)
+					revert = nil
+				}
+			})
+			revert = t
+		}
+		loggerOr(nil).Info("log level changed", "level", level.String(), "for", d.String())
+		return response.JSON(w, http.StatusOK, state())
+	})
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// LogSink is one destination for request logs. Any slog.Handler works: a JSON
+// handler on stdout, a text handler on a RotatingFile or a log/syslog writer,
+// or an OpenTelemetry bridge exporting OTLP logs.
+type LogSink struct {
+	Handler slog.Handler
+	// MinLevel drops records below this level for this sink only. Defaults to Info.
+	MinLevel slog.Leveler
+}
+
+// NewSinkHandler returns a slog.Handler fanning records out to every sink whose
+// level filter admits them. Errors from individual sinks are joined.
+func NewSinkHandler(sinks ...LogSink) slog.Handler {
+	return &sinkHandler{sinks: sinks}
+}
+
+type sinkHandler struct {
+	sinks []LogSink
+}
+
+func (h *sinkHandler) admits(ctx context.Context, s LogSink, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if s.MinLevel != nil {
+		minLevel = s.MinLevel.Level()
+	}
+	return level >= minLevel && s.Handler.Enabled(ctx, level)
+}
+
+func (h *sinkHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, s := range h.sinks {
+		if h.admits(ctx, s, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *sinkHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, s := range h.sinks {
+		if h.admits(ctx, s, r.Level) {
+			if err := s.Handler.Handle(ctx, r.Clone()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *sinkHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	sinks := make([]LogSink, len(h.sinks))
+	for i, s := range h.sinks {
+		sinks[i] = LogSink{Handler: s.Handler.WithAttrs(attrs), MinLevel: s.MinLevel}
+	}
+	return &sinkHandler{sinks: sinks}
+}
+
+func (h *sinkHandler) WithGroup(name string) slog.Handler {
+	sinks := make([]LogSink, len(h.sinks))
+	for i, s := range h.sinks {
+		sinks[i] = LogSink{Handler: s.Handler.WithGroup(name), MinLevel: s.MinLevel}
+	}
+	return &sinkHandler{sinks: sinks}
+}
+
+// RotatingFile is an io.WriteCloser appending to a file and rotating it once
+// it exceeds a size limit, keeping a fixed number of numbered backups
+// (access.log.1 is the most recent). It is safe for concurrent use.
+type RotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	backups  int
+	file     *os.File
+	size     int64
+}
+
+// NewRotatingFile opens path for appending, rotating when it grows beyond
+// maxBytes and keeping up to backups old files.
+func NewRotatingFile(path string, maxBytes int64, backups int) (*RotatingFile, error) {
+	rf := &RotatingFile{path: path, maxBytes: maxBytes, backups: backups}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(rf.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	rf.file, rf.size = f, info.Size()
+	return nil
+}
+
+// Write appends p, rotating first if p would push the file past the size limit.
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file == nil {
+		return 0, os.ErrClosed
+	}
+	if rf.maxBytes > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxBytes {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+func (rf *RotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return err
+	}
+	rf.file = nil
+
+	if rf.backups > 0 {
+		_ = os.Remove(fmt.Sprintf("%s.%d", rf.path, rf.backups))
+		for i := rf.backups - 1; i >= 1; i-- {
+			_ = os.Rename(fmt.Sprintf("%s.%d", rf.path, i), fmt.Sprintf("%s.%d", rf.path, i+1))
+		}
+		if err := os.Rename(rf.path, rf.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Truncate(rf.path, 0); err != nil {
+		return err
+	}
+	return rf.open()
+}
+
+// Close closes the current file.
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+// MaxBodyContextKey is the key for storing the MaxBody limit in request context.
+const MaxBodyContextKey contextKey = "MaxBody"
+
+// GetMaxBody returns the body limit set by MaxBody, so body readers such as
+// bind.JSON can honor a route's larger or smaller limit instead of their own default.
+func GetMaxBody(ctx context.Context) (int64, bool) {
+	n, ok := ctx.Value(MaxBodyContextKey).(int64)
+	return n, ok
+}
+
+// MaxBody limits request bodies to n bytes. Reads past the limit fail with
+// *http.MaxBytesError, which MapError turns into a 413 "payload_too_large" APIError.
+func MaxBody(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				writeError(w, r, &http.MaxBytesError{Limit: n})
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			*r = *r.WithContext(context.WithValue(r.Context(), MaxBodyContextKey, n))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// OIDCConfig configures an OIDCProvider.
+type OIDCConfig struct {
+	// IssuerURL is the provider's issuer, e.g. "https://accounts.example.com".
+	IssuerURL string
+	// ClientID, when set, must appear in the token's "aud" claim.
+	ClientID string
+	// Algorithms lists accepted signing algorithms. Defaults to RS256 and ES256.
+	Algorithms []string
+	// JWKSRefresh controls how often signing keys are refetched. Defaults to one hour.
+	JWKSRefresh time.Duration
+	// Leeway tolerates clock skew when checking "exp" and "nbf".
+	Leeway time.Duration
+	// HTTPClient is used for discovery and key fetches.
+	HTTPClient *http.Client
+}
+
+// OIDCProvider validates ID and access tokens issued by an OpenID Connect
+// provider. Discovery runs on first use and is retried with backoff until it
+// succeeds; the discovered JWKS is cached and refreshed on key rotation.
+type OIDCProvider struct {
+	cfg OIDCConfig
+
+	mu    sync.Mutex
+	jwt   *JWTConfig
+	retry backoff
+}
+
+var errOIDCUnavailable = errors.New("oidc discovery failed")
+
+// NewOIDCProvider creates an OIDCProvider for cfg.
+func NewOIDCProvider(cfg OIDCConfig) *OIDCProvider {
+	cfg.IssuerURL = strings.TrimSuffix(cfg.IssuerURL, "/")
+	if len(cfg.Algorithms) == 0 {
+		cfg.Algorithms = []string{"RS256", "ES256"}
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &OIDCProvider{cfg: cfg}
+}
+
+// Verify validates token and returns its claims.
+func (p *OIDCProvider) Verify(ctx context.Context, token string) (Claims, error) {
+	cfg, err := p.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.verify(ctx, token)
+}
+
+func (p *OIDCProvider) config(ctx context.Context) (*JWTConfig, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.jwt != nil {
+		return p.jwt, nil
+	}
+
+	if err := p.retry.wait(); err != nil {
+		return nil, err
+	}
+	discovery, err := p.discover(ctx)
+	if err == nil && discovery.Issuer != p.cfg.IssuerURL {
+		err = fmt.Errorf("issuer mismatch %q", discovery.Issuer)
+	}
+	if err != nil {
+		err = fmt.Errorf("%w: %w", errOIDCUnavailable, err)
+	}
+	p.retry.done(ctx, err)
+	if err != nil {
+		return nil, err
+	}
+
+	p.jwt = &JWTConfig{
+		Algorithms: p.cfg.Algorithms,
+		JWKS:       NewJWKS(discovery.JWKSURI, p.cfg.JWKSRefresh, p.cfg.HTTPClient),
+		Issuer:     discovery.Issuer,
+		Audience:   p.cfg.ClientID,
+		Leeway:     p.cfg.Leeway,
+	}
+	return p.jwt, nil
+}
+
+type oidcDiscovery struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.IssuerURL+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var d oidcDiscovery
+	if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+		return nil, err
+	}
+	if d.JWKSURI == "" {
+		return nil, errors.New("discovery document has no jwks_uri")
+	}
+	return &d, nil
+}
+
+// OIDC authenticates requests with Bearer tokens validated by p, storing the
+// claims in the request context. Invalid tokens get a 401 APIError; a provider
+// that cannot be reached yields a 503.
+func OIDC(p *OIDCProvider) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, err := p.config(r.Context()); err != nil {
+				writeError(w, r, fmt.Errorf("%w: %w", apierr.NewError(http.StatusServiceUnavailable, "unavailable", "authentication provider unavailable"), err))
+				return
+			}
+			if authenticate(w, r, p.Verify) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
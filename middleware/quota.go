@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// KeyBySubject keys requests by the authenticated subject, leaving
+// unauthenticated requests unkeyed.
+func KeyBySubject(r *http.Request) string {
+	claims, _ := GetClaims(r.Context())
+	return claims.Subject()
+}
+
+// QuotaPeriod is a calendar window, in UTC, over which requests are counted.
+type QuotaPeriod string
+
+// Quota periods.
+const (
+	QuotaDaily   QuotaPeriod = "daily"
+	QuotaMonthly QuotaPeriod = "monthly"
+)
+
+// window returns the start of the period containing t and the start of the next one.
+func (p QuotaPeriod) window(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	if p == QuotaMonthly {
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	}
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// QuotaLimit caps the number of requests a principal may make per period.
+type QuotaLimit struct {
+	Period QuotaPeriod
+	Limit  int64
+}
+
+// QuotaProvider returns the limits that apply to a principal, e.g. based on
+// their plan. Returning no limits exempts the request.
+type QuotaProvider func(r *http.Request, principal string) ([]QuotaLimit, error)
+
+// StaticQuota returns a QuotaProvider applying the same limits to everyone.
+func StaticQuota(limits ...QuotaLimit) QuotaProvider {
+	return func(*http.Request, string) ([]QuotaLimit, error) {
+		return limits, nil
+	}
+}
+
+// QuotaStore counts requests per key. Implementations must be safe for
+// concurrent use; use a shared store to enforce quotas across replicas.
+type QuotaStore interface {
+	// Incr increments the counter for key, which may be dropped after
+	// expireAt, and returns the new count.
+	Incr(ctx context.Context, key string, expireAt time.Time) (int64, error)
+}
+
+// QuotaOptions configures Quota.
+type QuotaOptions struct {
+	// Principal identifies whose quota a request counts against. Defaults to
+	// KeyBySubject. Requests without a principal are not counted.
+	Principal KeyFunc
+	// Store holds the counters. Defaults to a MemoryQuotaStore local to this middleware.
+	Store QuotaStore
+	// Logger receives provider and store failures. Defaults to the logger set with SetLogger.
+	Logger *slog.Logger
+}
+
+// Quota enforces per-principal request quotas over calendar periods. Every
+// response carries X-Quota-Limit, X-Quota-Remaining and X-Quota-Reset (Unix
+// seconds) for the most constrained period; once a quota is used up, requests
+// are answered with a 429 APIError and Retry-After until it resets. Rejected
+// requests still count. Provider and store failures fail open.
+func Quota(limits QuotaProvider, opts QuotaOptions) func(http.Handler) http.Handler {
+	if opts.Principal == nil {
+		opts.Principal = KeyBySubject
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemoryQuotaStore()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := opts.Principal(r)
+			if principal == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			quotas, err := limits(r, principal)
+			if err != nil {
+				loggerOr(opts.Logger).Warn("quota provider unavailable", slog.String("error", err.Error()))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			now := time.Now()
+			var tightest *quotaUsage
+			for _, q := range quotas {
+				start, reset := q.Period.window(now)
+				key := "quota:" + principal + ":" + string(q.Period) + ":" + start.Format("2006-01-02")
+				count, err := opts.Store.Incr(r.Context(), key, reset)
+				if err != nil {
+					loggerOr(opts.Logger).Warn("quota store unavailable", slog.String("error", err.Error()))
+					next.ServeHTTP(w, r)
+					return
+				}
+
+				usage := &quotaUsage{limit: q, remaining: q.Limit - count, reset: reset}
+				if tightest == nil || usage.remaining < tightest.remaining ||
+					(usage.remaining == tightest.remaining && usage.reset.After(tightest.reset)) {
+					tightest = usage
+				}
+			}
+			if tightest == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("X-Quota-Limit", strconv.FormatInt(tightest.limit.Limit, 10))
+			h.Set("X-Quota-Remaining", strconv.FormatInt(max(tightest.remaining, 0), 10))
+			h.Set("X-Quota-Reset", strconv.FormatInt(tightest.reset.Unix(), 10))
+
+			if tightest.remaining < 0 {
+				h.Set("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(tightest.reset).Seconds()))))
+				msg := string(tightest.limit.Period) + " quota exceeded, resets at " + tightest.reset.Format(time.RFC3339)
+				writeError(w, r, apierr.NewError(http.StatusTooManyRequests, "quota_exceeded", msg))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type quotaUsage struct {
+	limit     QuotaLimit
+	remaining int64
+	reset     time.Time
+}
+
+// MemoryQuotaStore is a process-local QuotaStore. Expired counters are evicted lazily.
+type MemoryQuotaStore struct {
+	mu        sync.Mutex
+	counters  map[string]*quotaCounter
+	nextSweep time.Time
+}
+
+type quotaCounter struct {
+	count    int64
+	expireAt time.Time
+}
+
+// NewMemoryQuotaStore creates an empty MemoryQuotaStore.
+func NewMemoryQuotaStore() *MemoryQuotaStore {
+	return &MemoryQuotaStore{counters: make(map[string]*quotaCounter)}
+}
+
+// Incr implements QuotaStore.
+func (s *MemoryQuotaStore) Incr(_ context.Context, key string, expireAt time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.After(s.nextSweep) {
+		for k, c := range s.counters {
+			if now.After(c.expireAt) {
+				delete(s.counters, k)
+			}
+		}
+		s.nextSweep = now.Add(time.Hour)
+	}
+
+	c, ok := s.counters[key]
+	if !ok || now.After(c.expireAt) {
+		c = &quotaCounter{expireAt: expireAt}
+		s.counters[key] = c
+	}
+	c.count++
+	return c.count, nil
+}
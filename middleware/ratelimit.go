@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// KeyFunc derives the rate limiting key for a request. An empty key exempts the request.
+type KeyFunc func(r *http.Request) string
+
+// KeyByIP keys requests by the client IP address as resolved by ClientIP.
+func KeyByIP(r *http.Request) string {
+	return ClientIP(r)
+}
+
+// KeyByHeader keys requests by the value of the named header, e.g. an API key.
+func KeyByHeader(name string) KeyFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// RateLimitDecision is the outcome of taking one request from a key's allowance.
+type RateLimitDecision struct {
+	Allowed    bool
+	Limit      int           // Burst size the decision was made against
+	Remaining  int           // Requests left before the limit is hit
+	RetryAfter time.Duration // When denied, how long until a request would be allowed
+	ResetAfter time.Duration // How long until the allowance is fully replenished
+}
+
+// RateLimitStore enforces rate limits for keys. Implementations must be safe for
+// concurrent use; a shared store such as RedisStore keeps limits consistent across replicas.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, rate float64, burst int) (RateLimitDecision, error)
+}
+
+// RateLimitOptions configures the RateLimit middleware.
+type RateLimitOptions struct {
+	// Rate is the number of requests per second refilled into each bucket.
+	Rate float64
+	// Burst is the bucket capacity. Defaults to max(1, Rate).
+	Burst int
+	// Key derives the bucket key. Defaults to KeyByIP.
+	Key KeyFunc
+	// Store holds the buckets. Defaults to a MemoryStore local to this middleware.
+	Store RateLimitStore
+	// Logger receives store failures. Defaults to the logger set with SetLogger.
+	Logger *slog.Logger
+}
+
+// RateLimit limits requests per key, answering with a 429 APIError and Retry-After
+// once the key's allowance is exhausted. Every limited response carries
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset (Unix seconds)
+// as well as the IETF draft RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset
+// (delta seconds) and RateLimit-Policy headers, so clients can self-throttle.
+// Store failures fail open.
+func RateLimit(opts RateLimitOptions) func(http.Handler) http.Handler {
+	if opts.Burst <= 0 {
+		opts.Burst = max(1, int(opts.Rate))
+	}
+	if opts.Key == nil {
+		opts.Key = KeyByIP
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemoryStore(0)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := opts.Key(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			decision, err := opts.Store.Take(r.Context(), key, opts.Rate, opts.Burst)
+			if err != nil {
+				loggerOr(opts.Logger).Warn("rate limit store unavailable", slog.String("error", err.Error()))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			setRateLimitHeaders(w.Header(), decision, opts.Rate)
+
+			if !decision.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds()))))
+				writeError(w, r, apierr.NewError(http.StatusTooManyRequests, "rate_limit", "too many requests"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func setRateLimitHeaders(h http.Header, d RateLimitDecision, rate float64) {
+	limit := strconv.Itoa(d.Limit)
+	remaining := strconv.Itoa(max(d.Remaining, 0))
+	reset := int(math.Ceil(d.ResetAfter.Seconds()))
+
+	h.Set("X-RateLimit-Limit", limit)
+	h.Set("X-RateLimit-Remaining", remaining)
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(d.ResetAfter).Unix(), 10))
+
+	h.Set("RateLimit-Limit", limit)
+	h.Set("RateLimit-Remaining", remaining)
+	h.Set("RateLimit-Reset", strconv.Itoa(reset))
+	if rate > 0 {
+		window := int(math.Ceil(float64(d.Limit) / rate))
+		h.Set("RateLimit-Policy", limit+";w="+strconv.Itoa(window))
+	}
+}
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// MemoryStore is a process-local RateLimitStore of token buckets. Idle buckets
+// are evicted lazily, keeping memory bounded under client churn without a
+// background goroutine.
+type MemoryStore struct {
+	mu        sync.Mutex
+	idleTTL   time.Duration
+	buckets   map[string]*bucket
+	nextSweep time.Time
+}
+
+// NewMemoryStore creates a MemoryStore evicting buckets unused for idleTTL
+// (10 minutes when zero).
+func NewMemoryStore(idleTTL time.Duration) *MemoryStore {
+	if idleTTL <= 0 {
+		idleTTL = 10 * time.Minute
+	}
+	return &MemoryStore{
+		idleTTL: idleTTL,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Take implements RateLimitStore.
+func (s *MemoryStore) Take(_ context.Context, key string, rate float64, burst int) (RateLimitDecision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.After(s.nextSweep) {
+		for k, b := range s.buckets {
+			if now.Sub(b.lastSeen) > s.idleTTL {
+				delete(s.buckets, k)
+			}
+		}
+		s.nextSweep = now.Add(s.idleTTL)
+	}
+
+	capacity := float64(burst)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, lastSeen: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.lastSeen).Seconds()*rate)
+	b.lastSeen = now
+
+	decision := RateLimitDecision{Limit: burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else if rate > 0 {
+		decision.RetryAfter = secondsToDuration((1 - b.tokens) / rate)
+	} else {
+		decision.RetryAfter = s.idleTTL
+	}
+
+	decision.Remaining = int(b.tokens)
+	if rate > 0 {
+		decision.ResetAfter = secondsToDuration((capacity - b.tokens) / rate)
+	}
+	return decision, nil
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// RedisEvaler runs a Lua script on Redis and returns its raw reply. It is
+// satisfied by a one-line adapter over any Redis client, e.g. for go-redis:
+//
+//	func (a adapter) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+//		return a.Client.Eval(ctx, script, keys, args...).Result()
+//	}
+type RedisEvaler interface {
+	Eval(ctx context.Context, script string, keys []string, args ...any) (any, error)
+}
+
+// gcraScript implements the generic cell rate algorithm. It stores a single
+// "theoretical arrival time" per key and uses the server clock, so every
+// replica sharing the Redis instance enforces the same limit.
+const gcraScript = `
+redis.replicate_commands()
+local burst = tonumber(ARGV[1])
+local emission = 1 / tonumber(ARGV[2])
+local tolerance = emission * burst
+local t = redis.call("TIME")
+local now = tonumber(t[1]) + tonumber(t[2]) / 1000000
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if not tat or tat < now then
+  tat = now
+end
+local new_tat = tat + emission
+local diff = now - (new_tat - tolerance)
+if diff < 0 then
+  return {0, 0, math.ceil(-diff * 1000), math.ceil((tat - now) * 1000)}
+end
+local ttl = math.ceil((new_tat - now) * 1000)
+redis.call("SET", KEYS[1], tostring(new_tat), "PX", ttl)
+return {1, math.floor(diff / emission), 0, ttl}
+`
+
+// RedisStore is a RateLimitStore backed by Redis using GCRA, enforcing limits
+// consistently across replicas behind a load balancer.
+type RedisStore struct {
+	client RedisEvaler
+	prefix string
+}
+
+// NewRedisStore creates a RedisStore. Keys are stored as prefix+key; prefix
+// defaults to "ratelimit:".
+func NewRedisStore(client RedisEvaler, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = "ratelimit:"
+	}
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Take implements RateLimitStore.
+func (s *RedisStore) Take(ctx context.Context, key string, rate float64, burst int) (RateLimitDecision, error) {
+	if rate <= 0 {
+		return RateLimitDecision{}, fmt.Errorf("redis rate limit: rate must be positive, got %v", rate)
+	}
+
+	reply, err := s.client.Eval(ctx, gcraScript, []string{s.prefix + key}, burst, strconv.FormatFloat(rate, 'f', -1, 64))
+	if err != nil {
+		return RateLimitDecision{}, fmt.Errorf("redis rate limit: %w", err)
+	}
+
+	values, ok := reply.([]any)
+	if !ok || len(values) != 4 {
+		return RateLimitDecision{}, fmt.Errorf("redis rate limit: unexpected reply %v", reply)
+	}
+	nums := make([]int64, len(values))
+	for i, v := range values {
+		n, ok := v.(int64)
+		if !ok {
+			return RateLimitDecision{}, fmt.Errorf("redis rate limit: unexpected reply element %v", v)
+		}
+		nums[i] = n
+	}
+
+	return RateLimitDecision{
+		Allowed:    nums[0] == 1,
+		Limit:      burst,
+		Remaining:  int(nums[1]),
+		RetryAfter: time.Duration(nums[2]) * time.Millisecond,
+		ResetAfter: time.Duration(nums[3]) * time.Millisecond,
+	}, nil
+}
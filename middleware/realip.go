@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ClientIPContextKey is the key for storing the resolved client IP in request context.
+const ClientIPContextKey contextKey = "ClientIP"
+
+// ClientIP returns the client IP resolved by RealIP, falling back to the host
+// part of r.RemoteAddr.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(ClientIPContextKey).(string); ok {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// RealIP resolves the true client IP from the Forwarded, X-Forwarded-For and
+// X-Real-IP headers, trusting them only when the connecting peer is inside one
+// of the trusted CIDRs. The result is available through ClientIP and is used by
+// the request logger and KeyByIP. It panics if a CIDR cannot be parsed.
+func RealIP(trustedCIDRs ...string) func(http.Handler) http.Handler {
+	trusted := make([]netip.Prefix, len(trustedCIDRs))
+	for i, cidr := range trustedCIDRs {
+		trusted[i] = netip.MustParsePrefix(cidr)
+	}
+
+	isTrusted := func(ip string) bool {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return false
+		}
+		addr = addr.Unmap()
+		for _, p := range trusted {
+			if p.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := ClientIP(r)
+			if isTrusted(ip) {
+				ip = forwardedClientIP(r, ip, isTrusted)
+			}
+
+			// Replace the request in place so outer middleware like RequestLogger
+			// observe the resolved IP too.
+			*r = *r.WithContext(context.WithValue(r.Context(), ClientIPContextKey, ip))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedClientIP walks the forwarding chain from the nearest hop outwards and
+// returns the first address not belonging to a trusted proxy.
+func forwardedClientIP(r *http.Request, peer string, isTrusted func(string) bool) string {
+	chain := forwardedFor(r)
+	if len(chain) == 0 {
+		if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+			if _, err := netip.ParseAddr(realIP); err == nil {
+				return realIP
+			}
+		}
+		return peer
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		if !isTrusted(chain[i]) {
+			return chain[i]
+		}
+	}
+	return chain[0]
+}
+
+// forwardedFor returns the client chain from the Forwarded header, or from
+// X-Forwarded-For when Forwarded is absent. Invalid entries are dropped.
+func forwardedFor(r *http.Request) []string {
+	var chain []string
+
+	if values := r.Header.Values("Forwarded"); len(values) > 0 {
+		for _, value := range values {
+			for _, element := range strings.Split(value, ",") {
+				for _, pair := range strings.Split(element, ";") {
+					key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+					if !ok || !strings.EqualFold(key, "for") {
+						continue
+					}
+					if ip := parseForwardedNode(val); ip != "" {
+						chain = append(chain, ip)
+					}
+				}
+			}
+		}
+		return chain
+	}
+
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		for _, ip := range strings.Split(value, ",") {
+			if addr, err := netip.ParseAddr(strings.TrimSpace(ip)); err == nil {
+				chain = append(chain, addr.String())
+			}
+		}
+	}
+	return chain
+}
+
+// parseForwardedNode extracts the IP from a Forwarded "for" node such as
+// 192.0.2.1, "192.0.2.1:443" or "[2001:db8::1]:443".
+func parseForwardedNode(node string) string {
+	node = strings.Trim(node, `"`)
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+	node = strings.TrimSuffix(strings.TrimPrefix(node, "["), "]")
+	addr, err := netip.ParseAddr(node)
+	if err != nil {
+		return ""
+	}
+	return addr.String()
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// responseRecorder tracks the status and size of a response on its way through.
+// Pass rr.wrap() to the next handler so the optional interfaces of the
+// underlying writer stay visible.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode   int
+	bytesWritten int64
+	wroteHeader  bool
+}
+
+// BytesWritten returns the number of response body bytes written so far.
+func (rr *responseRecorder) BytesWritten() int64 {
+	return rr.bytesWritten
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.wroteHeader = true
+	n, err := rr.ResponseWriter.Write(b)
+	rr.bytesWritten += int64(n)
+	return n, err
+}
+
+func (rr *responseRecorder) Flush() {
+	if flusher, ok := rr.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (rr *responseRecorder) WriteHeader(statusCode int) {
+	rr.statusCode = statusCode
+	rr.wroteHeader = true
+	rr.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
+
+// wrap returns rr extended with exactly those of http.Hijacker, http.Pusher and
+// io.ReaderFrom that the underlying writer implements, so type assertions in
+// handlers (WebSocket upgrades, sendfile via io.Copy) behave as without rr.
+func (rr *responseRecorder) wrap() http.ResponseWriter {
+	_, hijacker := rr.ResponseWriter.(http.Hijacker)
+	_, pusher := rr.ResponseWriter.(http.Pusher)
+	_, readerFrom := rr.ResponseWriter.(io.ReaderFrom)
+
+	h, p, rf := recorderHijacker{rr}, recorderPusher{rr}, recorderReaderFrom{rr}
+	switch {
+	case hijacker && pusher && readerFrom:
+		return struct {
+			*responseRecorder
+			recorderHijacker
+			recorderPusher
+			recorderReaderFrom
+		}{rr, h, p, rf}
+	case hijacker && pusher:
+		return struct {
+			*responseRecorder
+			recorderHijacker
+			recorderPusher
+		}{rr, h, p}
+	case hijacker && readerFrom:
+		return struct {
+			*responseRecorder
+			recorderHijacker
+			recorderReaderFrom
+		}{rr, h, rf}
+	case pusher && readerFrom:
+		return struct {
+			*responseRecorder
+			recorderPusher
+			recorderReaderFrom
+		}{rr, p, rf}
+	case hijacker:
+		return struct {
+			*responseRecorder
+			recorderHijacker
+		}{rr, h}
+	case pusher:
+		return struct {
+			*responseRecorder
+			recorderPusher
+		}{rr, p}
+	case readerFrom:
+		return struct {
+			*responseRecorder
+			recorderReaderFrom
+		}{rr, rf}
+	}
+	return rr
+}
+
+type recorderHijacker struct{ rr *responseRecorder }
+
+// Hijack takes over the connection; the response is recorded as 101 Switching
+// Protocols unless a status was already written.
+func (h recorderHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := h.rr.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil && !h.rr.wroteHeader {
+		h.rr.wroteHeader = true
+		h.rr.statusCode = http.StatusSwitchingProtocols
+	}
+	return conn, brw, err
+}
+
+type recorderPusher struct{ rr *responseRecorder }
+
+func (p recorderPusher) Push(target string, opts *http.PushOptions) error {
+	return p.rr.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+type recorderReaderFrom struct{ rr *responseRecorder }
+
+// ReadFrom lets io.Copy use the underlying writer's sendfile path while still
+// counting the bytes written.
+func (f recorderReaderFrom) ReadFrom(src io.Reader) (int64, error) {
+	f.rr.wroteHeader = true
+	n, err := f.rr.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+	f.rr.bytesWritten += n
+	return n, err
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"strconv"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// PanicHook is invoked after a panic has been recovered and logged,
+// e.g. to alert on-call or report to an error tracker.
+type PanicHook func(r *http.Request, recovered any, stack []string)
+
+// panicError carries a recovered panic value while unwrapping to the 500 APIError,
+// so RequestLogger logs the panic as the error detail.
+type panicError struct {
+	value  any
+	stack  []string
+	apiErr *apierr.APIError
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+func (e *panicError) Unwrap() error {
+	return e.apiErr
+}
+
+// Recover converts panics in next into 500 APIErrors and logs the stack trace.
+func Recover(next http.Handler) http.Handler {
+	return RecoverWith(nil)(next)
+}
+
+// RecoverWith is Recover with an optional hook called for every recovered panic.
+func RecoverWith(hook PanicHook) func(http.Handler) http.Handler {
+	return RecoverWithOptions(RecoverOptions{Hook: hook})
+}
+
+// RecoverOptions configures RecoverWithOptions.
+type RecoverOptions struct {
+	// Hook is called for every recovered panic.
+	Hook PanicHook
+	// Error builds the APIError answered for a panic. Defaults to a 500 typed "internal".
+	Error func(r *http.Request, recovered any) *apierr.APIError
+	// AbortPartial aborts the connection when a panic happens after the response
+	// has started, so clients see a failed transfer rather than a truncated body.
+	// The panic is still logged and passed to Hook, but outer middleware such as
+	// RequestLogger does not run to completion.
+	AbortPartial bool
+	// Logger receives the panic logs. Defaults to the logger set with SetLogger.
+	Logger *slog.Logger
+}
+
+// RecoverWithOptions is Recover configured by opts.
+func RecoverWithOptions(opts RecoverOptions) func(http.Handler) http.Handler {
+	if opts.Error == nil {
+		opts.Error = func(*http.Request, any) *apierr.APIError {
+			return apierr.NewError(http.StatusInternalServerError, "internal", "internal server error")
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rr := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+
+				stack := captureStack()
+				loggerOr(opts.Logger).Error("PANIC",
+					slog.String("panic", fmt.Sprint(v)),
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.Any("stack", stack),
+				)
+
+				err := &panicError{value: v, stack: stack, apiErr: opts.Error(r, v)}
+				partial := rr.wroteHeader
+				if partial {
+					// Too late for an error response; still record the error for RequestLogger.
+					apierr.MapError(err, r)
+				} else {
+					writeError(rr, r, err)
+				}
+
+				if opts.Hook != nil {
+					opts.Hook(r, v, stack)
+				}
+				if partial && opts.AbortPartial {
+					panic(http.ErrAbortHandler)
+				}
+			}()
+
+			next.ServeHTTP(rr.wrap(), r)
+		})
+	}
+}
+
+// captureStack returns the panicking goroutine's stack as "function file:line" frames,
+// skipping the runtime and this package's recovery frames.
+func captureStack() []string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(4, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []string
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, frame.Function+" "+frame.File+":"+strconv.Itoa(frame.Line))
+		if !more {
+			break
+		}
+	}
+	return stack
+}
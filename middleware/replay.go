@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// MemoryReplayStore is a process-local ReplayStore. Expired keys are swept
+// lazily, so memory is bounded by the number of keys seen within their TTL.
+type MemoryReplayStore struct {
+	mu        sync.Mutex
+	keys      map[string]time.Time
+	nextSweep time.Time
+}
+
+// NewMemoryReplayStore creates an empty MemoryReplayStore.
+func NewMemoryReplayStore() *MemoryReplayStore {
+	return &MemoryReplayStore{keys: make(map[string]time.Time)}
+}
+
+// Seen implements ReplayStore.
+func (s *MemoryReplayStore) Seen(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.After(s.nextSweep) {
+		for k, expires := range s.keys {
+			if now.After(expires) {
+				delete(s.keys, k)
+			}
+		}
+		s.nextSweep = now.Add(time.Minute)
+	}
+
+	if expires, ok := s.keys[key]; ok && now.Before(expires) {
+		return true, nil
+	}
+	s.keys[key] = now.Add(ttl)
+	return false, nil
+}
+
+// NonceOptions configures RejectReplays.
+type NonceOptions struct {
+	// Store remembers nonces. Defaults to a MemoryReplayStore local to the middleware.
+	Store ReplayStore
+	// Header carries the client-supplied nonce. Defaults to "X-Nonce".
+	Header string
+	// TTL is how long a nonce is remembered. Defaults to 24 hours.
+	TTL time.Duration
+}
+
+// RejectReplays requires every request to carry a unique nonce header and
+// rejects reused nonces with a 409 APIError, for partner-facing mutation
+// endpoints. Nonces are scoped to the authenticated subject when claims exist.
+func RejectReplays(opts NonceOptions) func(http.Handler) http.Handler {
+	if opts.Store == nil {
+		opts.Store = NewMemoryReplayStore()
+	}
+	if opts.Header == "" {
+		opts.Header = "X-Nonce"
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = 24 * time.Hour
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			nonce := r.Header.Get(opts.Header)
+			if nonce == "" || len(nonce) > 256 {
+				writeError(w, r, apierr.NewError(http.StatusBadRequest, "missing_nonce", "a unique "+opts.Header+" header is required"))
+				return
+			}
+
+			key := "nonce:" + nonce
+			if claims, ok := GetClaims(r.Context()); ok {
+				key = "nonce:" + claims.Subject() + ":" + nonce
+			}
+
+			seen, err := opts.Store.Seen(r.Context(), key, opts.TTL)
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+			if seen {
+				writeError(w, r, apierr.NewError(http.StatusConflict, "replayed_request", "nonce already used"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
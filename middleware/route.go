@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+// RouteContextKey is the key for storing the matched route in request context.
+const RouteContextKey contextKey = "Route"
+
+// route is filled in by the matched handler, after outer middleware has
+// already captured the context, so they share it by pointer.
+type route struct {
+	pattern string
+}
+
+// WithRoute returns a context that can record the matched route pattern.
+// Routers install it before running middleware so that loggers and metrics
+// wrapping the whole router can label requests by route.
+func WithRoute(ctx context.Context) context.Context {
+	return context.WithValue(ctx, RouteContextKey, &route{})
+}
+
+// SetRoutePattern records pattern, e.g. "GET /users/{id}", as the matched
+// route. It does nothing when ctx has no WithRoute holder.
+func SetRoutePattern(ctx context.Context, pattern string) {
+	if rt, ok := ctx.Value(RouteContextKey).(*route); ok {
+		rt.pattern = pattern
+	}
+}
+
+// GetRoutePattern returns the matched route pattern, or "" when no route matched.
+func GetRoutePattern(ctx context.Context) string {
+	if rt, ok := ctx.Value(RouteContextKey).(*route); ok {
+		return rt.pattern
+	}
+	return ""
+}
+
+// routeLabel returns the matched route pattern for labeling metrics, falling
+// back to the ServeMux pattern and then to "unmatched", so unrouted paths
+// can't create unbounded label values.
+func routeLabel(r *http.Request) string {
+	if pattern := GetRoutePattern(r.Context()); pattern != "" {
+		return pattern
+	}
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+	return "unmatched"
+}
@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// SignatureKeyIDContextKey is the key for storing the verified signing key ID in request context.
+const SignatureKeyIDContextKey contextKey = "SignatureKeyID"
+
+// Request signing scheme constants shared by SignRequest and VerifySignature.
+const (
+	SignatureAlgorithm  = "APICORE-HMAC-SHA256"
+	SignatureDateHeader = "X-Date"
+	signatureDateFormat = "20060102T150405Z"
+)
+
+// GetSignatureKeyID returns the key ID of a request verified by VerifySignature.
+func GetSignatureKeyID(ctx context.Context) string {
+	id, _ := ctx.Value(SignatureKeyIDContextKey).(string)
+	return id
+}
+
+// SigningKeyLookup returns the secret for a key ID, or nil if the key is unknown.
+type SigningKeyLookup func(ctx context.Context, keyID string) ([]byte, error)
+
+// SignatureOptions configures VerifySignature.
+type SignatureOptions struct {
+	// Keys resolves signing secrets by key ID.
+	Keys SigningKeyLookup
+	// RequiredHeaders must be covered by the signature in addition to host and
+	// X-Date, e.g. "content-type" or "x-idempotency-key".
+	RequiredHeaders []string
+	// ClockSkew is how far X-Date may deviate from the current time. Defaults to five minutes.
+	ClockSkew time.Duration
+}
+
+// SignRequest signs r for VerifySignature: it sets X-Date when missing and an
+// Authorization header covering the method, path, query, body and the host,
+// X-Date and extra signedHeaders. The body is read and restored.
+func SignRequest(r *http.Request, keyID string, secret []byte, signedHeaders ...string) error {
+	body, err := readAndRestoreBody(r)
+	if err != nil {
+		return err
+	}
+	if r.Header.Get(SignatureDateHeader) == "" {
+		r.Header.Set(SignatureDateHeader, time.Now().UTC().Format(signatureDateFormat))
+	}
+
+	headers := normalizeSignedHeaders(append([]string{"host", strings.ToLower(SignatureDateHeader)}, signedHeaders...))
+	sig := computeSignature(r, headers, body, secret)
+	r.Header.Set("Authorization", SignatureAlgorithm+" Credential="+keyID+", SignedHeaders="+strings.Join(headers, ";")+", Signature="+sig)
+	return nil
+}
+
+// VerifySignature authenticates machine-to-machine callers that sign requests
+// with SignRequest, a canonical-request HMAC scheme modeled on AWS SigV4.
+// Failures are answered with 401 APIErrors typed "invalid_signature" or
+// "stale_signature"; key lookup errors are mapped as usual. The verified key ID
+// is available through GetSignatureKeyID. Limit the body size with MaxBody, as
+// it is read in full.
+func VerifySignature(opts SignatureOptions) func(http.Handler) http.Handler {
+	if opts.ClockSkew <= 0 {
+		opts.ClockSkew = 5 * time.Minute
+	}
+	required := normalizeSignedHeaders(append([]string{"host", strings.ToLower(SignatureDateHeader)}, opts.RequiredHeaders...))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			keyID, headers, sig, err := parseSignatureAuthorization(r.Header.Get("Authorization"))
+			if err != nil {
+				writeError(w, r, apierr.NewError(http.StatusUnauthorized, "invalid_signature", err.Error()))
+				return
+			}
+			for _, h := range required {
+				if !slices.Contains(headers, h) {
+					writeError(w, r, apierr.NewError(http.StatusUnauthorized, "invalid_signature", "signed headers must include "+h))
+					return
+				}
+			}
+
+			ts, err := time.Parse(signatureDateFormat, r.Header.Get(SignatureDateHeader))
+			if err != nil {
+				writeError(w, r, apierr.NewError(http.StatusUnauthorized, "invalid_signature", "malformed "+SignatureDateHeader+" header"))
+				return
+			}
+			if skew := time.Since(ts); skew > opts.ClockSkew || skew < -opts.ClockSkew {
+				writeError(w, r, apierr.NewError(http.StatusUnauthorized, "stale_signature", "signature timestamp outside tolerance"))
+				return
+			}
+
+			secret, err := opts.Keys(r.Context(), keyID)
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+			if secret == nil {
+				writeError(w, r, apierr.NewError(http.StatusUnauthorized, "invalid_signature", "unknown key"))
+				return
+			}
+
+			body, err := readAndRestoreBody(r)
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+			expected := computeSignature(r, headers, body, secret)
+			if !hmac.Equal([]byte(sig), []byte(expected)) {
+				writeError(w, r, apierr.NewError(http.StatusUnauthorized, "invalid_signature", "signature mismatch"))
+				return
+			}
+
+			*r = *r.WithContext(context.WithValue(r.Context(), SignatureKeyIDContextKey, keyID))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+var errMalformedAuthorization = errors.New("malformed signature authorization header")
+
+// parseSignatureAuthorization parses
+// "APICORE-HMAC-SHA256 Credential=<id>, SignedHeaders=<h1;h2>, Signature=<hex>".
+func parseSignatureAuthorization(header string) (keyID string, signedHeaders []string, sig string, err error) {
+	params, ok := strings.CutPrefix(header, SignatureAlgorithm+" ")
+	if !ok {
+		return "", nil, "", errMalformedAuthorization
+	}
+	for _, part := range strings.Split(params, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "Credential":
+			keyID = value
+		case "SignedHeaders":
+			signedHeaders = strings.Split(value, ";")
+		case "Signature":
+			sig = value
+		}
+	}
+	if keyID == "" || len(signedHeaders) == 0 || sig == "" {
+		return "", nil, "", errMalformedAuthorization
+	}
+	return keyID, normalizeSignedHeaders(signedHeaders), sig, nil
+}
+
+func normalizeSignedHeaders(headers []string) []string {
+	out := make([]string, 0, len(headers))
+	for _, h := range headers {
+		if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+			out = append(out, h)
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// computeSignature returns the hex HMAC-SHA256 of the string to sign for r.
+func computeSignature(r *http.Request, signedHeaders []string, body []byte, secret []byte) string {
+	bodyHash := sha256.Sum256(body)
+
+	var canonical strings.Builder
+	canonical.WriteString(r.Method + "\n")
+	canonical.WriteString(r.URL.EscapedPath() + "\n")
+	canonical.WriteString(canonicalQuery(r.URL.Query()) + "\n")
+	for _, name := range signedHeaders {
+		value := strings.Join(r.Header.Values(name), ",")
+		if name == "host" {
+			value = r.Host
+		}
+		canonical.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	canonical.WriteString(strings.Join(signedHeaders, ";") + "\n")
+	canonical.WriteString(hex.EncodeToString(bodyHash[:]))
+
+	canonicalHash := sha256.Sum256([]byte(canonical.String()))
+	stringToSign := SignatureAlgorithm + "\n" + r.Header.Get(SignatureDateHeader) + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(stringToSign))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// canonicalQuery encodes q with keys and values sorted and spaces as %20.
+func canonicalQuery(q url.Values) string {
+	for _, values := range q {
+		slices.Sort(values)
+	}
+	return strings.ReplaceAll(q.Encode(), "+", "%20")
+}
+
+func readAndRestoreBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
@@ -0,0 +1,255 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/piheta/apicore/response"
+)
+
+// sloBuckets is the number of slices a rolling window is divided into. Old
+// slices expire one at a time, so the window slides in Window/sloBuckets steps.
+const sloBuckets = 60
+
+// latencyBounds are the upper bounds of the latency histogram buckets used by
+// SLO percentiles and the default LatencyHistograms.
+var latencyBounds = []time.Duration{
+	time.Millisecond, 2 * time.Millisecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+}
+
+// SLOOptions configures NewSLO.
+type SLOOptions struct {
+	// Objective is the target fraction of good requests. Defaults to 0.999.
+	Objective float64
+	// Window is the rolling window rates and percentiles cover. Defaults to one hour.
+	Window time.Duration
+	// IsError reports whether a response status spends error budget. Defaults
+	// to 5xx responses.
+	IsError func(status int) bool
+	// Slow, when set, also counts requests taking longer as errors, for
+	// latency objectives such as "99.9% of requests within 300ms".
+	Slow time.Duration
+	// BurnRateThreshold is the burn rate at which OnBurn fires. The burn rate is
+	// the error rate divided by the error budget, 1 - Objective, so 1 spends
+	// the budget exactly over the SLO period. Defaults to 14.4, the common
+	// threshold for paging on a one hour window.
+	BurnRateThreshold float64
+	// MinRequests is the number of requests in the window below which OnBurn
+	// does not fire, so a single failure on a quiet route does not page.
+	// Defaults to 100.
+	MinRequests int64
+	// OnBurn is called when a route's burn rate rises above BurnRateThreshold,
+	// and again only after it has dropped below it.
+	OnBurn func(route string, burnRate float64)
+}
+
+// SLO tracks rolling success rates and latency percentiles per route against an
+// objective. Its Middleware records requests, Report and Handler expose the
+// windows, and since it implements expvar.Var it can be published as a metric:
+//
+//	slo := middleware.NewSLO(middleware.SLOOptions{Objective: 0.995, OnBurn: page})
+//	expvar.Publish("slo", slo)
+//	r := router.New(slo.Middleware)
+//	r.HandleHTTP(http.MethodGet, "/slo", slo.Handler(), adminOnly)
+type SLO struct {
+	opts SLOOptions
+
+	mu     sync.Mutex
+	routes map[string]*sloRoute
+}
+
+// sloRoute is the rolling window of one route.
+type sloRoute struct {
+	buckets [sloBuckets]sloBucket
+	burning bool
+}
+
+type sloBucket struct {
+	slot     int64
+	requests int64
+	errors   int64
+	latency  [14]int64 // counts per latencyBounds, then beyond the last
+}
+
+// SLOReport is the state of one route's window.
+type SLOReport struct {
+	Route       string  `json:"route"`
+	Requests    int64   `json:"requests"`
+	Errors      int64   `json:"errors"`
+	SuccessRate float64 `json:"success_rate"`
+	BurnRate    float64 `json:"burn_rate"`
+	// Percentiles are upper bounds taken from a latency histogram, in milliseconds.
+	P50 float64 `json:"p50_ms"`
+	P95 float64 `json:"p95_ms"`
+	P99 float64 `json:"p99_ms"`
+}
+
+// NewSLO creates an SLO tracker configured by opts.
+func NewSLO(opts SLOOptions) *SLO {
+	if opts.Objective <= 0 || opts.Objective >= 1 {
+		opts.Objective = 0.999
+	}
+	if opts.Window <= 0 {
+		opts.Window = time.Hour
+	}
+	if opts.IsError == nil {
+		opts.IsError = func(status int) bool { return status >= http.StatusInternalServerError }
+	}
+	if opts.BurnRateThreshold <= 0 {
+		opts.BurnRateThreshold = 14.4
+	}
+	if opts.MinRequests <= 0 {
+		opts.MinRequests = 100
+	}
+	return &SLO{opts: opts, routes: make(map[string]*sloRoute)}
+}
+
+// Middleware records the outcome and latency of every request under its route
+// pattern, or "unmatched" for requests matching no route.
+func (s *SLO) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rr := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+
+		next.ServeHTTP(rr.wrap(), r)
+
+		duration := time.Since(start)
+		failed := s.opts.IsError(rr.statusCode) || (s.opts.Slow > 0 && duration > s.opts.Slow)
+		s.record(routeLabel(r), duration, failed)
+	})
+}
+
+// record adds one request to route's window and fires OnBurn on a rising edge.
+func (s *SLO) record(route string, duration time.Duration, failed bool) {
+	slot := s.slot()
+
+	s.mu.Lock()
+	rt, ok := s.routes[route]
+	if !ok {
+		rt = &sloRoute{}
+		s.routes[route] = rt
+	}
+	b := &rt.buckets[slot%sloBuckets]
+	if b.slot != slot {
+		*b = sloBucket{slot: slot}
+	}
+	b.requests++
+	if failed {
+		b.errors++
+	}
+	i, _ := slices.BinarySearch(latencyBounds, duration)
+	b.latency[i]++
+
+	report := s.report(route, rt, slot)
+	fire := false
+	if report.BurnRate >= s.opts.BurnRateThreshold && report.Requests >= s.opts.MinRequests {
+		fire = !rt.burning
+		rt.burning = true
+	} else {
+		rt.burning = false
+	}
+	s.mu.Unlock()
+
+	if fire && s.opts.OnBurn != nil {
+		s.opts.OnBurn(route, report.BurnRate)
+	}
+}
+
+// slot returns the index of the current window slice.
+func (s *SLO) slot() int64 {
+	return time.Now().UnixNano() / max(int64(s.opts.Window/sloBuckets), 1)
+}
+
+// report sums the live buckets of rt. s.mu must be held.
+func (s *SLO) report(route string, rt *sloRoute, slot int64) SLOReport {
+	rep := SLOReport{Route: route, SuccessRate: 1}
+	var latency [14]int64
+	for _, b := range rt.buckets {
+		if b.slot <= slot-sloBuckets || b.slot > slot {
+			continue
+		}
+		rep.Requests += b.requests
+		rep.Errors += b.errors
+		for i, n := range b.latency {
+			latency[i] += n
+		}
+	}
+	if rep.Requests == 0 {
+		return rep
+	}
+	rep.SuccessRate = 1 - float64(rep.Errors)/float64(rep.Requests)
+	rep.BurnRate = (1 - rep.SuccessRate) / (1 - s.opts.Objective)
+	rep.P50 = percentile(latency[:], rep.Requests, 0.50)
+	rep.P95 = percentile(latency[:], rep.Requests, 0.95)
+	rep.P99 = percentile(latency[:], rep.Requests, 0.99)
+	return rep
+}
+
+// percentile returns the upper bound, in milliseconds, of the histogram bucket
+// holding quantile q of total observations. Observations beyond the last bound
+// report twice that bound.
+func percentile(counts []int64, total int64, q float64) float64 {
+	rank := int64(q*float64(total) + 0.5)
+	rank = max(rank, 1)
+	var seen int64
+	for i, n := range counts {
+		seen += n
+		if seen >= rank {
+			if i < len(latencyBounds) {
+				return float64(latencyBounds[i].Microseconds()) / 1000
+			}
+			break
+		}
+	}
+	return float64(2*latencyBounds[len(latencyBounds)-1].Microseconds()) / 1000
+}
+
+// Report returns the current window of every route that served requests in
+// it, sorted by route.
+func (s *SLO) Report() []SLOReport {
+	slot := s.slot()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reports := make([]SLOReport, 0, len(s.routes))
+	for route, rt := range s.routes {
+		if rep := s.report(route, rt, slot); rep.Requests > 0 {
+			reports = append(reports, rep)
+		}
+	}
+	slices.SortFunc(reports, func(a, b SLOReport) int {
+		return strings.Compare(a.Route, b.Route)
+	})
+	return reports
+}
+
+// Handler serves Report as JSON with the objective, for an /slo endpoint.
+// Register it behind authentication.
+func (s *SLO) Handler() http.Handler {
+	return Public(func(w http.ResponseWriter, _ *http.Request) error {
+		return response.JSON(w, http.StatusOK, s.snapshot())
+	})
+}
+
+// String returns the JSON served by Handler, implementing expvar.Var.
+func (s *SLO) String() string {
+	b, _ := json.Marshal(s.snapshot())
+	return string(b)
+}
+
+type sloSnapshot struct {
+	Objective float64     `json:"objective"`
+	Window    string      `json:"window"`
+	Routes    []SLOReport `json:"routes"`
+}
+
+func (s *SLO) snapshot() sloSnapshot {
+	return sloSnapshot{Objective: s.opts.Objective, Window: s.opts.Window.String(), Routes: s.Report()}
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// Timeout runs next with a request context deadline of d. The handler's output
+// is buffered; if it overruns, exactly one 504 APIError is written (the same one
+// MapError produces for context.DeadlineExceeded) and later writes are discarded.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			// The handler gets its own request copy: it keeps running on another
+			// goroutine after a timeout and must not mutate r under our feet.
+			inner := r.WithContext(ctx)
+			tw := &timeoutWriter{w: w, header: make(http.Header), statusCode: http.StatusOK}
+
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if v := recover(); v != nil {
+						panicked <- v
+					}
+				}()
+				next.ServeHTTP(tw, inner)
+				close(done)
+			}()
+
+			select {
+			case v := <-panicked:
+				panic(v)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				if err, ok := inner.Context().Value(apierr.OriginalErrorContextKey).(error); ok {
+					*r = *r.WithContext(context.WithValue(r.Context(), apierr.OriginalErrorContextKey, err))
+				}
+				dst := w.Header()
+				for k, v := range tw.header {
+					dst[k] = v
+				}
+				w.WriteHeader(tw.statusCode)
+				_, _ = w.Write(tw.buf.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				tw.timedOut = true
+				writeError(w, r, ctx.Err())
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers the response until the handler finishes. The handler
+// never reaches w: it may still be running after a timeout has answered w.
+type timeoutWriter struct {
+	w http.ResponseWriter
+
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	statusCode  int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.wroteHeader = true
+	return tw.buf.Write(b)
+}
+
+func (tw *timeoutWriter) WriteHeader(statusCode int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	tw.statusCode = statusCode
+}
+
+// FlushError is a no-op for http.ResponseController, since the response is
+// buffered until the handler returns. It fails once the timeout has fired.
+func (tw *timeoutWriter) FlushError() error {
+	return tw.unsupported(nil)
+}
+
+// Hijack is refused: the connection must stay free for the timeout response.
+func (tw *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, tw.unsupported(http.ErrNotSupported)
+}
+
+// SetReadDeadline is refused; Timeout's deadline bounds the handler.
+func (tw *timeoutWriter) SetReadDeadline(time.Time) error {
+	return tw.unsupported(http.ErrNotSupported)
+}
+
+// SetWriteDeadline is refused; Timeout's deadline bounds the handler.
+func (tw *timeoutWriter) SetWriteDeadline(time.Time) error {
+	return tw.unsupported(http.ErrNotSupported)
+}
+
+// unsupported returns http.ErrHandlerTimeout after a timeout, err otherwise.
+func (tw *timeoutWriter) unsupported(err error) error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return http.ErrHandlerTimeout
+	}
+	return err
+}
+
+// DefaultsFrom lets response options set outside Timeout apply to the
+// handler's responses without exposing w through Unwrap.
+func (tw *timeoutWriter) DefaultsFrom() http.ResponseWriter {
+	return tw.w
+}
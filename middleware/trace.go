@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/piheta/apicore/metaerr"
+)
+
+// TraceContextKey is the key for storing the request's SpanContext in request context.
+const TraceContextKey contextKey = "Trace"
+
+// TraceparentHeader is the W3C Trace Context header read and forwarded by Trace.
+const TraceparentHeader = "Traceparent"
+
+// SpanContext identifies the span serving a request within a distributed trace.
+type SpanContext struct {
+	// TraceID is the 32 hex digit trace ID.
+	TraceID string
+	// SpanID is the 16 hex digit ID of the server span.
+	SpanID string
+	// Sampled reports whether the caller asked for the trace to be recorded.
+	Sampled bool
+}
+
+// Traceparent formats sc as a traceparent header value, for propagating the
+// trace to outgoing requests.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID + "-" + sc.SpanID + "-" + flags
+}
+
+// Child returns a span continuing sc's trace with a new span ID, for an
+// outgoing request such as a proxied hop. A zero sc starts a new trace.
+func (sc SpanContext) Child() SpanContext {
+	if sc.TraceID == "" {
+		sc.TraceID = randomHex(16)
+	}
+	sc.SpanID = randomHex(8)
+	return sc
+}
+
+var spanExtractor atomic.Pointer[func(ctx context.Context) (SpanContext, bool)]
+
+// SetSpanExtractor makes GetSpan fall back to fn for requests without a span
+// stored by Trace, so spans started by a tracing library, e.g. otelhttp, show
+// up in the logs without using Trace. A nil fn removes the extractor.
+func SetSpanExtractor(fn func(ctx context.Context) (SpanContext, bool)) {
+	if fn == nil {
+		spanExtractor.Store(nil)
+		return
+	}
+	spanExtractor.Store(&fn)
+}
+
+// GetSpan returns the span stored by Trace, or the one reported by the
+// extractor set with SetSpanExtractor.
+func GetSpan(ctx context.Context) (SpanContext, bool) {
+	if sc, ok := ctx.Value(TraceContextKey).(SpanContext); ok {
+		return sc, true
+	}
+	if fn := spanExtractor.Load(); fn != nil {
+		return (*fn)(ctx)
+	}
+	return SpanContext{}, false
+}
+
+func init() {
+	metaerr.RegisterContext(traceMetadata)
+}
+
+// traceMetadata adds trace_id and span_id to the metaerr context metadata,
+// and through it to RequestLogger and InjectLogger lines.
+func traceMetadata(ctx context.Context) []any {
+	sc, ok := GetSpan(ctx)
+	if !ok {
+		return nil
+	}
+	return []any{"trace_id", sc.TraceID, "span_id", sc.SpanID}
+}
+
+// Trace starts a server span for each request: it continues the trace of the
+// caller's traceparent header, or starts a new one when it is missing or
+// malformed, with a new span ID. The span is stored for GetSpan and the header
+// is rewritten to it so proxied requests join the trace. Log lines of
+// RequestLogger and InjectLogger carry trace_id and span_id from then on.
+func Trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sc, _ := ParseTraceparent(r.Header.Get(TraceparentHeader))
+		sc = sc.Child()
+		r.Header.Set(TraceparentHeader, sc.Traceparent())
+
+		*r = *r.WithContext(context.WithValue(r.Context(), TraceContextKey, sc))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ParseTraceparent parses a version 00 traceparent header value, or the first
+// four fields of a later version, returning the caller's span as SpanID.
+func ParseTraceparent(h string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(h), "-")
+	if len(parts) < 4 || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, false
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isHex(version, 2) || version == "ff" || !isHex(traceID, 32) || !isHex(spanID, 16) || !isHex(flags, 2) {
+		return SpanContext{}, false
+	}
+	if traceID == strings.Repeat("0", 32) || spanID == strings.Repeat("0", 16) {
+		return SpanContext{}, false
+	}
+	flagBits, _ := hex.DecodeString(flags)
+	return SpanContext{TraceID: traceID, SpanID: spanID, Sampled: flagBits[0]&1 == 1}, true
+}
+
+// isHex reports whether s is n lowercase hex digits.
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if (s[i] < '0' || s[i] > '9') && (s[i] < 'a' || s[i] > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
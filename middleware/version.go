@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"github.com/piheta/apicore/response"
+)
+
+// BuildInfo describes the running build.
+type BuildInfo struct {
+	// Version is the main module version, e.g. "v1.4.2", or "(devel)" for
+	// builds outside a module download.
+	Version string `json:"version"`
+	// Commit is the VCS revision, suffixed with "-dirty" for modified trees.
+	Commit string `json:"commit,omitempty"`
+	// Date is the commit time in RFC 3339.
+	Date string `json:"date,omitempty"`
+	// GoVersion is the Go toolchain the binary was built with.
+	GoVersion string `json:"go_version"`
+}
+
+// ReadBuildInfo returns the BuildInfo embedded in the binary by the Go
+// toolchain. Fields the binary carries no information for are empty.
+var ReadBuildInfo = sync.OnceValue(func() BuildInfo {
+	info := BuildInfo{GoVersion: runtime.Version()}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.Version = bi.Main.Version
+	modified := false
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Commit = s.Value
+		case "vcs.time":
+			info.Date = s.Value
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if modified && info.Commit != "" {
+		info.Commit += "-dirty"
+	}
+	return info
+})
+
+// VersionHandler serves info as JSON, with empty fields filled in from
+// ReadBuildInfo, so deploys can be verified with a request. Pass values
+// injected at link time to override them, e.g.
+//
+//	var version = "dev" // go build -ldflags "-X main.version=v1.4.2"
+//	r.HandleHTTP(http.MethodGet, "/version", middleware.VersionHandler(middleware.BuildInfo{Version: version}))
+func VersionHandler(info BuildInfo) http.Handler {
+	build := ReadBuildInfo()
+	if info.Version == "" {
+		info.Version = build.Version
+	}
+	if info.Commit == "" {
+		info.Commit = build.Commit
+	}
+	if info.Date == "" {
+		info.Date = build.Date
+	}
+	if info.GoVersion == "" {
+		info.GoVersion = build.GoVersion
+	}
+
+	return Public(func(w http.ResponseWriter, _ *http.Request) error {
+		w.Header().Set("Cache-Control", "no-store")
+		return response.JSON(w, http.StatusOK, info)
+	})
+}
@@ -0,0 +1,242 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// SecretProvider returns the signing secrets valid for a request. Returning
+// several secrets lets old and new secrets overlap during rotation.
+type SecretProvider func(r *http.Request) ([][]byte, error)
+
+// StaticSecret returns a SecretProvider for a single fixed secret.
+func StaticSecret(secret string) SecretProvider {
+	return func(*http.Request) ([][]byte, error) {
+		return [][]byte{[]byte(secret)}, nil
+	}
+}
+
+// SignatureScheme describes how a webhook provider signs its requests with HMAC-SHA256.
+type SignatureScheme struct {
+	// Parse extracts the signing timestamp (zero when the scheme has none) and
+	// the candidate hex signatures from the signature header value.
+	Parse func(r *http.Request, header string) (ts time.Time, signatures []string, err error)
+	// Payload builds the signed message from the timestamp header value and body.
+	Payload func(r *http.Request, ts time.Time, body []byte) []byte
+}
+
+var errMalformedSignature = errors.New("malformed signature header")
+
+// StripeScheme verifies "t=<unix>,v1=<hex>" headers signed over "<t>.<body>".
+var StripeScheme = SignatureScheme{
+	Parse: func(_ *http.Request, header string) (time.Time, []string, error) {
+		var ts time.Time
+		var sigs []string
+		for _, item := range strings.Split(header, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+			if !ok {
+				continue
+			}
+			switch key {
+			case "t":
+				unix, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					return time.Time{}, nil, errMalformedSignature
+				}
+				ts = time.Unix(unix, 0)
+			case "v1":
+				sigs = append(sigs, value)
+			}
+		}
+		if ts.IsZero() {
+			return time.Time{}, nil, errMalformedSignature
+		}
+		return ts, sigs, nil
+	},
+	Payload: func(_ *http.Request, ts time.Time, body []byte) []byte {
+		return append([]byte(strconv.FormatInt(ts.Unix(), 10)+"."), body...)
+	},
+}
+
+// GitHubScheme verifies "sha256=<hex>" headers signed over the raw body. It has
+// no timestamp, so pair it with a ReplayStore keyed on the delivery signature.
+var GitHubScheme = SignatureScheme{
+	Parse: func(_ *http.Request, header string) (time.Time, []string, error) {
+		sig, ok := strings.CutPrefix(header, "sha256=")
+		if !ok {
+			return time.Time{}, nil, errMalformedSignature
+		}
+		return time.Time{}, []string{sig}, nil
+	},
+	Payload: func(_ *http.Request, _ time.Time, body []byte) []byte {
+		return body
+	},
+}
+
+// SlackScheme verifies "v0=<hex>" headers signed over "v0:<ts>:<body>", where
+// the timestamp comes from X-Slack-Request-Timestamp.
+var SlackScheme = SignatureScheme{
+	Parse: func(r *http.Request, header string) (time.Time, []string, error) {
+		sig, ok := strings.CutPrefix(header, "v0=")
+		if !ok {
+			return time.Time{}, nil, errMalformedSignature
+		}
+		unix, err := strconv.ParseInt(r.Header.Get("X-Slack-Request-Timestamp"), 10, 64)
+		if err != nil {
+			return time.Time{}, nil, errMalformedSignature
+		}
+		return time.Unix(unix, 0), []string{sig}, nil
+	},
+	Payload: func(_ *http.Request, ts time.Time, body []byte) []byte {
+		return append([]byte("v0:"+strconv.FormatInt(ts.Unix(), 10)+":"), body...)
+	},
+}
+
+// ReplayStore remembers keys for a limited time to detect replayed requests.
+type ReplayStore interface {
+	// Seen records key for ttl and reports whether it was already recorded.
+	Seen(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// HMACOption configures VerifyHMAC.
+type HMACOption func(*hmacConfig)
+
+type hmacConfig struct {
+	tolerance time.Duration
+	replay    ReplayStore
+	replayTTL time.Duration
+	maxBytes  int64
+}
+
+// DefaultWebhookMaxBytes is the default body limit of VerifyHMAC.
+const DefaultWebhookMaxBytes int64 = 1 << 20
+
+// DefaultReplayTTL is how long VerifyHMAC remembers deliveries of schemes
+// without a timestamp, such as GitHubScheme, whose signatures never go stale.
+const DefaultReplayTTL = 7 * 24 * time.Hour
+
+// WithTolerance sets how far a signed timestamp may deviate from the current
+// time. Defaults to five minutes.
+func WithTolerance(d time.Duration) HMACOption {
+	return func(c *hmacConfig) {
+		c.tolerance = d
+	}
+}
+
+// WithReplayStore rejects requests whose signature has been seen before.
+// Signatures of timestamped schemes are remembered for twice the tolerance,
+// after which the timestamp check rejects them; others for the replay TTL.
+func WithReplayStore(store ReplayStore) HMACOption {
+	return func(c *hmacConfig) {
+		c.replay = store
+	}
+}
+
+// WithReplayTTL sets how long signatures of schemes without a timestamp are
+// remembered by the ReplayStore. A replay after ttl is accepted again, so
+// keep it longer than the provider's redelivery window. Defaults to
+// DefaultReplayTTL.
+func WithReplayTTL(ttl time.Duration) HMACOption {
+	return func(c *hmacConfig) {
+		c.replayTTL = ttl
+	}
+}
+
+// WithMaxBytes limits the body read for verification to n bytes. Larger
+// deliveries are answered with 413 before their signature is checked.
+// Defaults to DefaultWebhookMaxBytes.
+func WithMaxBytes(n int64) HMACOption {
+	return func(c *hmacConfig) {
+		c.maxBytes = n
+	}
+}
+
+// VerifyHMAC authenticates webhook deliveries signed with HMAC-SHA256 according
+// to scheme, reading the signature from headerName. Failures are answered with
+// 401 APIErrors typed "invalid_signature", "stale_signature" or
+// "replayed_request", and bodies over the limit with 413. The body is restored
+// for the wrapped handler.
+func VerifyHMAC(secrets SecretProvider, headerName string, scheme SignatureScheme, opts ...HMACOption) func(http.Handler) http.Handler {
+	cfg := hmacConfig{tolerance: 5 * time.Minute, replayTTL: DefaultReplayTTL, maxBytes: DefaultWebhookMaxBytes}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ts, signatures, err := scheme.Parse(r, r.Header.Get(headerName))
+			if err != nil {
+				writeError(w, r, apierr.NewError(http.StatusUnauthorized, "invalid_signature", err.Error()))
+				return
+			}
+
+			if !ts.IsZero() {
+				if skew := time.Since(ts); skew > cfg.tolerance || skew < -cfg.tolerance {
+					writeError(w, r, apierr.NewError(http.StatusUnauthorized, "stale_signature", "signature timestamp outside tolerance"))
+					return
+				}
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, cfg.maxBytes))
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			keys, err := secrets(r)
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+
+			matched := ""
+			payload := scheme.Payload(r, ts, body)
+			for _, key := range keys {
+				mac := hmac.New(sha256.New, key)
+				mac.Write(payload)
+				expected := mac.Sum(nil)
+				for _, sig := range signatures {
+					if decoded, err := hex.DecodeString(sig); err == nil && hmac.Equal(decoded, expected) {
+						// Key replays on the canonical MAC, since hex decoding
+						// also accepts upper-case signatures.
+						matched = hex.EncodeToString(expected)
+					}
+				}
+			}
+			if matched == "" {
+				writeError(w, r, apierr.NewError(http.StatusUnauthorized, "invalid_signature", "signature mismatch"))
+				return
+			}
+
+			if cfg.replay != nil {
+				ttl := cfg.replayTTL
+				if !ts.IsZero() {
+					ttl = 2 * cfg.tolerance
+				}
+				seen, err := cfg.replay.Seen(r.Context(), "hmac:"+matched, ttl)
+				if err != nil {
+					writeError(w, r, err)
+					return
+				}
+				if seen {
+					writeError(w, r, apierr.NewError(http.StatusUnauthorized, "replayed_request", "request already processed"))
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
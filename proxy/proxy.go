@@ -0,0 +1,111 @@
+// Package proxy provides a reverse proxy that reports upstream failures as APIErrors.
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/metaerr"
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/response"
+)
+
+type contextKey string
+
+const inboundContextKey contextKey = "ProxyInbound"
+
+// inbound is what the error handler needs from the client-facing request.
+type inbound struct {
+	r     *http.Request
+	start time.Time
+}
+
+// Options configures New.
+type Options struct {
+	// Transport performs upstream round trips. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+	// Rewrite, when set, further adjusts the outbound request after the target,
+	// forwarding and tracing headers have been applied.
+	Rewrite func(*httputil.ProxyRequest)
+	// ModifyResponse, when set, is passed to httputil.ReverseProxy. Returning an
+	// error answers the client with a 502 APIError.
+	ModifyResponse func(*http.Response) error
+	// FlushInterval is passed to httputil.ReverseProxy for streaming responses.
+	FlushInterval time.Duration
+}
+
+// New returns a handler proxying requests to target. Upstream failures are
+// answered with 504 "upstream_timeout" or 502 "bad_gateway" APIErrors and
+// recorded with the upstream host and latency as metaerr metadata, so
+// RequestLogger logs them like any handler error. The outbound request carries
+// X-Forwarded-* headers, the request ID and a W3C traceparent continuing the
+// caller's trace.
+func New(target *url.URL, opts Options) http.Handler {
+	rp := &httputil.ReverseProxy{
+		Transport:      opts.Transport,
+		ModifyResponse: opts.ModifyResponse,
+		FlushInterval:  opts.FlushInterval,
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			if id := middleware.GetRequestID(pr.In.Context()); id != "" {
+				pr.Out.Header.Set(middleware.RequestIDHeader, id)
+			}
+			pr.Out.Header.Set(middleware.TraceparentHeader, childSpan(pr.In).Traceparent())
+			if opts.Rewrite != nil {
+				opts.Rewrite(pr)
+			}
+		},
+		ErrorHandler: func(w http.ResponseWriter, out *http.Request, err error) {
+			in, ok := out.Context().Value(inboundContextKey).(*inbound)
+			if !ok {
+				in = &inbound{r: out, start: time.Now()}
+			}
+			err = metaerr.WithMetadata(upstreamError(in.r, err),
+				"upstream", target.Host,
+				"upstream_ms", fmt.Sprintf("%.2f", float64(time.Since(in.start).Microseconds())/1000),
+			)
+			apiErr := apierr.MapError(err, in.r)
+			_ = response.JSON(w, apiErr.StatusCode, apiErr)
+		},
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), inboundContextKey, &inbound{r: r, start: time.Now()})
+		rp.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// upstreamError classifies a proxy failure. Client cancellations keep their
+// context error so MapError reports them as such.
+func upstreamError(r *http.Request, err error) error {
+	if errors.Is(err, context.Canceled) && r.Context().Err() != nil {
+		return err
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w: %w", apierr.NewError(http.StatusGatewayTimeout, "upstream_timeout", "upstream timed out"), err)
+	}
+	return fmt.Errorf("%w: %w", apierr.NewError(http.StatusBadGateway, "bad_gateway", "upstream unavailable"), err)
+}
+
+// childSpan continues the trace of the request's span, from middleware.Trace
+// or a span extractor, or else of its traceparent header, with a new span ID
+// for the proxied hop. Without either it starts a new sampled trace.
+func childSpan(r *http.Request) middleware.SpanContext {
+	sc, ok := middleware.GetSpan(r.Context())
+	if !ok {
+		if sc, ok = middleware.ParseTraceparent(r.Header.Get(middleware.TraceparentHeader)); !ok {
+			sc = middleware.SpanContext{Sampled: true}
+		}
+	}
+	return sc.Child()
+}
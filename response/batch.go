@@ -0,0 +1,85 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+
+	"github.com/piheta/apicore/apierr"
+)
+
+// BatchWriter emits a multipart/mixed batch response in the Google/OData style,
+// where each part is an embedded HTTP response with its own status and JSON body.
+type BatchWriter struct {
+	w       http.ResponseWriter
+	mw      *multipart.Writer
+	started bool
+}
+
+// NewBatchWriter creates a BatchWriter writing to w. No output is produced until
+// the first part is written or Close is called.
+func NewBatchWriter(w http.ResponseWriter) *BatchWriter {
+	return &BatchWriter{w: w, mw: multipart.NewWriter(w)}
+}
+
+// Boundary returns the multipart boundary separating the parts.
+func (bw *BatchWriter) Boundary() string {
+	return bw.mw.Boundary()
+}
+
+func (bw *BatchWriter) start() {
+	if bw.started {
+		return
+	}
+	bw.started = true
+	bw.w.Header().Set("Content-Type", "multipart/mixed; boundary="+bw.mw.Boundary())
+	bw.w.WriteHeader(http.StatusOK)
+}
+
+// WritePart writes one part with the given status code and data encoded as JSON.
+// contentID correlates the part with the batch request item and may be empty.
+func (bw *BatchWriter) WritePart(contentID string, statusCode int, data any) error {
+	bw.start()
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("encoding batch part: %w", err)
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", "application/http")
+	if contentID != "" {
+		header.Set("Content-ID", "<response-"+contentID+">")
+	}
+	part, err := bw.mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "HTTP/1.1 %d %s\r\n", statusCode, http.StatusText(statusCode))
+	msg.WriteString("Content-Type: application/json\r\n")
+	msg.WriteString("Content-Length: " + strconv.Itoa(len(body)) + "\r\n\r\n")
+	msg.Write(body)
+
+	_, err = part.Write(msg.Bytes())
+	return err
+}
+
+// WriteError writes one part describing err as an APIError, mapped the same way
+// Public maps handler errors.
+func (bw *BatchWriter) WriteError(contentID string, err error) error {
+	apiErr := apierr.MapError(err, nil)
+	return bw.WritePart(contentID, apiErr.StatusCode, apiErr)
+}
+
+// Close writes the closing boundary. A batch without parts is still a valid,
+// empty multipart/mixed response.
+func (bw *BatchWriter) Close() error {
+	bw.start()
+	return bw.mw.Close()
+}
@@ -0,0 +1,52 @@
+package response
+
+import (
+	"net/http"
+	"slices"
+)
+
+// optionsWriter carries default JSON options for every response written through it.
+type optionsWriter struct {
+	http.ResponseWriter
+	opts []Option
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (ow *optionsWriter) Unwrap() http.ResponseWriter {
+	return ow.ResponseWriter
+}
+
+func (ow *optionsWriter) Flush() {
+	if flusher, ok := ow.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// WithDefaults returns a ResponseWriter whose JSON responses apply opts before
+// any options passed to JSON directly. Middleware uses it to configure encoding
+// for a whole group of routes.
+func WithDefaults(w http.ResponseWriter, opts ...Option) http.ResponseWriter {
+	return &optionsWriter{ResponseWriter: w, opts: opts}
+}
+
+// defaultOptions collects options attached by WithDefaults anywhere in the writer chain,
+// outer wrappers first so the ones closest to the handler take precedence. The
+// chain is followed through Unwrap, or through DefaultsFrom for wrappers such
+// as middleware.Timeout that must not expose their writer to the handler.
+func defaultOptions(w http.ResponseWriter) []Option {
+	var opts []Option
+	for w != nil {
+		if ow, ok := w.(*optionsWriter); ok {
+			opts = slices.Concat(ow.opts, opts)
+		}
+		switch u := w.(type) {
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = u.Unwrap()
+		case interface{ DefaultsFrom() http.ResponseWriter }:
+			w = u.DefaultsFrom()
+		default:
+			return opts
+		}
+	}
+	return opts
+}
@@ -2,20 +2,122 @@
 package response
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
 )
 
+// Option configures how JSON encodes a response.
+type Option func(*config)
+
+type config struct {
+	safeIntegers    bool
+	arrayPrefix     bool
+	strict          bool
+	encodeErrStatus int
+	encodeErrBody   any
+}
+
+// SafeIntegers encodes integers outside the range JavaScript can represent
+// exactly (±2^53-1) as JSON strings instead of numbers.
+func SafeIntegers() Option {
+	return func(c *config) {
+		c.safeIntegers = true
+	}
+}
+
+// HijackPrefix prepends ")]}',\n" to JSON array responses, guarding legacy
+// browsers against JSON hijacking. Clients must strip the prefix before parsing.
+func HijackPrefix() Option {
+	return func(c *config) {
+		c.arrayPrefix = true
+	}
+}
+
+// ReturnErrors makes JSON return encoding errors to the caller instead of
+// answering with the EncodeFailure document itself. Nothing is written when
+// encoding fails, so Public can map the returned error into an APIError response.
+// Write errors, such as a client that disconnected, are not returned: the
+// status has already been sent, so no other response can replace it.
+func ReturnErrors() Option {
+	return func(c *config) {
+		c.strict = true
+	}
+}
+
+// EncodeFailure sets the status and body JSON answers with when data cannot
+// be encoded. body should be an *apierr.APIError or similar document so clients
+// always get parseable JSON. The default is a 500 APIError typed "internal".
+func EncodeFailure(statusCode int, body any) Option {
+	return func(c *config) {
+		c.encodeErrStatus = statusCode
+		c.encodeErrBody = body
+	}
+}
+
+// encodeFailureBody is written when neither the data nor the configured
+// EncodeFailure body can be encoded.
+const encodeFailureBody = `{"status":500,"type":"internal","msg":"failed to encode response"}` + "\n"
+
+// hijackPrefix is the prefix written by HijackPrefix.
+const hijackPrefix = ")]}',\n"
+
 // JSON writes the given data as JSON to the response writer with the specified status code.
-func JSON(w http.ResponseWriter, statusCode int, data any) error {
+// The body is encoded into a buffer first so Content-Length can be set.
+func JSON(w http.ResponseWriter, statusCode int, data any, opts ...Option) error {
+	var cfg config
+	for _, opt := range defaultOptions(w) {
+		opt(&cfg)
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(data); err != nil {
+		if cfg.strict {
+			return fmt.Errorf("encoding response: %w", err)
+		}
+		writeEncodeFailure(w, cfg)
+		return nil
+	}
+
+	body := buf.Bytes()
+	if cfg.safeIntegers {
+		body = quoteUnsafeIntegers(body)
+	}
+	if cfg.arrayPrefix && len(body) > 0 && body[0] == '[' {
+		body = append([]byte(hijackPrefix), body...)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
 	w.WriteHeader(statusCode)
+	_, _ = w.Write(body)
+	return nil
+}
 
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+// writeEncodeFailure answers with the EncodeFailure document, falling back to
+// a fixed 500 APIError when that cannot be encoded either.
+func writeEncodeFailure(w http.ResponseWriter, cfg config) {
+	status, body := cfg.encodeErrStatus, []byte(encodeFailureBody)
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	if cfg.encodeErrBody != nil {
+		if b, err := json.Marshal(cfg.encodeErrBody); err == nil {
+			body = append(b, '\n')
+		} else {
+			status = http.StatusInternalServerError
+		}
 	}
 
-	return nil
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
 }
 
 // Status writes the HTTP status code without a response body.
@@ -23,3 +125,47 @@ func Status(w http.ResponseWriter, statusCode int) error {
 	w.WriteHeader(statusCode)
 	return nil
 }
+
+// Created writes data with 201 Created and a Location header pointing at the
+// new resource, e.g. one built with router.Router.URL.
+func Created(w http.ResponseWriter, location string, data any, opts ...Option) error {
+	w.Header().Set("Location", location)
+	return JSON(w, http.StatusCreated, data, opts...)
+}
+
+// ItemStatus is the outcome of one item in a bulk request.
+type ItemStatus struct {
+	Index  int `json:"index"`
+	Status int `json:"status"`
+	Body   any `json:"body,omitempty"`
+}
+
+// MultiStatus writes a 207 Multi-Status response listing each item's outcome,
+// for bulk endpoints where items succeed or fail independently.
+func MultiStatus(w http.ResponseWriter, items []ItemStatus, opts ...Option) error {
+	return JSON(w, http.StatusMultiStatus, map[string][]ItemStatus{"results": items}, opts...)
+}
+
+// PageInfo describes a page of results.
+type PageInfo struct {
+	// Page is the 1-based page number, omitted for cursor pagination.
+	Page       int    `json:"page,omitempty"`
+	PerPage    int    `json:"per_page"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Page is the envelope Paginated writes.
+type Page[T any] struct {
+	Items      []T      `json:"items"`
+	Pagination PageInfo `json:"pagination"`
+}
+
+// Paginated writes items in a Page envelope. A nil items slice is written as
+// an empty array so clients never see null.
+func Paginated[T any](w http.ResponseWriter, statusCode int, items []T, info PageInfo, opts ...Option) error {
+	if items == nil {
+		items = []T{}
+	}
+	return JSON(w, statusCode, Page[T]{Items: items, Pagination: info}, opts...)
+}
@@ -0,0 +1,141 @@
+package response
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// maxSafeInteger is the largest integer JavaScript can represent exactly (2^53-1).
+const maxSafeInteger = 1<<53 - 1
+
+// Int64 is an int64 that encodes as a JSON string when it falls outside the
+// range JavaScript can represent exactly, and decodes from either a JSON
+// number or a JSON string.
+type Int64 int64
+
+// MarshalJSON implements json.Marshaler.
+func (i Int64) MarshalJSON() ([]byte, error) {
+	s := strconv.FormatInt(int64(i), 10)
+	if i > maxSafeInteger || i < -maxSafeInteger {
+		return []byte(`"` + s + `"`), nil
+	}
+	return []byte(s), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (i *Int64) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	v, err := strconv.ParseInt(string(unquoteNumber(b)), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid int64 %s: %w", b, err)
+	}
+	*i = Int64(v)
+	return nil
+}
+
+// Uint64 is a uint64 that encodes as a JSON string when it exceeds the range
+// JavaScript can represent exactly, and decodes from either a JSON number or
+// a JSON string.
+type Uint64 uint64
+
+// MarshalJSON implements json.Marshaler.
+func (u Uint64) MarshalJSON() ([]byte, error) {
+	s := strconv.FormatUint(uint64(u), 10)
+	if u > maxSafeInteger {
+		return []byte(`"` + s + `"`), nil
+	}
+	return []byte(s), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (u *Uint64) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	v, err := strconv.ParseUint(string(unquoteNumber(b)), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid uint64 %s: %w", b, err)
+	}
+	*u = Uint64(v)
+	return nil
+}
+
+func unquoteNumber(b []byte) []byte {
+	if len(b) >= 2 && b[0] == '"' && b[len(b)-1] == '"' {
+		return b[1 : len(b)-1]
+	}
+	return b
+}
+
+// quoteUnsafeIntegers rewrites integer literals in encoded JSON that fall
+// outside ±2^53-1 into JSON strings, leaving everything else untouched.
+func quoteUnsafeIntegers(b []byte) []byte {
+	var out bytes.Buffer
+	out.Grow(len(b))
+
+	inString := false
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		if inString {
+			out.WriteByte(c)
+			switch c {
+			case '\\':
+				if i+1 < len(b) {
+					i++
+					out.WriteByte(b[i])
+				}
+			case '"':
+				inString = false
+			}
+			continue
+		}
+
+		if c == '"' {
+			inString = true
+			out.WriteByte(c)
+			continue
+		}
+
+		if c != '-' && (c < '0' || c > '9') {
+			out.WriteByte(c)
+			continue
+		}
+
+		end := i
+		integer := true
+		for end < len(b) && isNumberByte(b[end]) {
+			if b[end] == '.' || b[end] == 'e' || b[end] == 'E' {
+				integer = false
+			}
+			end++
+		}
+
+		literal := b[i:end]
+		if integer && !isSafeInteger(literal) {
+			out.WriteByte('"')
+			out.Write(literal)
+			out.WriteByte('"')
+		} else {
+			out.Write(literal)
+		}
+		i = end - 1
+	}
+
+	return out.Bytes()
+}
+
+func isNumberByte(c byte) bool {
+	return (c >= '0' && c <= '9') || c == '-' || c == '+' || c == '.' || c == 'e' || c == 'E'
+}
+
+func isSafeInteger(literal []byte) bool {
+	v, err := strconv.ParseInt(string(literal), 10, 64)
+	if err != nil {
+		// Overflows int64 entirely, so it is certainly unsafe.
+		return false
+	}
+	return v <= maxSafeInteger && v >= -maxSafeInteger
+}
@@ -0,0 +1,72 @@
+// Package router provides routing helpers built on Go 1.22 http.ServeMux patterns.
+package router
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+// Group registers routes under a common path prefix on a ServeMux, wrapping
+// them with middleware that applies only to the group's routes, e.g. auth and
+// auditing for /admin while /public only gets rate limiting.
+type Group struct {
+	mux        *http.ServeMux
+	prefix     string
+	middleware []middleware.Middleware
+}
+
+// NewGroup creates a Group registering on mux under prefix.
+func NewGroup(mux *http.ServeMux, prefix string, mws ...middleware.Middleware) *Group {
+	return &Group{mux: mux, prefix: strings.TrimSuffix(prefix, "/"), middleware: mws}
+}
+
+// Use appends middleware to the group. It affects routes registered afterwards.
+func (g *Group) Use(mws ...middleware.Middleware) {
+	g.middleware = append(g.middleware, mws...)
+}
+
+// Group creates a nested group under prefix that inherits g's middleware.
+func (g *Group) Group(prefix string, mws ...middleware.Middleware) *Group {
+	return &Group{
+		mux:        g.mux,
+		prefix:     g.prefix + strings.TrimSuffix(prefix, "/"),
+		middleware: append(slices.Clone(g.middleware), mws...),
+	}
+}
+
+// Handle registers h for pattern, a ServeMux pattern such as "GET /users/{id}"
+// relative to the group prefix.
+func (g *Group) Handle(pattern string, h http.Handler) {
+	g.mux.Handle(JoinPattern(g.prefix, pattern), middleware.Chain(h, g.middleware...))
+}
+
+// HandleFunc registers h for pattern relative to the group prefix.
+func (g *Group) HandleFunc(pattern string, h http.HandlerFunc) {
+	g.Handle(pattern, h)
+}
+
+// JoinPattern inserts prefix in front of the path of a ServeMux pattern,
+// keeping its optional method and host, e.g. ("/admin", "GET /users") yields
+// "GET /admin/users".
+func JoinPattern(prefix, pattern string) string {
+	method, rest, ok := strings.Cut(pattern, " ")
+	if !ok {
+		method, rest = "", pattern
+	}
+	rest = strings.TrimLeft(rest, " \t")
+
+	slash := strings.Index(rest, "/")
+	if slash < 0 {
+		slash = len(rest)
+	}
+	host, path := rest[:slash], rest[slash:]
+
+	joined := host + prefix + path
+	if method == "" {
+		return joined
+	}
+	return method + " " + joined
+}
@@ -0,0 +1,73 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+// Host returns a router for requests to host, so one server can serve
+// several domains:
+//
+//	admin := api.Host("admin.example.com", requireStaff)
+//	admin.Handle(http.MethodGet, "/users", listAllUsers)
+//	tenants := api.Host("{tenant}.example.com")
+//	tenants.Handle(http.MethodGet, "/users", listTenantUsers)
+//
+// A host starting with a "{name}" label matches any single subdomain label,
+// e.g. "acme.example.com" but not "example.com" or "a.b.example.com", and
+// makes the label available as r.PathValue(name) to handlers and middleware
+// such as tenant resolution. A wildcard host router answers every request to
+// matching hosts, including with 404 and 405, while exact hosts registered on
+// api take precedence over it. Patterns report the host, e.g.
+// "GET {tenant}.example.com/users".
+func (rt *Router) Host(host string, mws ...middleware.Middleware) *Router {
+	sub := New(mws...)
+	label, _, _ := strings.Cut(host, ".")
+	if !strings.HasPrefix(label, "{") {
+		rt.Mount(host, sub)
+		return sub
+	}
+
+	if !strings.HasSuffix(label, "}") || len(label) < 3 || strings.ContainsAny(host[len(label):], "{}/") {
+		panic("router: invalid host pattern " + host)
+	}
+	sub.host = strings.ToLower(host)
+	sub.outer = rt
+	rt.hosts = append(rt.hosts, sub)
+	return sub
+}
+
+// matchHost returns the wildcard host router matching r, recording the
+// wildcard label as a path value.
+func (rt *Router) matchHost(r *http.Request) *Router {
+	if len(rt.hosts) == 0 {
+		return nil
+	}
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+
+	for _, sub := range rt.hosts {
+		label, suffix, _ := strings.Cut(sub.host, ".")
+		value, ok := strings.CutSuffix(host, "."+suffix)
+		if !ok || value == "" || strings.Contains(value, ".") {
+			continue
+		}
+		r.SetPathValue(label[1:len(label)-1], value)
+		return sub
+	}
+	return nil
+}
+
+// hasHost reports whether a ServeMux pattern is restricted to a host.
+func hasHost(pattern string) bool {
+	if _, rest, ok := strings.Cut(pattern, " "); ok {
+		pattern = rest
+	}
+	return !strings.HasPrefix(pattern, "/")
+}
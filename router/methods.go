@@ -0,0 +1,100 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// probeMethods are the methods AutoMethods checks when building an Allow header.
+var probeMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// AutoMethods wraps mux to answer the methods it leaves to handlers:
+//
+//   - OPTIONS requests with no matching route get 204 and an Allow header
+//     listing the methods registered for the path.
+//   - HEAD requests served by a GET route run the GET handler with the body
+//     discarded and Content-Length set from its size, so handlers that write
+//     with response.JSON don't fail on HEAD.
+//
+// Routes registered explicitly for OPTIONS or HEAD, e.g. by CORS, take precedence.
+func AutoMethods(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodOptions:
+			if _, pattern := mux.Handler(r); pattern != "" {
+				break
+			}
+			allowed := allowedMethods(mux, r, probeMethods)
+			if len(allowed) == 0 {
+				break
+			}
+			w.Header().Set("Allow", strings.Join(append(allowed, http.MethodOptions), ", "))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case http.MethodHead:
+			if _, pattern := mux.Handler(r); strings.HasPrefix(pattern, http.MethodGet+" ") {
+				hw := &headWriter{ResponseWriter: w, statusCode: http.StatusOK}
+				mux.ServeHTTP(hw, r)
+				hw.finish()
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// allowedMethods returns the methods that have a route for r's path.
+func allowedMethods(mux *http.ServeMux, r *http.Request, methods []string) []string {
+	var allowed []string
+	for _, method := range methods {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		if _, pattern := mux.Handler(probe); pattern != "" {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// headWriter swallows the body of a GET handler answering a HEAD request,
+// counting it so the real Content-Length can be reported.
+type headWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	size        int
+}
+
+func (hw *headWriter) WriteHeader(statusCode int) {
+	if !hw.wroteHeader {
+		hw.wroteHeader = true
+		hw.statusCode = statusCode
+	}
+}
+
+func (hw *headWriter) Write(b []byte) (int, error) {
+	hw.wroteHeader = true
+	hw.size += len(b)
+	return len(b), nil
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (hw *headWriter) Unwrap() http.ResponseWriter {
+	return hw.ResponseWriter
+}
+
+func (hw *headWriter) finish() {
+	header := hw.ResponseWriter.Header()
+	if header.Get("Content-Length") == "" && hw.size > 0 {
+		header.Set("Content-Length", strconv.Itoa(hw.size))
+	}
+	hw.ResponseWriter.WriteHeader(hw.statusCode)
+}
@@ -0,0 +1,48 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+// closureSuffix matches the suffixes the compiler gives closures and method
+// values, e.g. ".func1", ".func2.1" and "-fm".
+var closureSuffix = regexp.MustCompile(`(\.func\d+(\.\d+)*|\.gowrap\d+|-fm)+$`)
+
+// funcName returns the short name of function f, e.g. "middleware.MaxBody"
+// for the closure MaxBody returns.
+func funcName(f any) string {
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	fn := runtime.FuncForPC(v.Pointer())
+	if fn == nil {
+		return ""
+	}
+	name := closureSuffix.ReplaceAllString(fn.Name(), "")
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// handlerName names h by its function, or by its type for other handlers.
+func handlerName(h http.Handler) string {
+	if f, ok := h.(http.HandlerFunc); ok {
+		return funcName(f)
+	}
+	name := fmt.Sprintf("%T", h)
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+func funcNames(mws []middleware.Middleware) []string {
+	names := make([]string, len(mws))
+	for i, mw := range mws {
+		names[i] = funcName(mw)
+	}
+	return names
+}
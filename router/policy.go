@@ -0,0 +1,177 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+// NoRateLimit is the Policy.RateLimit tier exempting a route from rate limiting.
+const NoRateLimit = "-"
+
+// Policy holds limits applied to each route, so an upload endpoint and a
+// health endpoint need not share one global setting. Zero fields inherit
+// from the enclosing policy.
+type Policy struct {
+	// Timeout bounds the handler with middleware.Timeout. Negative disables it.
+	Timeout time.Duration
+	// MaxBody limits request bodies with middleware.MaxBody. Negative removes
+	// the limit, including the default of bind's binders.
+	MaxBody int64
+	// RateLimit names a tier registered with RateLimitTier, or NoRateLimit.
+	RateLimit string
+}
+
+// inherit fills the zero fields of p from parent.
+func (p Policy) inherit(parent Policy) Policy {
+	if p.Timeout == 0 {
+		p.Timeout = parent.Timeout
+	}
+	if p.MaxBody == 0 {
+		p.MaxBody = parent.MaxBody
+	}
+	if p.RateLimit == "" {
+		p.RateLimit = parent.RateLimit
+	}
+	return p
+}
+
+// SetPolicy sets the default policy for the router's routes, including those
+// of routers mounted on it, unless they override it. It must be called
+// before the router serves requests.
+func (rt *Router) SetPolicy(p Policy) {
+	rt.policy = p
+}
+
+// RateLimitTier registers a named rate limit for Policy.RateLimit, e.g.
+// "uploads" at 1 request per second. Each route using the tier gets its own
+// middleware.RateLimit, so limits apply per route unless opts.Store is shared.
+// Tiers are visible to routers mounted on rt and must be registered before
+// the router serves requests.
+func (rt *Router) RateLimitTier(name string, opts middleware.RateLimitOptions) {
+	if name == "" || name == NoRateLimit {
+		panic("router: invalid rate limit tier " + name)
+	}
+	if rt.rateTiers == nil {
+		rt.rateTiers = make(map[string]middleware.RateLimitOptions)
+	}
+	rt.rateTiers[name] = opts
+}
+
+// enclosing returns the router rt is mounted or hosted on, or nil.
+func (rt *Router) enclosing() *Router {
+	if rt.parent != nil {
+		return rt.parent
+	}
+	return rt.outer
+}
+
+// resolvePolicy completes p with the policies of rt and its enclosing routers.
+func (rt *Router) resolvePolicy(p Policy) Policy {
+	for r := rt; r != nil; r = r.enclosing() {
+		p = p.inherit(r.policy)
+	}
+	return p
+}
+
+// applyPolicy wraps h in the rate limit, body limit and timeout of p
+// completed by the policies of rt and its enclosing routers. A route naming
+// an unknown rate limit tier answers with the error, which Check reports
+// before serving.
+func (rt *Router) applyPolicy(p Policy, h http.Handler) http.Handler {
+	p = rt.resolvePolicy(p)
+
+	if p.Timeout > 0 {
+		h = middleware.Timeout(p.Timeout)(h)
+	}
+	switch {
+	case p.MaxBody > 0:
+		h = middleware.MaxBody(p.MaxBody)(h)
+	case p.MaxBody < 0:
+		h = unlimitedBody(h)
+	}
+	if p.RateLimit != "" && p.RateLimit != NoRateLimit {
+		opts, err := rt.rateTier(p.RateLimit)
+		if err != nil {
+			return middleware.Public(func(http.ResponseWriter, *http.Request) error {
+				return err
+			})
+		}
+		h = middleware.RateLimit(opts)(h)
+	}
+	return h
+}
+
+// rateTier looks up a tier on rt and its enclosing routers.
+func (rt *Router) rateTier(name string) (middleware.RateLimitOptions, error) {
+	for r := rt; r != nil; r = r.enclosing() {
+		if opts, ok := r.rateTiers[name]; ok {
+			return opts, nil
+		}
+	}
+	return middleware.RateLimitOptions{}, fmt.Errorf("router: unknown rate limit tier %q", name)
+}
+
+// Check reports routes whose policy names a rate limit tier registered
+// neither on their router nor on those it is mounted or hosted on, so a
+// misspelled tier fails at startup instead of on every request.
+// server.Server.Start calls it before listening. Call it on the outermost
+// router once every router is mounted.
+func (rt *Router) Check() error {
+	for _, ep := range rt.endpoints {
+		p := rt.resolvePolicy(ep.policy)
+		if p.RateLimit == "" || p.RateLimit == NoRateLimit {
+			continue
+		}
+		if _, err := rt.rateTier(p.RateLimit); err != nil {
+			return fmt.Errorf("%w on %s", err, ep.route)
+		}
+	}
+	for _, sub := range slices.Concat(rt.mounts, rt.hosts) {
+		if err := sub.Check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unlimitedBody records a zero body limit, which bind treats as no limit.
+func unlimitedBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*r = *r.WithContext(context.WithValue(r.Context(), middleware.MaxBodyContextKey, int64(0)))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Endpoint configures a registered route.
+type Endpoint struct {
+	router  *Router
+	pattern string
+	route   string
+	policy  Policy
+}
+
+// Timeout overrides the policy timeout for the route. Negative disables it.
+func (e *Endpoint) Timeout(d time.Duration) *Endpoint {
+	e.policy.Timeout = d
+	return e
+}
+
+// MaxBody overrides the policy body limit for the route. Negative removes it.
+func (e *Endpoint) MaxBody(n int64) *Endpoint {
+	e.policy.MaxBody = n
+	return e
+}
+
+// RateLimit overrides the policy rate limit tier for the route, e.g.
+//
+//	r.Handle(http.MethodPost, "/uploads", upload).Timeout(5 * time.Minute).MaxBody(100 << 20).RateLimit("uploads")
+//	r.Handle(http.MethodGet, "/healthz", health).RateLimit(router.NoRateLimit)
+func (e *Endpoint) RateLimit(tier string) *Endpoint {
+	e.policy.RateLimit = tier
+	return e
+}
@@ -0,0 +1,157 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Name names the route for URL and AbsoluteURL, e.g. "user.get". Names must
+// be unique within a router; those of mounted and Host routers can be
+// resolved from the routers they are attached to.
+func (e *Endpoint) Name(name string) *Endpoint {
+	rt := e.router
+	if rt.names == nil {
+		rt.names = make(map[string]string)
+	}
+	if _, ok := rt.names[name]; ok {
+		panic("router: route name " + name + " registered twice")
+	}
+	rt.names[name] = e.pattern
+	return e
+}
+
+// lookup returns the pattern of the route called name, relative to rt.
+func (rt *Router) lookup(name string) (string, bool) {
+	if pattern, ok := rt.names[name]; ok {
+		return pattern, true
+	}
+	for _, sub := range rt.mounts {
+		if pattern, ok := sub.lookup(name); ok {
+			return JoinPattern(sub.prefix, pattern), true
+		}
+	}
+	for _, sub := range rt.hosts {
+		if pattern, ok := sub.lookup(name); ok {
+			return JoinPattern(sub.host, pattern), true
+		}
+	}
+	return "", false
+}
+
+// URL builds the path of the route called name, filling its wildcards from
+// alternating name and value pairs; values are formatted with fmt.Sprint and
+// escaped. Pairs not naming a wildcard become query parameters:
+//
+//	r.Handle(http.MethodGet, "/users/{id}", getUser).Name("user.get")
+//	r.URL("user.get", "id", 42, "expand", "orders") // "/users/42?expand=orders"
+//
+// The host of host-specific routes is dropped; use AbsoluteURL to keep it.
+func (rt *Router) URL(name string, pairs ...any) (string, error) {
+	_, path, err := rt.reverse(name, pairs)
+	return path, err
+}
+
+// AbsoluteURL is URL with the scheme and host of r, or the route's own host
+// for routes registered with a host, e.g. via Host.
+func (rt *Router) AbsoluteURL(r *http.Request, name string, pairs ...any) (string, error) {
+	host, path, err := rt.reverse(name, pairs)
+	if err != nil {
+		return "", err
+	}
+	if host == "" {
+		host = r.Host
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + host + path, nil
+}
+
+// reverse fills the host and path of the route called name.
+func (rt *Router) reverse(name string, pairs []any) (host, path string, err error) {
+	pattern, ok := rt.lookup(name)
+	if !ok {
+		return "", "", fmt.Errorf("router: no route named %q", name)
+	}
+	if len(pairs)%2 != 0 {
+		return "", "", fmt.Errorf("router: odd number of URL parameters for %q", name)
+	}
+	params := make(map[string]string, len(pairs)/2)
+	var keys []string
+	for i := 0; i < len(pairs); i += 2 {
+		key := fmt.Sprint(pairs[i])
+		params[key] = fmt.Sprint(pairs[i+1])
+		keys = append(keys, key)
+	}
+
+	if _, rest, ok := strings.Cut(pattern, " "); ok {
+		pattern = rest
+	}
+	slash := strings.Index(pattern, "/")
+	if slash < 0 {
+		slash = len(pattern)
+	}
+	used := make(map[string]bool)
+	if host, err = fill(pattern[:slash], params, used, false); err != nil {
+		return "", "", fmt.Errorf("router: %s: %w", name, err)
+	}
+	if path, err = fill(pattern[slash:], params, used, true); err != nil {
+		return "", "", fmt.Errorf("router: %s: %w", name, err)
+	}
+
+	query := url.Values{}
+	for _, key := range keys {
+		if !used[key] {
+			query.Add(key, params[key])
+		}
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return host, path, nil
+}
+
+// fill replaces the {name} and {name...} wildcards of a pattern part with
+// params, marking the ones it uses.
+func fill(pattern string, params map[string]string, used map[string]bool, escape bool) (string, error) {
+	var b strings.Builder
+	for {
+		open := strings.Index(pattern, "{")
+		if open < 0 {
+			b.WriteString(pattern)
+			return b.String(), nil
+		}
+		end := strings.Index(pattern[open:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("malformed pattern %q", pattern)
+		}
+		b.WriteString(pattern[:open])
+		wildcard := pattern[open+1 : open+end]
+		pattern = pattern[open+end+1:]
+		if wildcard == "$" {
+			continue
+		}
+
+		key, rest := strings.CutSuffix(wildcard, "...")
+		value, ok := params[key]
+		if !ok {
+			return "", fmt.Errorf("missing parameter %q", key)
+		}
+		used[key] = true
+		switch {
+		case !escape:
+		case rest:
+			segments := strings.Split(value, "/")
+			for i, s := range segments {
+				segments[i] = url.PathEscape(s)
+			}
+			value = strings.Join(segments, "/")
+		default:
+			value = url.PathEscape(value)
+		}
+		b.WriteString(value)
+	}
+}
@@ -0,0 +1,319 @@
+package router
+
+import (
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/response"
+)
+
+// Route describes a registered route.
+type Route struct {
+	// Method is the HTTP method, or "" for routes matching any method.
+	Method string `json:"method,omitempty"`
+	// Pattern is the path pattern, e.g. "/users/{id}".
+	Pattern string `json:"pattern"`
+	// Middleware names the middleware wrapping the route, outermost first,
+	// e.g. "middleware.RequestLogger".
+	Middleware []string `json:"middleware,omitempty"`
+	// Handler names the handler function, e.g. "users.(*Handler).Get".
+	Handler string `json:"handler"`
+	// Request and Response are the input and output types of routes
+	// registered with HandleTyped, for schema generation.
+	Request  reflect.Type `json:"-"`
+	Response reflect.Type `json:"-"`
+}
+
+// String returns the route as a ServeMux pattern, e.g. "GET /users/{id}".
+func (rt Route) String() string {
+	if rt.Method == "" {
+		return rt.Pattern
+	}
+	return rt.Method + " " + rt.Pattern
+}
+
+// Router registers middleware.APIFunc handlers on ServeMux patterns. It
+// applies Public to every handler, supports middleware for the whole router
+// and per route, and records the matched pattern so middleware.RequestLogger
+// and metrics can label requests by route rather than by raw path:
+//
+//	r := router.New(middleware.RequestLogger, middleware.Recover)
+//	r.Handle(http.MethodGet, "/users/{id}", getUser)
+//	r.Handle(http.MethodPost, "/users", createUser, middleware.MaxBody(1<<20))
+//	http.ListenAndServe(":8080", r)
+//
+// Routers can be split across packages and mounted at a prefix with Mount.
+type Router struct {
+	mux        *http.ServeMux
+	middleware []middleware.Middleware
+	routes     []route
+	versions   []version
+
+	notFound         http.Handler
+	methodNotAllowed http.Handler
+
+	// parent and prefix are set once the router is mounted.
+	parent *Router
+	prefix string
+	// host is the wildcard host pattern of a router created by Host, and
+	// hosts are the wildcard host routers of this one.
+	host  string
+	hosts []*Router
+	// outer is the router a Host router was created on.
+	outer *Router
+
+	policy    Policy
+	rateTiers map[string]middleware.RateLimitOptions
+	endpoints []*Endpoint
+
+	names  map[string]string
+	mounts []*Router
+
+	once    sync.Once
+	handler http.Handler
+}
+
+// route is a registered Route with its handler, kept so that mounting can
+// re-register it under the mount prefix.
+type route struct {
+	Route
+	handler http.Handler
+}
+
+// New creates a Router whose requests all pass through mws, including those
+// matching no route.
+func New(mws ...middleware.Middleware) *Router {
+	return &Router{mux: http.NewServeMux(), middleware: mws}
+}
+
+// Use appends router-wide middleware. It must be called before the router serves requests.
+func (rt *Router) Use(mws ...middleware.Middleware) {
+	rt.middleware = append(rt.middleware, mws...)
+}
+
+// Handle registers h for method and pattern, a ServeMux path pattern such as
+// "/users/{id}" or "api.example.com/items/". An empty method matches any method.
+// mws wrap only this route, inside the router-wide middleware.
+// The returned Endpoint configures per-route overrides such as timeouts.
+func (rt *Router) Handle(method, pattern string, h middleware.APIFunc, mws ...middleware.Middleware) *Endpoint {
+	return rt.handle(Route{Method: method, Pattern: pattern, Handler: funcName(h)}, middleware.Public(h), mws)
+}
+
+// HandleHTTP registers a plain http.Handler like Handle, for handlers that
+// manage their own responses such as proxies and file servers.
+func (rt *Router) HandleHTTP(method, pattern string, h http.Handler, mws ...middleware.Middleware) *Endpoint {
+	return rt.handle(Route{Method: method, Pattern: pattern, Handler: handlerName(h)}, h, mws)
+}
+
+func (rt *Router) handle(r Route, h http.Handler, mws []middleware.Middleware) *Endpoint {
+	r.Middleware = funcNames(mws)
+	h = middleware.Chain(h, mws...)
+
+	ep := &Endpoint{router: rt, pattern: r.Pattern, route: r.String()}
+	rt.endpoints = append(rt.endpoints, ep)
+	// The policy is resolved on first use, once overrides and the policies of
+	// the routers this one is mounted on are known.
+	withPolicy := sync.OnceValue(func() http.Handler {
+		return rt.applyPolicy(ep.policy, h)
+	})
+	rt.register(r, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		withPolicy().ServeHTTP(w, r)
+	}))
+	return ep
+}
+
+// Mount registers every route of sub under prefix, e.g.
+//
+//	api.Mount("/v1/users", users.NewRouter())
+//
+// makes sub's "GET /{id}" reachable and reported as "GET /v1/users/{id}".
+// Requests pass through api's middleware, then sub's, then the route's own.
+// Routes registered on sub after mounting are added to api as well. A router
+// can be mounted only once and is served through its parent from then on.
+func (rt *Router) Mount(prefix string, sub *Router) {
+	if sub.parent != nil || sub.host != "" {
+		panic("router: Mount of a router that is already mounted")
+	}
+	if sub == rt {
+		panic("router: Mount of a router on itself")
+	}
+	sub.parent = rt
+	sub.prefix = strings.TrimSuffix(prefix, "/")
+	rt.mounts = append(rt.mounts, sub)
+	for _, r := range sub.routes {
+		rt.register(sub.mountedRoute(r.Route), sub.inherit(r.handler))
+	}
+}
+
+// register records route and adds h to the mux, or to the parent's when rt is mounted.
+func (rt *Router) register(r Route, h http.Handler) {
+	rt.routes = append(rt.routes, route{Route: r, handler: h})
+	if rt.parent != nil {
+		rt.parent.register(rt.mountedRoute(r), rt.inherit(h))
+		return
+	}
+
+	name := r.String()
+	reported := name
+	if rt.host != "" {
+		reported = JoinPattern(rt.host, name)
+	}
+	rt.mux.Handle(name, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		middleware.SetRoutePattern(r.Context(), reported)
+		h.ServeHTTP(w, r)
+	}))
+}
+
+// mountedRoute returns r as seen by the parent router.
+func (rt *Router) mountedRoute(r Route) Route {
+	r.Pattern = JoinPattern(rt.prefix, r.Pattern)
+	r.Middleware = append(funcNames(rt.middleware), r.Middleware...)
+	return r
+}
+
+// inherit wraps h, a route of a mounted router, in the router's middleware.
+// The chain is built on first use so that Use still applies until the
+// router serves requests.
+func (rt *Router) inherit(h http.Handler) http.Handler {
+	chain := sync.OnceValue(func() http.Handler {
+		return middleware.Chain(h, rt.middleware...)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chain().ServeHTTP(w, r)
+	})
+}
+
+// Routes returns the registered routes in registration order, including
+// those of mounted routers with their mount prefix. Route.Middleware starts
+// with the router-wide middleware.
+func (rt *Router) Routes() []Route {
+	global := funcNames(rt.middleware)
+	routes := make([]Route, len(rt.routes))
+	for i, r := range rt.routes {
+		routes[i] = r.Route
+		routes[i].Middleware = append(slices.Clone(global), r.Middleware...)
+	}
+	for _, sub := range rt.hosts {
+		for _, r := range sub.Routes() {
+			r.Pattern = JoinPattern(sub.host, r.Pattern)
+			r.Middleware = append(slices.Clone(global), r.Middleware...)
+			routes = append(routes, r)
+		}
+	}
+	return routes
+}
+
+// RoutesHandler serves Routes as JSON, for ops tooling and client generation.
+// Register it behind authentication, e.g.
+//
+//	r.HandleHTTP(http.MethodGet, "/debug/routes", r.RoutesHandler(), adminOnly)
+func (rt *Router) RoutesHandler() http.Handler {
+	return middleware.Public(func(w http.ResponseWriter, _ *http.Request) error {
+		return response.JSON(w, http.StatusOK, rt.Routes())
+	})
+}
+
+// NotFound replaces the 404 response for requests matching no route. The
+// default is a JSON APIError typed "not_found".
+func (rt *Router) NotFound(h middleware.APIFunc) {
+	rt.notFound = middleware.Public(h)
+}
+
+// MethodNotAllowed replaces the 405 response for requests whose path matches
+// routes for other methods only. The Allow header is set before h runs. The
+// default is a JSON APIError typed "method_not_allowed".
+func (rt *Router) MethodNotAllowed(h middleware.APIFunc) {
+	rt.methodNotAllowed = middleware.Public(h)
+}
+
+// dispatch serves matched routes through the mux and answers the rest inside
+// the router-wide middleware, so they are logged like any other response:
+// OPTIONS requests get 204 with an Allow header listing the methods registered
+// for the path, other methods registered for the path only get the
+// MethodNotAllowed handler with the same header, and the rest get NotFound.
+// Routes registered explicitly for OPTIONS, e.g. by CORS, take precedence.
+func (rt *Router) dispatch() http.Handler {
+	methods := rt.methods()
+	notFound := rt.notFound
+	if notFound == nil {
+		notFound = middleware.Public(func(http.ResponseWriter, *http.Request) error {
+			return apierr.NewError(http.StatusNotFound, "not_found", "not found")
+		})
+	}
+	methodNotAllowed := rt.methodNotAllowed
+	if methodNotAllowed == nil {
+		methodNotAllowed = middleware.Public(func(http.ResponseWriter, *http.Request) error {
+			return apierr.NewError(http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		})
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := rt.mux.Handler(r)
+		if pattern != "" && hasHost(pattern) {
+			rt.mux.ServeHTTP(w, r)
+			return
+		}
+		if sub := rt.matchHost(r); sub != nil {
+			sub.chain().ServeHTTP(w, r)
+			return
+		}
+		if pattern != "" {
+			rt.mux.ServeHTTP(w, r)
+			return
+		}
+		allowed := allowedMethods(rt.mux, r, methods)
+		if len(allowed) == 0 {
+			notFound.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Allow", strings.Join(append(allowed, http.MethodOptions), ", "))
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		methodNotAllowed.ServeHTTP(w, r)
+	})
+}
+
+// methods returns the methods of the registered routes, with HEAD for GET,
+// in the order of probeMethods followed by any others.
+func (rt *Router) methods() []string {
+	registered := make(map[string]bool)
+	for _, r := range rt.routes {
+		registered[r.Method] = true
+		if r.Method == http.MethodGet {
+			registered[http.MethodHead] = true
+		}
+	}
+	delete(registered, "")
+	delete(registered, http.MethodOptions)
+
+	var methods []string
+	for _, method := range probeMethods {
+		if registered[method] {
+			methods = append(methods, method)
+			delete(registered, method)
+		}
+	}
+	return append(methods, slices.Sorted(maps.Keys(registered))...)
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	*r = *r.WithContext(middleware.WithRoute(r.Context()))
+	rt.chain().ServeHTTP(w, r)
+}
+
+// chain returns the router's handler wrapped in its router-wide middleware,
+// building it on first use.
+func (rt *Router) chain() http.Handler {
+	rt.once.Do(func() {
+		rt.handler = middleware.Chain(rt.resolveVersion(rt.dispatch()), rt.middleware...)
+	})
+	return rt.handler
+}
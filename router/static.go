@@ -0,0 +1,216 @@
+package router
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/response"
+)
+
+// StaticOptions configures Static.
+type StaticOptions struct {
+	// Index is the file served for directories and as the SPA fallback.
+	// Defaults to "index.html".
+	Index string
+	// SPA serves Index for unknown paths without a file extension, so client
+	// side routes like /settings/profile load the app. Unknown asset paths
+	// such as /app.js still get 404.
+	SPA bool
+	// Immutable reports whether a file name is content-addressed and may be
+	// cached forever. Defaults to detecting a hash right before the
+	// extension, as in "app.3f9a2c1e.js" or "index-BxT2k9Qa.css"; set it for
+	// other naming schemes.
+	Immutable func(name string) bool
+	// MaxAge is the cache lifetime of immutable files. Defaults to one year.
+	// Other files are served with Cache-Control: no-cache so they are revalidated.
+	MaxAge time.Duration
+	// DevDir, when set, serves files from this directory on disk instead of
+	// fsys, with caching disabled, so edits to an embedded frontend show up on
+	// reload without rebuilding. Leave it empty in production.
+	DevDir string
+}
+
+// Static serves files from fsys, e.g. an embed.FS holding a frontend bundle.
+// Dotfiles and directory listings are never served, and because fs.FS only
+// accepts clean relative names, paths cannot escape fsys.
+//
+// Files are looked up by the full r.URL.Path, so when Static is mounted under
+// a prefix, strip it first:
+//
+//	r.HandleHTTP(http.MethodGet, "/app/", http.StripPrefix("/app", router.Static(dist, router.StaticOptions{SPA: true})))
+func Static(fsys fs.FS, opts StaticOptions) http.Handler {
+	if opts.Index == "" {
+		opts.Index = "index.html"
+	}
+	if opts.Immutable == nil {
+		opts.Immutable = hashedAsset
+	}
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = 365 * 24 * time.Hour
+	}
+	immutable := "public, max-age=" + strconv.Itoa(int(opts.MaxAge.Seconds())) + ", immutable"
+	if opts.DevDir != "" {
+		root, err := os.OpenRoot(opts.DevDir)
+		if err != nil {
+			panic("router: " + err.Error())
+		}
+		fsys = root.FS()
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			_ = response.JSON(w, http.StatusMethodNotAllowed, apierr.NewError(http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed"))
+			return
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if name == "" {
+			name = "."
+		}
+
+		resolved, ok := resolveFile(fsys, name, opts.Index)
+		if !ok && opts.SPA && path.Ext(name) == "" && !hiddenPath(name) {
+			resolved, ok = resolveFile(fsys, opts.Index, opts.Index)
+		}
+		if !ok {
+			_ = response.JSON(w, http.StatusNotFound, apierr.NewError(http.StatusNotFound, "not_found", "not found"))
+			return
+		}
+
+		switch {
+		case opts.DevDir != "":
+			w.Header().Set("Cache-Control", "no-store")
+		case opts.Immutable(path.Base(resolved)):
+			w.Header().Set("Cache-Control", immutable)
+		default:
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+		serveFile(w, r, fsys, resolved)
+	})
+}
+
+// StaticDir is Static for a directory on disk. Symlinks pointing outside dir
+// are not followed. It panics if dir cannot be opened.
+func StaticDir(dir string, opts StaticOptions) http.Handler {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		panic("router: " + err.Error())
+	}
+	return Static(root.FS(), opts)
+}
+
+// resolveFile maps name to a regular file in fsys, descending into index for
+// directories. Hidden files are reported as missing.
+func resolveFile(fsys fs.FS, name, index string) (string, bool) {
+	if hiddenPath(name) {
+		return "", false
+	}
+	info, err := fs.Stat(fsys, name)
+	if err == nil && info.IsDir() {
+		name = path.Join(name, index)
+		info, err = fs.Stat(fsys, name)
+	}
+	if err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+	return name, true
+}
+
+// serveFile writes the named file with range and conditional request support.
+func serveFile(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			_ = response.JSON(w, http.StatusNotFound, apierr.NewError(http.StatusNotFound, "not_found", "not found"))
+			return
+		}
+		_ = response.JSON(w, http.StatusInternalServerError, apierr.NewError(http.StatusInternalServerError, "internal", "internal server error"))
+		return
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = response.JSON(w, http.StatusInternalServerError, apierr.NewError(http.StatusInternalServerError, "internal", "internal server error"))
+		return
+	}
+
+	// Files from embed.FS and os.Root are seekable, which ServeContent needs for
+	// ranges and content sniffing; other filesystems are read into memory.
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			_ = response.JSON(w, http.StatusInternalServerError, apierr.NewError(http.StatusInternalServerError, "internal", "internal server error"))
+			return
+		}
+		content = bytes.NewReader(b)
+	}
+	http.ServeContent(w, r, info.Name(), info.ModTime(), content)
+}
+
+// hiddenPath reports whether any element of name starts with a dot, keeping
+// files like .env and .git out of reach.
+func hiddenPath(name string) bool {
+	for _, elem := range strings.Split(name, "/") {
+		if strings.HasPrefix(elem, ".") && elem != "." {
+			return true
+		}
+	}
+	return false
+}
+
+// hashedAsset reports whether a file name ends in a content hash right
+// before its extension, delimited by "." or "-": either at least 8 lowercase
+// hex characters mixing letters and digits, as in "app.3f9a2c1e.js", or at
+// least 8 alphanumerics mixing upper case, lower case and digits, as in
+// "index-BxT2k9Qa.css". Names like "bootstrap5.min.css" or
+// "favicon32x32.png" are not hashed.
+func hashedAsset(name string) bool {
+	base := strings.TrimSuffix(name, path.Ext(name))
+	if base == name {
+		return false
+	}
+	i := strings.LastIndexAny(base, ".-")
+	if i < 0 {
+		return false
+	}
+	seg := base[i+1:]
+	if len(seg) < 8 {
+		return false
+	}
+
+	var lower, upper, digits, hex int
+	for _, c := range seg {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c >= 'a' && c <= 'z':
+			lower++
+			if c <= 'f' {
+				hex++
+			}
+		case c >= 'A' && c <= 'Z':
+			upper++
+		default:
+			return false
+		}
+	}
+	if digits == 0 || lower == 0 {
+		return false
+	}
+	if upper == 0 {
+		return hex == lower
+	}
+	return true
+}
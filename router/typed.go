@@ -0,0 +1,94 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+
+	"github.com/piheta/apicore/bind"
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/response"
+)
+
+// TypedOption configures Typed.
+type TypedOption func(*typedConfig)
+
+type typedConfig struct {
+	status     int
+	bind       []bind.Option
+	middleware []middleware.Middleware
+}
+
+// Status sets the success status, e.g. http.StatusCreated. Defaults to 200;
+// with http.StatusNoContent the response is written without a body.
+func Status(code int) TypedOption {
+	return func(c *typedConfig) {
+		c.status = code
+	}
+}
+
+// BindOptions passes opts to bind.Request, e.g. bind.Strict().
+func BindOptions(opts ...bind.Option) TypedOption {
+	return func(c *typedConfig) {
+		c.bind = append(c.bind, opts...)
+	}
+}
+
+// With adds per-route middleware to a route registered with HandleTyped.
+// Typed alone ignores it.
+func With(mws ...middleware.Middleware) TypedOption {
+	return func(c *typedConfig) {
+		c.middleware = append(c.middleware, mws...)
+	}
+}
+
+// Typed adapts a handler taking and returning plain structs to an APIFunc.
+// The request is bound and validated with bind.Request, so Req declares its
+// sources with `path`, `query`, `header` and `json` tags; the result is
+// written with response.JSON, and errors from either side are mapped like
+// any other APIFunc error:
+//
+//	func createUser(ctx context.Context, req CreateUserRequest) (UserResponse, error)
+//
+//	r.Handle(http.MethodPost, "/users", router.Typed(createUser, router.Status(http.StatusCreated)))
+//
+// Use HandleTyped to also record Req and Resp in the route metadata.
+func Typed[Req, Resp any](h func(ctx context.Context, req Req) (Resp, error), opts ...TypedOption) middleware.APIFunc {
+	cfg := newTypedConfig(opts)
+	return func(w http.ResponseWriter, r *http.Request) error {
+		req, err := bind.As[Req](r, cfg.bind...)
+		if err != nil {
+			return err
+		}
+		resp, err := h(r.Context(), req)
+		if err != nil {
+			return err
+		}
+		if cfg.status == http.StatusNoContent {
+			return response.Status(w, cfg.status)
+		}
+		return response.JSON(w, cfg.status, resp)
+	}
+}
+
+// HandleTyped registers h on rt like rt.Handle(method, pattern, Typed(h, opts...)),
+// recording Req and Resp as Route.Request and Route.Response so tooling can
+// derive schemas from Routes. Per-route middleware is added with With.
+func HandleTyped[Req, Resp any](rt *Router, method, pattern string, h func(ctx context.Context, req Req) (Resp, error), opts ...TypedOption) *Endpoint {
+	route := Route{
+		Method:   method,
+		Pattern:  pattern,
+		Handler:  funcName(h),
+		Request:  reflect.TypeFor[Req](),
+		Response: reflect.TypeFor[Resp](),
+	}
+	return rt.handle(route, middleware.Public(Typed(h, opts...)), newTypedConfig(opts).middleware)
+}
+
+func newTypedConfig(opts []TypedOption) typedConfig {
+	cfg := typedConfig{status: http.StatusOK}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
@@ -0,0 +1,185 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/middleware"
+)
+
+type contextKey string
+
+// VersionContextKey is the key for storing the resolved API version in request context.
+const VersionContextKey contextKey = "Version"
+
+// GetVersion returns the API version serving the request, e.g. "v2", or "" outside versioned routes.
+func GetVersion(ctx context.Context) string {
+	if v, ok := ctx.Value(VersionContextKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// VersionOptions configures an API version registered with Router.Version.
+type VersionOptions struct {
+	// Default serves requests that name no version, neither by path prefix
+	// nor by Accept-Version header.
+	Default bool
+	// Deprecated adds a "Deprecation: true" header to every response of the version.
+	Deprecated bool
+	// Sunset is when the version stops being served. When set, responses carry
+	// it in a Sunset header and the version counts as deprecated.
+	Sunset time.Time
+	// Link points at migration documentation, sent as a Link header with
+	// rel="deprecation" on deprecated versions.
+	Link string
+}
+
+type version struct {
+	name string
+	opts VersionOptions
+}
+
+// Version returns a router for the API version name, e.g. "v1", mounted at
+// "/v1". Clients pick a version by path prefix (/v1/users) or, for paths
+// without one, with an Accept-Version header ("v1" or "1"):
+//
+//	v1 := api.Version("v1", router.VersionOptions{Sunset: sunset, Link: "https://docs.example.com/v2-migration"})
+//	v1.Handle(http.MethodGet, "/users", listUsersV1)
+//	v2 := api.Version("v2", router.VersionOptions{Default: true})
+//	v2.Handle(http.MethodGet, "/users", listUsers)
+//
+// Unknown versions, in either form, are rejected with a 400 APIError typed
+// "unsupported_version".
+func (rt *Router) Version(name string, opts VersionOptions) *Router {
+	if name == "" || strings.Contains(name, "/") {
+		panic("router: invalid version " + name)
+	}
+	if slices.ContainsFunc(rt.versions, func(v version) bool { return v.name == name }) {
+		panic("router: version " + name + " registered twice")
+	}
+	if opts.Default && slices.ContainsFunc(rt.versions, func(v version) bool { return v.opts.Default }) {
+		panic("router: more than one default version")
+	}
+	rt.versions = append(rt.versions, version{name: name, opts: opts})
+
+	sub := New(versionHeaders(name, opts))
+	rt.Mount("/"+name, sub)
+	return sub
+}
+
+// versionHeaders records the version in context and announces deprecation.
+func versionHeaders(name string, opts VersionOptions) middleware.Middleware {
+	deprecated := opts.Deprecated || !opts.Sunset.IsZero()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if deprecated {
+				w.Header().Set("Deprecation", "true")
+				if !opts.Sunset.IsZero() {
+					w.Header().Set("Sunset", opts.Sunset.UTC().Format(http.TimeFormat))
+				}
+				if opts.Link != "" {
+					w.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"deprecation\"", opts.Link))
+				}
+			}
+			*r = *r.WithContext(context.WithValue(r.Context(), VersionContextKey, name))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// resolveVersion routes requests without a version prefix to the version
+// named by Accept-Version, or the default version, by prefixing their path.
+// Paths matching an unversioned route, such as /healthz or /debug/, are
+// served as is.
+func (rt *Router) resolveVersion(next http.Handler) http.Handler {
+	unsupported := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+		names := make([]string, len(rt.versions))
+		for i, v := range rt.versions {
+			names[i] = v.name
+		}
+		return apierr.NewError(http.StatusBadRequest, "unsupported_version",
+			fmt.Sprintf("unsupported API version, expected one of: %s", strings.Join(names, ", ")))
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(rt.versions) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		segment, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		if rt.hasVersion(segment) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if isVersionSegment(segment) {
+			unsupported.ServeHTTP(w, r)
+			return
+		}
+		if _, pattern := rt.mux.Handler(r); pattern != "" && !isCatchAll(pattern) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		name := ""
+		if requested := r.Header.Get("Accept-Version"); requested != "" {
+			w.Header().Add("Vary", "Accept-Version")
+			name = rt.matchVersion(requested)
+			if name == "" {
+				unsupported.ServeHTTP(w, r)
+				return
+			}
+		} else if i := slices.IndexFunc(rt.versions, func(v version) bool { return v.opts.Default }); i >= 0 {
+			name = rt.versions[i].name
+		}
+
+		if name != "" {
+			u := *r.URL
+			u.Path = "/" + name + u.Path
+			u.RawPath = ""
+			r.URL = &u
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (rt *Router) hasVersion(name string) bool {
+	return slices.ContainsFunc(rt.versions, func(v version) bool { return v.name == name })
+}
+
+// matchVersion returns the registered version requested, accepting "v2" and "2".
+func (rt *Router) matchVersion(requested string) string {
+	requested = strings.TrimSpace(requested)
+	for _, v := range rt.versions {
+		if v.name == requested || v.name == "v"+requested {
+			return v.name
+		}
+	}
+	return ""
+}
+
+// isCatchAll reports whether pattern matches every path, like "/" or
+// "GET /{path...}", so matching it does not make a path unversioned.
+func isCatchAll(pattern string) bool {
+	path := pattern[strings.Index(pattern, "/"):]
+	return path == "/" || (strings.HasPrefix(path, "/{") && strings.HasSuffix(path, "...}") && strings.Count(path, "/") == 1)
+}
+
+// isVersionSegment reports whether a path segment looks like a version, e.g. "v3".
+func isVersionSegment(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	for _, c := range s[1:] {
+		if (c < '0' || c > '9') && c != '.' {
+			return false
+		}
+	}
+	return true
+}
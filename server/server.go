@@ -0,0 +1,366 @@
+// Package server runs a router.Router on an http.Server with sensible
+// timeouts, a standard middleware stack and graceful shutdown, so main stays short:
+//
+//	func main() {
+//		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+//		defer stop()
+//
+//		srv := server.New(server.Options{Addr: ":8080"})
+//		srv.Handle(http.MethodGet, "/ping", ping)
+//		srv.OnShutdown(func(ctx context.Context) error { return db.Close() })
+//		if err := srv.Run(ctx); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/piheta/apicore/healthcheck"
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/router"
+)
+
+// Options configures a Server. Zero durations use the defaults noted on each field.
+type Options struct {
+	// Addr is the TCP address to listen on. Defaults to ":8080".
+	Addr string
+	// Middleware wraps every request. Defaults to DefaultMiddleware.
+	Middleware []middleware.Middleware
+
+	// ReadHeaderTimeout bounds reading request headers. Defaults to 5 seconds.
+	ReadHeaderTimeout time.Duration
+	// ReadTimeout bounds reading the whole request. Defaults to 30 seconds.
+	ReadTimeout time.Duration
+	// WriteTimeout bounds writing the response. Defaults to 30 seconds.
+	WriteTimeout time.Duration
+	// IdleTimeout bounds keep-alive connections between requests. Defaults to 2 minutes.
+	IdleTimeout time.Duration
+	// ShutdownTimeout bounds the graceful drain in Run. Defaults to 15 seconds.
+	ShutdownTimeout time.Duration
+
+	// Health, when set, is drained first on Stop so its readiness handler
+	// fails while the server still serves, letting load balancers route new
+	// requests elsewhere before connections are refused.
+	Health *healthcheck.Checker
+	// DrainDelay is how long Stop keeps serving after draining Health, at
+	// least the load balancer's or Kubernetes readiness probe's detection
+	// time, e.g. 10 seconds. Run adds it to ShutdownTimeout. Defaults to none.
+	DrainDelay time.Duration
+
+	// TLSConfig enables HTTPS. Certificates can come from it or from CertFile and KeyFile.
+	TLSConfig *tls.Config
+	// CertFile and KeyFile enable HTTPS with a certificate loaded from disk.
+	CertFile, KeyFile string
+	// CertManager enables HTTPS with certificates obtained on demand, e.g.
+	// from Let's Encrypt. See CertManager.
+	CertManager CertManager
+	// RedirectAddr, when set (typically ":80"), adds a plain HTTP listener
+	// that redirects requests to HTTPS and answers ACME HTTP-01 challenges
+	// through CertManager.
+	RedirectAddr string
+
+	// H2C serves HTTP/2 without TLS alongside HTTP/1.1, for internal traffic
+	// from meshes and gateways that speak prior-knowledge h2c.
+	H2C bool
+	// HTTP3, when set, creates an HTTP/3 server for the same handler and TLS
+	// config, started and stopped with the Server. Responses over TCP then
+	// advertise it with an Alt-Svc header. Experimental.
+	HTTP3 func(addr string, h http.Handler, cfg *tls.Config) HTTP3Server
+}
+
+// HTTP3Server is an HTTP/3 server started alongside the TCP one, e.g. an
+// *http3.Server from github.com/quic-go/quic-go/http3:
+//
+//	HTTP3: func(addr string, h http.Handler, cfg *tls.Config) server.HTTP3Server {
+//		return &http3.Server{Addr: addr, Handler: h, TLSConfig: http3.ConfigureTLSConfig(cfg)}
+//	},
+type HTTP3Server interface {
+	ListenAndServe() error
+	Shutdown(ctx context.Context) error
+}
+
+// CertManager provides TLS certificates and answers ACME HTTP-01
+// challenges. *autocert.Manager from golang.org/x/crypto/acme/autocert
+// implements it, with the host allowlist and certificate cache configured there:
+//
+//	m := &autocert.Manager{
+//		Prompt:     autocert.AcceptTOS,
+//		HostPolicy: autocert.HostWhitelist("api.example.com"),
+//		Cache:      autocert.DirCache("/var/lib/myapp/certs"),
+//	}
+//	srv := server.New(server.Options{Addr: ":443", RedirectAddr: ":80", CertManager: m})
+type CertManager interface {
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+	// HTTPHandler serves ACME challenges and passes other requests to fallback.
+	HTTPHandler(fallback http.Handler) http.Handler
+}
+
+// DefaultMiddleware returns the stack used when Options.Middleware is empty:
+// request IDs, request logging, then panic recovery.
+func DefaultMiddleware() []middleware.Middleware {
+	return []middleware.Middleware{middleware.RequestID, middleware.RequestLogger, middleware.Recover}
+}
+
+// Server is a Router served by an http.Server. Register routes on it like on
+// any Router, then call Run, or Start and Stop.
+type Server struct {
+	*router.Router
+
+	opts     Options
+	srv      *http.Server
+	redirect *http.Server
+	h3       HTTP3Server
+
+	mu       sync.Mutex
+	listener net.Listener
+	hooks    []func(context.Context) error
+	done     chan error
+}
+
+// New creates a Server configured by opts.
+func New(opts Options) *Server {
+	if opts.Addr == "" {
+		opts.Addr = ":8080"
+	}
+	if len(opts.Middleware) == 0 {
+		opts.Middleware = DefaultMiddleware()
+	}
+	if opts.ReadHeaderTimeout == 0 {
+		opts.ReadHeaderTimeout = 5 * time.Second
+	}
+	if opts.ReadTimeout == 0 {
+		opts.ReadTimeout = 30 * time.Second
+	}
+	if opts.WriteTimeout == 0 {
+		opts.WriteTimeout = 30 * time.Second
+	}
+	if opts.IdleTimeout == 0 {
+		opts.IdleTimeout = 2 * time.Minute
+	}
+	if opts.ShutdownTimeout == 0 {
+		opts.ShutdownTimeout = 15 * time.Second
+	}
+
+	s := &Server{Router: router.New(opts.Middleware...), opts: opts}
+	s.srv = &http.Server{
+		Addr:              opts.Addr,
+		Handler:           s.Router,
+		ReadHeaderTimeout: opts.ReadHeaderTimeout,
+		ReadTimeout:       opts.ReadTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+		TLSConfig:         opts.TLSConfig,
+	}
+	if opts.CertManager != nil {
+		cfg := &tls.Config{}
+		if opts.TLSConfig != nil {
+			cfg = opts.TLSConfig.Clone()
+		}
+		cfg.GetCertificate = opts.CertManager.GetCertificate
+		// acme-tls/1 lets the manager answer TLS-ALPN-01 challenges.
+		for _, proto := range []string{"h2", "http/1.1", "acme-tls/1"} {
+			if !slices.Contains(cfg.NextProtos, proto) {
+				cfg.NextProtos = append(cfg.NextProtos, proto)
+			}
+		}
+		s.srv.TLSConfig = cfg
+	}
+	if opts.H2C {
+		s.srv.Protocols = new(http.Protocols)
+		s.srv.Protocols.SetHTTP1(true)
+		s.srv.Protocols.SetHTTP2(true)
+		s.srv.Protocols.SetUnencryptedHTTP2(true)
+	}
+	if opts.HTTP3 != nil {
+		s.h3 = opts.HTTP3(opts.Addr, s.Router, s.srv.TLSConfig)
+		s.srv.Handler = altSvc(opts.Addr, s.Router)
+	}
+	if opts.RedirectAddr != "" {
+		var h http.Handler = redirectHTTPS(opts.Addr)
+		if opts.CertManager != nil {
+			h = opts.CertManager.HTTPHandler(h)
+		}
+		s.redirect = &http.Server{
+			Addr:              opts.RedirectAddr,
+			Handler:           h,
+			ReadHeaderTimeout: opts.ReadHeaderTimeout,
+			ReadTimeout:       opts.ReadTimeout,
+			WriteTimeout:      opts.WriteTimeout,
+			IdleTimeout:       opts.IdleTimeout,
+		}
+	}
+	return s
+}
+
+// altSvc advertises HTTP/3 on the port of addr.
+func altSvc(addr string, next http.Handler) http.Handler {
+	_, port, _ := net.SplitHostPort(addr)
+	value := fmt.Sprintf(`h3=":%s"; ma=86400`, port)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Alt-Svc", value)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// redirectHTTPS redirects to the same host and URI over HTTPS on the port of
+// addr. Methods other than GET and HEAD get 308 so clients replay the body.
+func redirectHTTPS(addr string) http.Handler {
+	_, port, _ := net.SplitHostPort(addr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+
+		code := http.StatusMovedPermanently
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			code = http.StatusPermanentRedirect
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), code)
+	})
+}
+
+// OnShutdown registers fn to run during Stop once in-flight requests have
+// drained, e.g. to close database pools. Hooks run in reverse registration order.
+func (s *Server) OnShutdown(fn func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, fn)
+}
+
+// Start listens on Options.Addr, and Options.RedirectAddr when set, and
+// serves in the background. It returns once the listeners are bound, so
+// address errors surface immediately, as do the route errors reported by
+// router.Router.Check.
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener != nil {
+		return errors.New("server: already started")
+	}
+	if err := s.Check(); err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", s.opts.Addr)
+	if err != nil {
+		return err
+	}
+	var redirectLn net.Listener
+	if s.redirect != nil {
+		if redirectLn, err = net.Listen("tcp", s.opts.RedirectAddr); err != nil {
+			_ = ln.Close()
+			return err
+		}
+	}
+
+	s.listener = ln
+	s.done = make(chan error, 3)
+	useTLS := s.srv.TLSConfig != nil || s.opts.CertFile != ""
+	go s.serve(s.srv, ln, useTLS)
+	if redirectLn != nil {
+		go s.serve(s.redirect, redirectLn, false)
+	}
+	if s.h3 != nil {
+		go func() {
+			err := s.h3.ListenAndServe()
+			if errors.Is(err, http.ErrServerClosed) {
+				err = nil
+			}
+			s.done <- err
+		}()
+	}
+	return nil
+}
+
+// serve runs srv on ln, reporting unexpected failures on s.done.
+func (s *Server) serve(srv *http.Server, ln net.Listener, useTLS bool) {
+	var err error
+	if useTLS {
+		err = srv.ServeTLS(ln, s.opts.CertFile, s.opts.KeyFile)
+	} else {
+		err = srv.Serve(ln)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+	s.done <- err
+}
+
+// Addr returns the address the server listens on, or nil before Start.
+// It resolves the port chosen for addresses like ":0".
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Stop drains Options.Health and keeps serving for Options.DrainDelay with
+// keep-alives disabled, then stops accepting connections, waits for in-flight
+// requests to finish or ctx to expire, and runs the OnShutdown hooks.
+func (s *Server) Stop(ctx context.Context) error {
+	if s.opts.Health != nil {
+		s.opts.Health.Drain()
+	}
+	if s.opts.DrainDelay > 0 {
+		s.srv.SetKeepAlivesEnabled(false)
+		timer := time.NewTimer(s.opts.DrainDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	err := s.srv.Shutdown(ctx)
+	if s.redirect != nil {
+		err = errors.Join(err, s.redirect.Shutdown(ctx))
+	}
+	if s.h3 != nil {
+		err = errors.Join(err, s.h3.Shutdown(ctx))
+	}
+
+	s.mu.Lock()
+	hooks := append([]func(context.Context) error(nil), s.hooks...)
+	s.mu.Unlock()
+
+	errs := []error{err}
+	for i := len(hooks) - 1; i >= 0; i-- {
+		errs = append(errs, hooks[i](ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// Run starts the server and blocks until ctx is done or serving fails, then
+// stops it, allowing Options.ShutdownTimeout for the drain. A clean shutdown returns nil.
+func (s *Server) Run(ctx context.Context) error {
+	if err := s.Start(); err != nil {
+		return err
+	}
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+	case serveErr = <-s.done:
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.opts.DrainDelay+s.opts.ShutdownTimeout)
+	defer cancel()
+	return errors.Join(serveErr, s.Stop(stopCtx))
+}
@@ -0,0 +1,191 @@
+package tests
+
+import (
+	"maps"
+	"net/http"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/piheta/apicore/apicoretest"
+	"github.com/piheta/apicore/bind"
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/response"
+	"github.com/piheta/apicore/router"
+)
+
+// fakeTB records Errorf and Fatalf instead of failing the enclosing test.
+type fakeTB struct {
+	testing.TB
+	failed bool
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(string, ...any) {
+	f.failed = true
+}
+
+func (f *fakeTB) Fatalf(string, ...any) {
+	f.failed = true
+	runtime.Goexit()
+}
+
+// fails reports whether fn calls Errorf or Fatalf on its testing.TB.
+func fails(t *testing.T, fn func(tb testing.TB)) bool {
+	tb := &fakeTB{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(tb)
+	}()
+	<-done
+	return tb.failed
+}
+
+func apicoretestRouter() *router.Router {
+	type userInput struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+	type user struct {
+		ID    int    `json:"id"`
+		Email string `json:"email"`
+	}
+
+	rt := router.New()
+	rt.Handle(http.MethodPost, "/users", func(w http.ResponseWriter, r *http.Request) error {
+		var in userInput
+		if err := bind.JSON(r, &in); err != nil {
+			return err
+		}
+		return response.Created(w, "/users/1", user{ID: 1, Email: in.Email})
+	})
+	return rt
+}
+
+func TestApicoretest_DoHandler(t *testing.T) {
+	rt := apicoretestRouter()
+
+	resp := apicoretest.DoHandler(rt, apicoretest.NewJSONRequest(http.MethodPost, "/users", map[string]string{"email": "a@example.com"}))
+	got := apicoretest.RequireJSON[map[string]any](t, resp, http.StatusCreated)
+	if got["email"] != "a@example.com" || resp.Header.Get("Location") != "/users/1" {
+		t.Errorf("Expected created user, got %v %v", got, resp.Header)
+	}
+
+	tests := []struct {
+		name   string
+		body   any
+		status int
+		typ    string
+	}{
+		{"validation", map[string]string{"email": "nope"}, http.StatusUnprocessableEntity, "validation"},
+		{"raw string", `{"email":`, http.StatusBadRequest, "json"},
+		{"raw bytes", []byte(`[]`), http.StatusBadRequest, "json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := apicoretest.DoHandler(rt, apicoretest.NewJSONRequest(http.MethodPost, "/users", tt.body))
+			apiErr := apicoretest.RequireAPIError(t, resp, tt.status, tt.typ)
+			if apiErr.Message == nil {
+				t.Errorf("Expected APIError message, got %+v", apiErr)
+			}
+		})
+	}
+}
+
+func TestApicoretest_Failures(t *testing.T) {
+	rt := apicoretestRouter()
+	created := apicoretest.DoHandler(rt, apicoretest.NewJSONRequest(http.MethodPost, "/users", map[string]string{"email": "a@example.com"}))
+	invalid := apicoretest.DoHandler(rt, apicoretest.NewJSONRequest(http.MethodPost, "/users", map[string]string{}))
+
+	tests := []struct {
+		name string
+		fn   func(tb testing.TB)
+		fail bool
+	}{
+		{"status matches", func(tb testing.TB) { apicoretest.RequireStatus(tb, created, http.StatusCreated) }, false},
+		{"status differs", func(tb testing.TB) { apicoretest.RequireStatus(tb, created, http.StatusOK) }, true},
+		{"error type matches", func(tb testing.TB) {
+			apicoretest.RequireAPIError(tb, invalid, http.StatusUnprocessableEntity, "validation")
+		}, false},
+		{"error type differs", func(tb testing.TB) { apicoretest.RequireAPIError(tb, invalid, http.StatusUnprocessableEntity, "json") }, true},
+		{"not an error", func(tb testing.TB) { apicoretest.RequireAPIError(tb, created, http.StatusCreated, "validation") }, true},
+		{"decode mismatch", func(tb testing.TB) { apicoretest.DecodeJSON[[]string](tb, created) }, true},
+	}
+	for _, tt := range tests {
+		if got := fails(t, tt.fn); got != tt.fail {
+			t.Errorf("%s: expected failure=%v, got %v", tt.name, tt.fail, got)
+		}
+	}
+}
+
+func TestNewJSONRequest(t *testing.T) {
+	r := apicoretest.NewJSONRequest(http.MethodGet, "/users?page=2", nil)
+	if r.Header.Get("Content-Type") != "" || r.Header.Get("Accept") != "application/json" || r.URL.Query().Get("page") != "2" {
+		t.Errorf("Expected bodyless JSON request, got %v %v", r.Header, r.URL)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected panic for unencodable body")
+		}
+	}()
+	apicoretest.NewJSONRequest(http.MethodPost, "/", make(chan int))
+}
+
+func TestNewServer(t *testing.T) {
+	rt := apicoretestRouter()
+	rt.Handle(http.MethodGet, "/panic", func(http.ResponseWriter, *http.Request) error {
+		panic("boom")
+	})
+	rt.Handle(http.MethodGet, "/users/{id}", func(w http.ResponseWriter, r *http.Request) error {
+		middleware.Log(r.Context()).Info("loading user", "id", r.PathValue("id"))
+		return response.JSON(w, http.StatusOK, map[string]string{"id": r.PathValue("id")})
+	})
+
+	srv := apicoretest.NewServer(rt, apicoretest.ServerOptions{
+		Logger: middleware.LoggerOptions{Fields: []middleware.LogField{middleware.FieldStatus, middleware.FieldRequestID}},
+	})
+	defer srv.Close()
+
+	resp := srv.Do(t, apicoretest.NewJSONRequest(http.MethodGet, "/users/7", nil))
+	apicoretest.RequireStatus(t, resp, http.StatusOK)
+	requestID := resp.Header.Get(middleware.RequestIDHeader)
+	if requestID == "" {
+		t.Errorf("Expected RequestID middleware to set %s", middleware.RequestIDHeader)
+	}
+
+	logs := srv.Logs()
+	if len(logs) != 2 {
+		t.Fatalf("Expected handler and request logs, got %+v", logs)
+	}
+	if logs[0].Message != "loading user" || logs[0].Attrs["id"] != "7" || logs[0].Attrs["request_id"] != requestID {
+		t.Errorf("Expected handler log with request ID, got %+v", logs[0])
+	}
+	if logs[1].Message != "REQ" || logs[1].Attrs["status"] != int64(200) || logs[1].Attrs["route"] != "GET /users/{id}" {
+		t.Errorf("Expected request log for the route, got %+v", logs[1])
+	}
+
+	srv.ResetLogs()
+	resp = srv.Do(t, apicoretest.NewJSONRequest(http.MethodGet, "/panic", nil))
+	apicoretest.RequireAPIError(t, resp, http.StatusInternalServerError, "internal")
+	var messages []string
+	for _, entry := range srv.Logs() {
+		messages = append(messages, entry.Message)
+	}
+	if !slices.Equal(messages, []string{"PANIC", "REQ"}) {
+		t.Errorf("Expected panic and request logs, got %v", messages)
+	}
+
+	resp = srv.Do(t, apicoretest.NewJSONRequest(http.MethodPost, "/users", map[string]string{}))
+	apicoretest.RequireAPIError(t, resp, http.StatusUnprocessableEntity, "validation")
+
+	classes := make(map[string]int64)
+	for _, h := range srv.Histograms() {
+		classes[h.Route+" "+h.Class] = h.Count
+	}
+	want := map[string]int64{"GET /users/{id} 2xx": 1, "GET /panic 5xx": 1, "POST /users 4xx": 1}
+	if !maps.Equal(classes, want) {
+		t.Errorf("Expected histograms %v, got %v", want, classes)
+	}
+}
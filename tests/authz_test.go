@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/middleware"
+)
+
+func TestRequireScopes(t *testing.T) {
+	handler := middleware.RequireScopes("orders:read", "orders:write")(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		claims     middleware.Claims
+		wantStatus int
+		wantMsg    string
+	}{
+		{name: "all scopes", claims: middleware.Claims{"scope": "orders:read orders:write"}, wantStatus: http.StatusOK},
+		{name: "scp list", claims: middleware.Claims{"scp": []any{"orders:read", "orders:write"}}, wantStatus: http.StatusOK},
+		{name: "missing scope", claims: middleware.Claims{"scope": "orders:read"}, wantStatus: http.StatusForbidden, wantMsg: "missing scope: orders:write"},
+		{name: "no claims", wantStatus: http.StatusUnauthorized, wantMsg: "authentication required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/orders", nil)
+			if tt.claims != nil {
+				r = r.WithContext(context.WithValue(r.Context(), middleware.ClaimsContextKey, tt.claims))
+			}
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantMsg == "" {
+				return
+			}
+			var result apierr.APIError
+			if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if result.Message != tt.wantMsg {
+				t.Errorf("Expected message %q, got %v", tt.wantMsg, result.Message)
+			}
+		})
+	}
+}
+
+func TestRequireRoles(t *testing.T) {
+	handler := middleware.RequireRoles("admin")(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	r = r.WithContext(context.WithValue(r.Context(), middleware.ClaimsContextKey, middleware.Claims{"roles": []any{"user"}}))
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+func TestBasicAuth(t *testing.T) {
+	handler := middleware.BasicAuth("admin", middleware.StaticCredentials("root", "hunter2"))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		user, pass string
+		setAuth    bool
+		wantStatus int
+	}{
+		{name: "valid", user: "root", pass: "hunter2", setAuth: true, wantStatus: http.StatusOK},
+		{name: "wrong password", user: "root", pass: "nope", setAuth: true, wantStatus: http.StatusUnauthorized},
+		{name: "missing", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if tt.setAuth {
+				r.SetBasicAuth(tt.user, tt.pass)
+			}
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") != `Basic realm="admin", charset="UTF-8"` {
+				t.Errorf("Unexpected challenge %q", w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
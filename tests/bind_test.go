@@ -0,0 +1,602 @@
+package tests
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/piheta/apicore/bind"
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/response"
+	"github.com/piheta/apicore/validate"
+)
+
+type createUserRequest struct {
+	Name  string `json:"name" validate:"required,min=2"`
+	Email string `json:"email" validate:"required,email"`
+	Age   int    `json:"age" validate:"omitempty,gte=18"`
+}
+
+func TestBindJSON(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+		expectedType string
+	}{
+		{name: "valid", body: `{"name":"ada","email":"ada@example.com"}`, expectedCode: http.StatusOK},
+		{name: "empty body", body: ``, expectedCode: http.StatusBadRequest, expectedType: "json"},
+		{name: "syntax error", body: `{"name":`, expectedCode: http.StatusBadRequest, expectedType: "json"},
+		{name: "wrong type", body: `{"name":1}`, expectedCode: http.StatusBadRequest, expectedType: "json"},
+		{name: "trailing data", body: `{"name":"ada","email":"ada@example.com"}{}`, expectedCode: http.StatusBadRequest, expectedType: "json"},
+		{name: "invalid", body: `{"name":"a","email":"nope","age":3}`, expectedCode: http.StatusUnprocessableEntity, expectedType: "validation"},
+		{name: "too large", body: `{"name":"` + strings.Repeat("a", int(bind.DefaultMaxBytes)) + `"}`, expectedCode: http.StatusRequestEntityTooLarge, expectedType: "payload_too_large"},
+	}
+
+	handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+		var req createUserRequest
+		if err := bind.JSON(r, &req); err != nil {
+			return err
+		}
+		return response.JSON(w, http.StatusOK, req)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body)))
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.expectedType != "" {
+				if got := decodeErrorType(t, w); got != tt.expectedType {
+					t.Errorf("Expected type %q, got %q", tt.expectedType, got)
+				}
+			}
+		})
+	}
+}
+
+func TestBindJSON_ValidationMessage(t *testing.T) {
+	handler := middleware.Public(func(_ http.ResponseWriter, r *http.Request) error {
+		var req createUserRequest
+		return bind.JSON(r, &req)
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"a"}`)))
+
+	var body struct {
+		Msg map[string]string `json:"msg"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	expected := map[string]string{"name": "min", "email": "required"}
+	for field, tag := range expected {
+		if body.Msg[field] != tag {
+			t.Errorf("Expected %s to fail on %q, got %q", field, tag, body.Msg[field])
+		}
+	}
+}
+
+type testID [4]byte
+
+func (id *testID) UnmarshalText(b []byte) error {
+	if len(b) != 8 {
+		return errors.New("invalid id")
+	}
+	_, err := hex.Decode(id[:], b)
+	return err
+}
+
+func TestBindPath(t *testing.T) {
+	type params struct {
+		Org   string  `path:"org" validate:"required,alpha"`
+		ID    int64   `path:"id" validate:"gt=0"`
+		Token *testID `path:"token"`
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		expectedCode int
+		expectedType string
+	}{
+		{name: "valid", path: "/orgs/acme/users/42/0a0b0c0d", expectedCode: http.StatusOK},
+		{name: "not an integer", path: "/orgs/acme/users/abc/0a0b0c0d", expectedCode: http.StatusBadRequest, expectedType: "invalid_path"},
+		{name: "overflow", path: "/orgs/acme/users/99999999999999999999/0a0b0c0d", expectedCode: http.StatusBadRequest, expectedType: "invalid_path"},
+		{name: "text unmarshaler", path: "/orgs/acme/users/42/zz", expectedCode: http.StatusBadRequest, expectedType: "invalid_path"},
+		{name: "validation", path: "/orgs/acme/users/-1/0a0b0c0d", expectedCode: http.StatusUnprocessableEntity, expectedType: "validation"},
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /orgs/{org}/users/{id}/{token}", middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+		var p params
+		if err := bind.Path(r, &p); err != nil {
+			return err
+		}
+		if p.Org != "acme" || p.ID != 42 || *p.Token != (testID{0x0a, 0x0b, 0x0c, 0x0d}) {
+			t.Errorf("Unexpected params %+v", p)
+		}
+		return response.Status(w, http.StatusOK)
+	}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.expectedType != "" {
+				if got := decodeErrorType(t, w); got != tt.expectedType {
+					t.Errorf("Expected type %q, got %q", tt.expectedType, got)
+				}
+			}
+		})
+	}
+}
+
+func decodeErrorType(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return body.Type
+}
+
+func TestBindForm(t *testing.T) {
+	type tokenRequest struct {
+		GrantType string   `form:"grant_type" validate:"required,oneof=client_credentials refresh_token"`
+		Scope     []string `form:"scope"`
+		TTL       int      `form:"ttl"`
+	}
+
+	tests := []struct {
+		name         string
+		contentType  string
+		body         string
+		expectedCode int
+		expectedType string
+	}{
+		{name: "valid", contentType: "application/x-www-form-urlencoded", body: "grant_type=client_credentials&scope=read&scope=write&ttl=60", expectedCode: http.StatusOK},
+		{name: "charset", contentType: "application/x-www-form-urlencoded; charset=utf-8", body: "grant_type=client_credentials&scope=read&scope=write&ttl=60", expectedCode: http.StatusOK},
+		{name: "wrong content type", contentType: "application/json", body: `{"grant_type":"client_credentials"}`, expectedCode: http.StatusUnsupportedMediaType, expectedType: "unsupported_media_type"},
+		{name: "bad integer", contentType: "application/x-www-form-urlencoded", body: "grant_type=client_credentials&ttl=soon", expectedCode: http.StatusBadRequest, expectedType: "invalid_form"},
+		{name: "malformed", contentType: "application/x-www-form-urlencoded", body: "grant_type=%zz", expectedCode: http.StatusBadRequest, expectedType: "invalid_form"},
+		{name: "validation", contentType: "application/x-www-form-urlencoded", body: "grant_type=password", expectedCode: http.StatusUnprocessableEntity, expectedType: "validation"},
+	}
+
+	handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+		var req tokenRequest
+		if err := bind.Form(r, &req); err != nil {
+			return err
+		}
+		if req.TTL != 60 || len(req.Scope) != 2 || req.Scope[1] != "write" {
+			t.Errorf("Unexpected request %+v", req)
+		}
+		return response.Status(w, http.StatusOK)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/token?grant_type=refresh_token", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.expectedType != "" {
+				if got := decodeErrorType(t, w); got != tt.expectedType {
+					t.Errorf("Expected type %q, got %q", tt.expectedType, got)
+				}
+			}
+		})
+	}
+}
+
+func TestBindJSON_Strict(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []bind.Option
+		body         string
+		expectedCode int
+		expectedMsg  string
+	}{
+		{name: "lenient ignores unknown", body: `{"name":"ada","email":"ada@example.com","emial":"x"}`, expectedCode: http.StatusOK},
+		{name: "strict rejects unknown", opts: []bind.Option{bind.Strict()}, body: `{"name":"ada","email":"ada@example.com","emial":"x"}`, expectedCode: http.StatusBadRequest, expectedMsg: `unknown field "emial"`},
+		{name: "strict accepts known", opts: []bind.Option{bind.Strict()}, body: `{"name":"ada","email":"ada@example.com"}`, expectedCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+				var req createUserRequest
+				if err := bind.JSON(r, &req, tt.opts...); err != nil {
+					return err
+				}
+				return response.Status(w, http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body)))
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.expectedMsg == "" {
+				return
+			}
+			var body struct {
+				Type string `json:"type"`
+				Msg  string `json:"msg"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if body.Type != "unknown_field" || body.Msg != tt.expectedMsg {
+				t.Errorf("Expected unknown_field %q, got %s %q", tt.expectedMsg, body.Type, body.Msg)
+			}
+		})
+	}
+}
+
+func TestBindDefaults(t *testing.T) {
+	type listParams struct {
+		Page    int      `query:"page" default:"1" validate:"gte=1"`
+		PerPage int      `query:"per_page" default:"20" validate:"max=100"`
+		Sort    string   `query:"sort" default:"created_at" validate:"oneof=created_at name"`
+		States  []string `query:"state" default:"open,pending"`
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		expected     listParams
+		expectedCode int
+	}{
+		{name: "defaults", query: "", expected: listParams{Page: 1, PerPage: 20, Sort: "created_at", States: []string{"open", "pending"}}, expectedCode: http.StatusOK},
+		{name: "overrides", query: "?page=3&per_page=50&sort=name&state=closed", expected: listParams{Page: 3, PerPage: 50, Sort: "name", States: []string{"closed"}}, expectedCode: http.StatusOK},
+		{name: "validated after defaults", query: "?per_page=500", expectedCode: http.StatusUnprocessableEntity},
+		{name: "bad value", query: "?page=first", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got listParams
+			handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+				if err := bind.Query(r, &got); err != nil {
+					return err
+				}
+				return response.Status(w, http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items"+tt.query, nil))
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.expectedCode == http.StatusOK && !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBindDefaults_JSON(t *testing.T) {
+	type settings struct {
+		Theme  string `json:"theme" default:"light"`
+		Limits struct {
+			Max int `json:"max" default:"10"`
+		} `json:"limits"`
+	}
+
+	var got settings
+	handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+		if err := bind.JSON(r, &got); err != nil {
+			return err
+		}
+		return response.Status(w, http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/settings", strings.NewReader(`{"theme":"dark"}`)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if got.Theme != "dark" || got.Limits.Max != 10 {
+		t.Errorf("Expected theme dark and max 10, got %+v", got)
+	}
+}
+
+func TestBindNewValidator(t *testing.T) {
+	v := bind.NewValidator(bind.ValidatorOptions{
+		Rules: map[string]validate.Rule{
+			"slug": func(_ context.Context, fv reflect.Value, _ string) bool {
+				return !strings.ContainsAny(fv.String(), " /")
+			},
+		},
+		Translations: map[string]validate.Translations{
+			"en": {"slug": "{field} must be URL safe"},
+			"nb": {"required": "{field} er påkrevd"},
+		},
+	})
+
+	type createPage struct {
+		Title string `json:"page_title" validate:"required"`
+		Slug  string `json:"slug" validate:"slug"`
+	}
+
+	tests := []struct {
+		name     string
+		lang     string
+		expected map[string]string
+	}{
+		{name: "english", expected: map[string]string{"page_title": "page_title is required", "slug": "slug must be URL safe"}},
+		{name: "norwegian", lang: "nb", expected: map[string]string{"page_title": "page_title er påkrevd", "slug": "slug must be URL safe"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.Public(func(_ http.ResponseWriter, r *http.Request) error {
+				var req createPage
+				return bind.JSON(r, &req, bind.WithValidator(v))
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/pages", strings.NewReader(`{"slug":"a b"}`))
+			if tt.lang != "" {
+				req.Header.Set("Accept-Language", tt.lang)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			var body struct {
+				Msg map[string]string `json:"msg"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if !reflect.DeepEqual(body.Msg, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, body.Msg)
+			}
+		})
+	}
+}
+
+// stubValidator stands in for a third-party validator such as go-playground's.
+type stubValidator struct{}
+
+func (stubValidator) StructCtx(context.Context, any) error {
+	return mockValidationErrors{{field: "Email", tag: "required"}}
+}
+
+func TestBindJSON_CustomValidator(t *testing.T) {
+	handler := middleware.Public(func(_ http.ResponseWriter, r *http.Request) error {
+		var req createUserRequest
+		return bind.JSON(r, &req, bind.WithValidator(stubValidator{}))
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"ada"}`)))
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"email":"required"`) {
+		t.Errorf("Expected the validator's errors in the response, got %s", w.Body.String())
+	}
+}
+
+func TestBindJSON_MaxBytes(t *testing.T) {
+	body := `{"name":"` + strings.Repeat("a", 2000) + `","email":"ada@example.com"}`
+
+	tests := []struct {
+		name         string
+		opts         []bind.Option
+		mws          []middleware.Middleware
+		chunked      bool
+		expectedCode int
+	}{
+		{name: "within default", expectedCode: http.StatusOK},
+		{name: "option", opts: []bind.Option{bind.MaxBytes(1024)}, expectedCode: http.StatusRequestEntityTooLarge},
+		{name: "option without content length", opts: []bind.Option{bind.MaxBytes(1024)}, chunked: true, expectedCode: http.StatusRequestEntityTooLarge},
+		{name: "route limit", mws: []middleware.Middleware{middleware.MaxBody(4096)}, expectedCode: http.StatusOK},
+		{name: "option overrides route limit", mws: []middleware.Middleware{middleware.MaxBody(4096)}, opts: []bind.Option{bind.MaxBytes(1024)}, expectedCode: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.Chain(middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+				var req createUserRequest
+				if err := bind.JSON(r, &req, tt.opts...); err != nil {
+					return err
+				}
+				return response.Status(w, http.StatusOK)
+			}), tt.mws...)
+
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestBindJSON_RouteLimitAboveDefault(t *testing.T) {
+	body := `{"name":"` + strings.Repeat("a", int(bind.DefaultMaxBytes)) + `","email":"ada@example.com"}`
+
+	handler := middleware.MaxBody(4 << 20)(middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+		var req createUserRequest
+		if err := bind.JSON(r, &req); err != nil {
+			return err
+		}
+		return response.Status(w, http.StatusOK)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body)))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestBindJSON_Scenario(t *testing.T) {
+	type userInput struct {
+		Name  string `json:"name" validate:"required" validate_update:"omitempty,min=2"`
+		Email string `json:"email" validate:"required,email" validate_update:"omitempty,email"`
+	}
+
+	tests := []struct {
+		name         string
+		opts         []bind.Option
+		body         string
+		expectedCode int
+	}{
+		{name: "create requires all", body: `{"name":"Ada"}`, expectedCode: http.StatusUnprocessableEntity},
+		{name: "update allows partial", opts: []bind.Option{bind.Scenario("update")}, body: `{"name":"Ada"}`, expectedCode: http.StatusOK},
+		{name: "update validates supplied", opts: []bind.Option{bind.Scenario("update")}, body: `{"email":"nope"}`, expectedCode: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+				var req userInput
+				if err := bind.JSON(r, &req, tt.opts...); err != nil {
+					return err
+				}
+				return response.Status(w, http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/users/1", strings.NewReader(tt.body)))
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestBindModifiers(t *testing.T) {
+	bind.RegisterModifier("digits", func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r < '0' || r > '9' {
+				return -1
+			}
+			return r
+		}, s)
+	})
+
+	type signup struct {
+		Email    string   `json:"email" mod:"trim,lower" validate:"required,email"`
+		Name     *string  `json:"name" mod:"strip_ctrl,squash"`
+		Phone    string   `json:"phone" mod:"digits"`
+		Tags     []string `json:"tags" mod:"trim,upper"`
+		Country  string   `json:"country" mod:"trim" default:"NO"`
+		Untagged string   `json:"untagged"`
+		Profile  struct {
+			Bio string `json:"bio" mod:"trim"`
+		} `json:"profile"`
+	}
+
+	var got signup
+	handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+		if err := bind.JSON(r, &got); err != nil {
+			return err
+		}
+		return response.Status(w, http.StatusOK)
+	})
+
+	body := `{"email":"  Ada@Example.COM ","name":"Ada\u0000  \u001b Lovelace","phone":"+47 912-34-567",` +
+		`"tags":[" go ","api"],"country":"   ","untagged":" keep ","profile":{"bio":"  hi  "}}`
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if got.Email != "ada@example.com" {
+		t.Errorf("Expected email ada@example.com, got %q", got.Email)
+	}
+	if got.Name == nil || *got.Name != "Ada Lovelace" {
+		t.Errorf("Expected name Ada Lovelace, got %v", got.Name)
+	}
+	if got.Phone != "4791234567" {
+		t.Errorf("Expected phone 4791234567, got %q", got.Phone)
+	}
+	if !reflect.DeepEqual(got.Tags, []string{"GO", "API"}) {
+		t.Errorf("Expected tags [GO API], got %v", got.Tags)
+	}
+	if got.Country != "NO" {
+		t.Errorf("Expected default applied after trimming, got %q", got.Country)
+	}
+	if got.Untagged != " keep " || got.Profile.Bio != "hi" {
+		t.Errorf("Unexpected untagged %q or bio %q", got.Untagged, got.Profile.Bio)
+	}
+}
+
+func TestBindModifiers_Unknown(t *testing.T) {
+	type profile struct {
+		Bio string `json:"bio" mod:"trimm"`
+	}
+	type signup struct {
+		Email   string  `json:"email" mod:"trim"`
+		Profile profile `json:"profile"`
+	}
+
+	if err := bind.Check(signup{}); err == nil || !strings.Contains(err.Error(), `"trimm"`) {
+		t.Errorf("Expected Check to report the unknown modifier, got %v", err)
+	}
+
+	handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+		var req signup
+		if err := bind.JSON(r, &req); err != nil {
+			return err
+		}
+		return response.Status(w, http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(`{"email":"a"}`)))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+func TestBindCheck(t *testing.T) {
+	if err := bind.Check(createUserRequest{}); err != nil {
+		t.Errorf("Expected valid tags, got %v", err)
+	}
+	type invalidRule struct {
+		Name string `json:"name" validate:"requird"`
+	}
+	if err := bind.Check(&invalidRule{}); err == nil {
+		t.Error("Expected Check to report the validator's unknown rule")
+	}
+	if err := bind.Check(42); err == nil {
+		t.Error("Expected error for non-struct")
+	}
+}
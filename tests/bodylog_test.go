@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+func echoJSON(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+func TestBodyLogger_Redaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	handler := middleware.BodyLogger(middleware.BodyLogOptions{
+		Logger: logger,
+		Redact: []string{"password", "cards.number"},
+	})(http.HandlerFunc(echoJSON))
+
+	body := `{"user":"ann","password":"hunter2","cards":[{"number":"4242","brand":"visa"}]}`
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(w, r)
+
+	if w.Body.String() != body {
+		t.Errorf("Expected handler to receive the full body, got %q", w.Body.String())
+	}
+	logged := buf.String()
+	for _, secret := range []string{"hunter2", "4242"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("Expected %q to be redacted, got %q", secret, logged)
+		}
+	}
+	if !strings.Contains(logged, "visa") || !strings.Contains(logged, "[REDACTED]") {
+		t.Errorf("Expected redacted bodies in log, got %q", logged)
+	}
+}
+
+func TestBodyLogger_Limits(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		redact      []string
+		wantLogged  string
+		wantAbsent  string
+	}{
+		{name: "truncated text", contentType: "text/plain", body: strings.Repeat("a", 20), wantLogged: "aaaaaaaaaa...[truncated]"},
+		{name: "truncated json with redaction", contentType: "application/json", body: `{"password":"hunter2000"}`, redact: []string{"password"}, wantLogged: "omitted", wantAbsent: "hunter"},
+		{name: "binary skipped", contentType: "application/octet-stream", body: "secret", wantAbsent: "secret"},
+		{name: "form redaction", contentType: "application/x-www-form-urlencoded", body: "pin=1234&x", redact: []string{"pin"}, wantLogged: "pin=%5BREDACTED%5D", wantAbsent: "1234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			handler := middleware.BodyLogger(middleware.BodyLogOptions{
+				Logger:   slog.New(slog.NewTextHandler(&buf, nil)),
+				MaxBytes: 10,
+				Redact:   tt.redact,
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.ReadAll(r.Body)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+
+			r := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if tt.wantLogged != "" && !strings.Contains(buf.String(), tt.wantLogged) {
+				t.Errorf("Expected log to contain %q, got %q", tt.wantLogged, buf.String())
+			}
+			if tt.wantAbsent != "" && strings.Contains(buf.String(), tt.wantAbsent) {
+				t.Errorf("Expected log not to contain %q, got %q", tt.wantAbsent, buf.String())
+			}
+		})
+	}
+}
+
+func TestBodyLogger_DebugToken(t *testing.T) {
+	secret := []byte("debug-secret")
+
+	tests := []struct {
+		name      string
+		token     string
+		wantLines int
+	}{
+		{name: "no token", token: "", wantLines: 0},
+		{name: "valid token", token: middleware.SignDebugToken(secret, time.Now().Add(time.Hour)), wantLines: 1},
+		{name: "expired token", token: middleware.SignDebugToken(secret, time.Now().Add(-time.Minute)), wantLines: 0},
+		{name: "wrong secret", token: middleware.SignDebugToken([]byte("other"), time.Now().Add(time.Hour)), wantLines: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			handler := middleware.BodyLogger(middleware.BodyLogOptions{
+				Logger:      slog.New(slog.NewTextHandler(&buf, nil)),
+				DebugSecret: secret,
+			})(http.HandlerFunc(echoJSON))
+
+			r := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{}`))
+			r.Header.Set("Content-Type", "application/json")
+			if tt.token != "" {
+				r.Header.Set(middleware.DebugBodyHeader, tt.token)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if got := strings.Count(buf.String(), "msg=BODY"); got != tt.wantLines {
+				t.Errorf("Expected %d log lines, got %d", tt.wantLines, got)
+			}
+		})
+	}
+}
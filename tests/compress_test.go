@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat("a", 2048)
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		body           string
+		wantEncoding   string
+	}{
+		{name: "large json", acceptEncoding: "gzip", contentType: "application/json", body: large, wantEncoding: "gzip"},
+		{name: "below threshold", acceptEncoding: "gzip", contentType: "application/json", body: "{}", wantEncoding: ""},
+		{name: "disallowed type", acceptEncoding: "gzip", contentType: "image/png", body: large, wantEncoding: ""},
+		{name: "client refuses", acceptEncoding: "gzip;q=0", contentType: "application/json", body: large, wantEncoding: ""},
+		{name: "unsupported codec", acceptEncoding: "br", contentType: "application/json", body: large, wantEncoding: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.Compress(middleware.CompressOptions{})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/test", nil)
+			r.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			handler.ServeHTTP(w, r)
+
+			if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+
+			body := w.Body.String()
+			if tt.wantEncoding == "gzip" {
+				zr, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatalf("Failed to open gzip body: %v", err)
+				}
+				decoded, _ := io.ReadAll(zr)
+				body = string(decoded)
+			}
+			if body != tt.body {
+				t.Errorf("Body length = %d, want %d", len(body), len(tt.body))
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/piheta/apicore/bind"
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/response"
+)
+
+func TestCookieCodecs(t *testing.T) {
+	encrypted, err := bind.EncryptedCookies([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codecs := map[string]bind.CookieCodec{
+		"signed":    bind.SignedCookies([]byte("secret")),
+		"encrypted": encrypted,
+	}
+
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			encoded, err := codec.Encode("sid", "user-42")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, err := codec.Decode("sid", encoded); err != nil || got != "user-42" {
+				t.Errorf("Expected user-42, got %q (%v)", got, err)
+			}
+			if _, err := codec.Decode("other", encoded); err == nil {
+				t.Error("Expected error decoding under another cookie name")
+			}
+			if _, err := codec.Decode("sid", "x"+encoded); err == nil {
+				t.Error("Expected error for tampered value")
+			}
+		})
+	}
+
+	if _, err := bind.EncryptedCookies([]byte("short")); err == nil {
+		t.Error("Expected error for invalid key length")
+	}
+}
+
+func TestBindCookie(t *testing.T) {
+	codec := bind.SignedCookies([]byte("secret"))
+	signed, _ := codec.Encode("sid", "user-42")
+	forged, _ := bind.SignedCookies([]byte("other")).Encode("sid", "admin")
+
+	type cookies struct {
+		SessionID string `cookie:"sid,secure" validate:"required"`
+		Theme     string `cookie:"theme" default:"light"`
+		Visits    int    `cookie:"visits"`
+	}
+
+	tests := []struct {
+		name         string
+		cookies      []*http.Cookie
+		expected     cookies
+		expectedCode int
+		expectedType string
+	}{
+		{name: "valid", cookies: []*http.Cookie{{Name: "sid", Value: signed}, {Name: "visits", Value: "3"}}, expected: cookies{SessionID: "user-42", Theme: "light", Visits: 3}, expectedCode: http.StatusOK},
+		{name: "plain overrides default", cookies: []*http.Cookie{{Name: "sid", Value: signed}, {Name: "theme", Value: "dark"}}, expected: cookies{SessionID: "user-42", Theme: "dark"}, expectedCode: http.StatusOK},
+		{name: "missing", expectedCode: http.StatusUnprocessableEntity, expectedType: "validation"},
+		{name: "forged", cookies: []*http.Cookie{{Name: "sid", Value: forged}}, expectedCode: http.StatusBadRequest, expectedType: "invalid_cookie"},
+		{name: "unsigned", cookies: []*http.Cookie{{Name: "sid", Value: "user-42"}}, expectedCode: http.StatusBadRequest, expectedType: "invalid_cookie"},
+		{name: "bad integer", cookies: []*http.Cookie{{Name: "sid", Value: signed}, {Name: "visits", Value: "many"}}, expectedCode: http.StatusBadRequest, expectedType: "invalid_cookie"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got cookies
+			handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+				if err := bind.Cookie(r, &got, bind.WithCookieCodec(codec)); err != nil {
+					return err
+				}
+				return response.Status(w, http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			for _, c := range tt.cookies {
+				req.AddCookie(c)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.expectedType != "" {
+				if got := decodeErrorType(t, w); got != tt.expectedType {
+					t.Errorf("Expected type %q, got %q", tt.expectedType, got)
+				}
+			}
+			if tt.expectedCode == http.StatusOK && got != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBindCookie_NoCodec(t *testing.T) {
+	var dst struct {
+		SessionID string `cookie:"sid,secure"`
+	}
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.AddCookie(&http.Cookie{Name: "sid", Value: "x"})
+
+	w := httptest.NewRecorder()
+	middleware.Public(func(_ http.ResponseWriter, r *http.Request) error {
+		return bind.Cookie(r, &dst)
+	}).ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
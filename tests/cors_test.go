@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+func TestCORS(t *testing.T) {
+	cors := middleware.CORS(middleware.CORSOptions{
+		AllowedOrigins:        []string{"https://app.example.com", "https://*.example.org"},
+		AllowedOriginPatterns: []*regexp.Regexp{regexp.MustCompile(`^http://localhost:\d+$`)},
+		AllowCredentials:      true,
+		MaxAge:                10 * time.Minute,
+	})
+
+	called := false
+	handler := cors(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		method     string
+		origin     string
+		preflight  bool
+		wantOrigin string
+		wantCalled bool
+		wantStatus int
+	}{
+		{name: "exact origin", method: http.MethodGet, origin: "https://app.example.com", wantOrigin: "https://app.example.com", wantCalled: true, wantStatus: http.StatusOK},
+		{name: "wildcard origin", method: http.MethodGet, origin: "https://eu.example.org", wantOrigin: "https://eu.example.org", wantCalled: true, wantStatus: http.StatusOK},
+		{name: "regex origin", method: http.MethodGet, origin: "http://localhost:3000", wantOrigin: "http://localhost:3000", wantCalled: true, wantStatus: http.StatusOK},
+		{name: "disallowed origin", method: http.MethodGet, origin: "https://evil.com", wantOrigin: "", wantCalled: true, wantStatus: http.StatusOK},
+		{name: "preflight", method: http.MethodOptions, origin: "https://app.example.com", preflight: true, wantOrigin: "https://app.example.com", wantCalled: false, wantStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called = false
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/test", nil)
+			r.Header.Set("Origin", tt.origin)
+			if tt.preflight {
+				r.Header.Set("Access-Control-Request-Method", http.MethodPut)
+			}
+
+			handler.ServeHTTP(w, r)
+
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if called != tt.wantCalled {
+				t.Errorf("handler called = %v, want %v", called, tt.wantCalled)
+			}
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.preflight && w.Header().Get("Access-Control-Max-Age") != "600" {
+				t.Errorf("Max-Age = %q, want 600", w.Header().Get("Access-Control-Max-Age"))
+			}
+		})
+	}
+}
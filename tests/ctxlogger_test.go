@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+func TestRequestID(t *testing.T) {
+	var got string
+	handler := middleware.RequestID(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = middleware.GetRequestID(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/test", nil)
+	r.Header.Set("X-Request-ID", "caller-id")
+	handler.ServeHTTP(w, r)
+	if got != "caller-id" || w.Header().Get("X-Request-ID") != "caller-id" {
+		t.Errorf("Expected caller request ID to propagate, got %q / %q", got, w.Header().Get("X-Request-ID"))
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/test", nil)
+	r.Header.Set("X-Request-ID", "bad id\n")
+	handler.ServeHTTP(w, r)
+	if got == "" || got == "bad id\n" {
+		t.Errorf("Expected generated request ID, got %q", got)
+	}
+}
+
+func TestInjectLogger(t *testing.T) {
+	var buf bytes.Buffer
+	base := slog.New(slog.NewTextHandler(&buf, nil))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		*r = *r.WithContext(context.WithValue(r.Context(), middleware.ClaimsContextKey, middleware.Claims{"sub": "user-7"}))
+		middleware.Log(r.Context()).Info("loading user")
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := middleware.RequestID(middleware.InjectLogger(base)(mux))
+
+	r := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	r.Header.Set("X-Request-ID", "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	out := buf.String()
+	for _, want := range []string{"request_id=req-1", "method=GET", `route="GET /users/{id}"`, "user=user-7"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected log to contain %q, got %q", want, out)
+		}
+	}
+}
+
+func TestLog_Default(t *testing.T) {
+	if middleware.Log(context.Background()) != slog.Default() {
+		t.Error("Expected Log to fall back to slog.Default()")
+	}
+}
+
+func TestLogLevelHandler(t *testing.T) {
+	lv := new(slog.LevelVar)
+	handler := middleware.LogLevelHandler(lv)
+
+	do := func(method, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, "/debug/loglevel", strings.NewReader(body)))
+		return w
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+		level  slog.Level
+	}{
+		{"reads level", http.MethodGet, "", http.StatusOK, slog.LevelInfo},
+		{"sets level", http.MethodPut, `{"level":"debug"}`, http.StatusOK, slog.LevelDebug},
+		{"accepts offsets", http.MethodPut, `{"level":"WARN+2"}`, http.StatusOK, slog.LevelWarn + 2},
+		{"rejects unknown level", http.MethodPut, `{"level":"loud"}`, http.StatusBadRequest, slog.LevelWarn + 2},
+		{"rejects bad duration", http.MethodPut, `{"level":"debug","for":"soon"}`, http.StatusBadRequest, slog.LevelWarn + 2},
+		{"rejects other methods", http.MethodDelete, "", http.StatusMethodNotAllowed, slog.LevelWarn + 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(tt.method, tt.body)
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body)
+			}
+			if lv.Level() != tt.level {
+				t.Errorf("Expected level %v, got %v", tt.level, lv.Level())
+			}
+		})
+	}
+
+	lv.Set(slog.LevelInfo)
+	w := do(http.MethodPut, `{"level":"debug","for":"30ms"}`)
+	if !strings.Contains(w.Body.String(), `"until"`) || lv.Level() != slog.LevelDebug {
+		t.Fatalf("Expected temporary debug level, got %s", w.Body)
+	}
+	do(http.MethodPut, `{"level":"debug","for":"40ms"}`)
+	time.Sleep(100 * time.Millisecond)
+	if lv.Level() != slog.LevelInfo {
+		t.Errorf("Expected level to revert to INFO, got %v", lv.Level())
+	}
+	if strings.Contains(do(http.MethodGet, "").Body.String(), "until") {
+		t.Errorf("Expected no until after revert")
+	}
+}
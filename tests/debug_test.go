@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/piheta/apicore/router"
+)
+
+func TestDebug(t *testing.T) {
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer admin" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	rt := router.New()
+	router.Debug(rt, auth)
+
+	tests := []struct {
+		name   string
+		path   string
+		authed bool
+		status int
+		body   string
+	}{
+		{"index", "/debug/pprof/", true, http.StatusOK, "goroutine"},
+		{"named profile", "/debug/pprof/goroutine?debug=1", true, http.StatusOK, "goroutine profile"},
+		{"cmdline", "/debug/pprof/cmdline", true, http.StatusOK, ""},
+		{"expvar", "/debug/vars", true, http.StatusOK, `"memstats"`},
+		{"unknown profile", "/debug/pprof/nope", true, http.StatusNotFound, ""},
+		{"requires auth", "/debug/pprof/", false, http.StatusUnauthorized, ""},
+		{"vars requires auth", "/debug/vars", false, http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authed {
+				r.Header.Set("Authorization", "Bearer admin")
+			}
+			w := httptest.NewRecorder()
+			rt.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.body) {
+				t.Errorf("Expected body to contain %q, got %q", tt.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestDebug_NilAuth(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected panic without auth middleware")
+		}
+	}()
+	router.Debug(router.New(), nil)
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/bind"
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/response"
+)
+
+// cents is a fixed-point amount parsed from "12.34".
+type cents int64
+
+func parseCents(s string) (cents, error) {
+	whole, frac, ok := strings.Cut(s, ".")
+	if !ok || len(frac) != 2 {
+		return 0, errors.New("expected two decimals")
+	}
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return cents(w*100 + f), nil
+}
+
+func init() {
+	bind.RegisterDecoder("amount", parseCents)
+}
+
+func TestBindDecoders(t *testing.T) {
+	type searchParams struct {
+		Since    time.Time     `query:"since"`
+		Window   time.Duration `query:"window" default:"1h"`
+		MinPrice cents         `query:"min_price"`
+		Prices   []cents       `query:"price"`
+		Timeout  time.Duration `header:"X-Timeout"`
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		timeout      string
+		expected     searchParams
+		expectedCode int
+		expectedMsg  string
+	}{
+		{
+			name:         "civil date",
+			query:        "?since=2024-05-31&window=30s&min_price=12.34&price=1.00&price=2.50",
+			timeout:      "5s",
+			expected:     searchParams{Since: time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC), Window: 30 * time.Second, MinPrice: 1234, Prices: []cents{100, 250}, Timeout: 5 * time.Second},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "rfc 3339 and default duration",
+			query:        "?since=2024-05-31T12:00:00Z",
+			expected:     searchParams{Since: time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC), Window: time.Hour},
+			expectedCode: http.StatusOK,
+		},
+		{name: "bad duration", query: "?window=30", expectedCode: http.StatusBadRequest, expectedMsg: `window: expected duration, got "30"`},
+		{name: "bad date", query: "?since=31/05/2024", expectedCode: http.StatusBadRequest, expectedMsg: `since: expected date or RFC 3339 time, got "31/05/2024"`},
+		{name: "custom decoder", query: "?min_price=12,34", expectedCode: http.StatusBadRequest, expectedMsg: `min_price: expected amount, got "12,34"`},
+		{name: "header", timeout: "soon", expectedCode: http.StatusBadRequest, expectedMsg: `X-Timeout: expected duration, got "soon"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got searchParams
+			handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+				if err := bind.Query(r, &got); err != nil {
+					return err
+				}
+				if err := bind.Header(r, &got); err != nil {
+					return err
+				}
+				return response.Status(w, http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/search"+tt.query, nil)
+			if tt.timeout != "" {
+				req.Header.Set("X-Timeout", tt.timeout)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.expectedMsg != "" {
+				var body struct {
+					Msg string `json:"msg"`
+				}
+				_ = json.Unmarshal(w.Body.Bytes(), &body)
+				if body.Msg != tt.expectedMsg {
+					t.Errorf("Expected message %q, got %q", tt.expectedMsg, body.Msg)
+				}
+			}
+			if tt.expectedCode == http.StatusOK {
+				if !got.Since.Equal(tt.expected.Since) || got.Window != tt.expected.Window || got.MinPrice != tt.expected.MinPrice ||
+					len(got.Prices) != len(tt.expected.Prices) || got.Timeout != tt.expected.Timeout {
+					t.Errorf("Expected %+v, got %+v", tt.expected, got)
+				}
+			}
+		})
+	}
+}
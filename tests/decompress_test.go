@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/response"
+)
+
+func compressBody(t *testing.T, encoding, body string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	var zw io.WriteCloser
+	if encoding == "gzip" {
+		zw = gzip.NewWriter(&buf)
+	} else {
+		zw = zlib.NewWriter(&buf)
+	}
+	_, _ = io.WriteString(zw, body)
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to compress body: %v", err)
+	}
+	return &buf
+}
+
+func TestDecompress(t *testing.T) {
+	handler := middleware.Decompress(64)(middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return err
+		}
+		return response.JSON(w, http.StatusOK, body)
+	}))
+
+	tests := []struct {
+		name         string
+		encoding     string
+		body         io.Reader
+		expectedCode int
+	}{
+		{name: "gzip", encoding: "gzip", body: compressBody(t, "gzip", `{"a":"b"}`), expectedCode: http.StatusOK},
+		{name: "deflate", encoding: "deflate", body: compressBody(t, "deflate", `{"a":"b"}`), expectedCode: http.StatusOK},
+		{name: "plain", encoding: "", body: strings.NewReader(`{"a":"b"}`), expectedCode: http.StatusOK},
+		{name: "bomb", encoding: "gzip", body: compressBody(t, "gzip", `{"a":"`+strings.Repeat("b", 10000)+`"}`), expectedCode: http.StatusRequestEntityTooLarge},
+		{name: "corrupt", encoding: "gzip", body: strings.NewReader("not gzip"), expectedCode: http.StatusBadRequest},
+		{name: "unsupported", encoding: "br", body: strings.NewReader("x"), expectedCode: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/ingest", tt.body)
+			if tt.encoding != "" {
+				r.Header.Set("Content-Encoding", tt.encoding)
+			}
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+		})
+	}
+}
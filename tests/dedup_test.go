@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+func TestDedup(t *testing.T) {
+	var executions atomic.Int32
+	release := make(chan struct{})
+	handler := middleware.Dedup(nil)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		executions.Add(1)
+		<-release
+		w.Header().Set("X-Shared", "yes")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("report"))
+	}))
+
+	const clients = 5
+	recorders := make([]*httptest.ResponseRecorder, clients)
+	var wg sync.WaitGroup
+	for i := range clients {
+		recorders[i] = httptest.NewRecorder()
+		wg.Go(func() {
+			handler.ServeHTTP(recorders[i], httptest.NewRequest(http.MethodGet, "/report?id=1", nil))
+		})
+	}
+	time.Sleep(30 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := executions.Load(); got != 1 {
+		t.Errorf("Expected 1 handler execution, got %d", got)
+	}
+	for _, w := range recorders {
+		if w.Code != http.StatusAccepted || w.Body.String() != "report" || w.Header().Get("X-Shared") != "yes" {
+			t.Errorf("Expected shared response, got %d %q %v", w.Code, w.Body.String(), w.Header())
+		}
+	}
+}
+
+func TestDedup_BehindRequestLogger(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	release := make(chan struct{})
+	handler := middleware.Chain(middleware.Public(func(_ http.ResponseWriter, _ *http.Request) error {
+		<-release
+		return errors.New("db down")
+	}), middleware.RequestLoggerWith(middleware.LoggerOptions{Logger: logger}), middleware.Dedup(nil))
+
+	const clients = 3
+	var wg sync.WaitGroup
+	for range clients {
+		wg.Go(func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/report?id=1", nil))
+		})
+	}
+	time.Sleep(30 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := strings.Count(logs.String(), `"error_detail":"db down"`); got != clients {
+		t.Errorf("Expected error_detail on %d log lines, got %d: %s", clients, got, logs.String())
+	}
+}
+
+func TestDedup_DistinctRequests(t *testing.T) {
+	var executions atomic.Int32
+	handler := middleware.Dedup(nil)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		executions.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/report?id=1", nil),
+		httptest.NewRequest(http.MethodGet, "/report?id=2", nil),
+		httptest.NewRequest(http.MethodPost, "/report?id=1", nil),
+	}
+	other := httptest.NewRequest(http.MethodGet, "/report?id=1", nil)
+	other.Header.Set("Authorization", "Bearer other-user")
+	requests = append(requests, other)
+
+	for _, r := range requests {
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	if got := executions.Load(); got != int32(len(requests)) {
+		t.Errorf("Expected %d executions, got %d", len(requests), got)
+	}
+
+	if middleware.RequestKey(requests[0]) == middleware.RequestKey(other) {
+		t.Errorf("Expected requests with different credentials to have different keys")
+	}
+}
@@ -3,11 +3,17 @@ package tests
 import (
 	"context"
 	"encoding/json"
+	"expvar"
+	"fmt"
 	"io"
+	"net/http"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/piheta/apicore/apierr"
 	"github.com/piheta/apicore/metaerr"
+	"github.com/piheta/apicore/validate"
 )
 
 // mockFieldError simulates a validator.FieldError for testing
@@ -338,3 +344,109 @@ func TestMapError_ValidationError_NestedFields(t *testing.T) {
 		t.Error("Expected name field error, got none")
 	}
 }
+
+func TestMapError_MaxBytes(t *testing.T) {
+	err := fmt.Errorf("decoding body: %w", &http.MaxBytesError{Limit: 1024})
+
+	apiErr := apierr.MapError(err, nil)
+	if apiErr.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status %d, got %d", http.StatusRequestEntityTooLarge, apiErr.StatusCode)
+	}
+	if apiErr.Type != "payload_too_large" {
+		t.Errorf("Expected type=payload_too_large, got %q", apiErr.Type)
+	}
+}
+
+func TestMapError_ValidationDetails(t *testing.T) {
+	if result := apierr.MapError(mockValidationErrors{{field: "Email", tag: "required"}}, nil); result.Details != nil {
+		t.Errorf("Expected no details without help, got %v", result.Details)
+	}
+
+	v := validate.New(validate.Options{FieldName: validate.JSONFieldName})
+	v.RegisterRuleHelp("iso4217", validate.Help{DocURL: "https://docs.example.com/currencies"})
+	v.RegisterFieldHelp("start_date", "", validate.Help{Hint: "use dates such as 2024-05-31"})
+	v.RegisterFieldHelp("currency", "required", validate.Help{Hint: "every price needs a currency"})
+
+	type price struct {
+		Currency  string `json:"currency" validate:"required,iso4217"`
+		StartDate string `json:"start_date" validate:"required"`
+		Amount    int    `json:"amount" validate:"gt=0"`
+	}
+
+	tests := []struct {
+		name     string
+		input    price
+		expected map[string]apierr.FieldDetail
+	}{
+		{
+			name:  "rule and field help",
+			input: price{Currency: "XXX"},
+			expected: map[string]apierr.FieldDetail{
+				"currency":   {DocURL: "https://docs.example.com/currencies"},
+				"start_date": {Hint: "use dates such as 2024-05-31"},
+			},
+		},
+		{
+			name:  "field rule help wins",
+			input: price{StartDate: "2024-05-31", Amount: 1},
+			expected: map[string]apierr.FieldDetail{
+				"currency": {Hint: "every price needs a currency"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := apierr.MapError(v.Struct(tt.input), nil)
+			if result.StatusCode != 422 {
+				t.Fatalf("Expected status 422, got %d", result.StatusCode)
+			}
+			if !reflect.DeepEqual(result.Details, tt.expected) {
+				t.Errorf("Expected details %v, got %v", tt.expected, result.Details)
+			}
+
+			body, _ := json.Marshal(result)
+			if !strings.Contains(string(body), `"details":{`) {
+				t.Errorf("Expected details in JSON, got %s", body)
+			}
+		})
+	}
+}
+
+func TestMapError_Metrics(t *testing.T) {
+	count := func(m *expvar.Map, key string) int64 {
+		if v, ok := m.Get(key).(*expvar.Int); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	validation := count(apierr.ErrorTypes, "validation")
+	notFound := count(apierr.ErrorTypes, "not_found")
+	email := count(apierr.ValidationFailures, "email.required")
+	age := count(apierr.ValidationFailures, "age.min")
+
+	apierr.MapError(mockValidationErrors{{field: "Email", tag: "required"}, {field: "Age", tag: "min"}}, nil)
+	apierr.MapError(mockValidationErrors{{field: "Email", tag: "required"}}, nil)
+	apierr.MapError(apierr.NewError(http.StatusNotFound, "not_found", "missing"), nil)
+
+	tests := []struct {
+		name string
+		m    *expvar.Map
+		key  string
+		want int64
+	}{
+		{"validation type", apierr.ErrorTypes, "validation", validation + 2},
+		{"not_found type", apierr.ErrorTypes, "not_found", notFound + 1},
+		{"email field", apierr.ValidationFailures, "email.required", email + 2},
+		{"age field", apierr.ValidationFailures, "age.min", age + 1},
+	}
+	for _, tt := range tests {
+		if got := count(tt.m, tt.key); got != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, got)
+		}
+	}
+
+	if expvar.Get("apierr.types") == nil || expvar.Get("apierr.validation_failures") == nil {
+		t.Errorf("Expected counters to be published with expvar")
+	}
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+func TestETag(t *testing.T) {
+	body := `{"id":1}`
+	handler := middleware.ETag(64)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/large" {
+			_, _ = io.WriteString(w, strings.Repeat("x", 100))
+			return
+		}
+		_, _ = io.WriteString(w, body)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/small", nil))
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected ETag header")
+	}
+	if w.Body.String() != body {
+		t.Errorf("Body = %q, want %q", w.Body.String(), body)
+	}
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/small", nil)
+	r.Header.Set("If-None-Match", "W/"+etag)
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected status %d, got %d", http.StatusNotModified, w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Expected empty 304 body, got %q", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/large", nil))
+	if w.Header().Get("ETag") != "" {
+		t.Error("Expected no ETag for responses above the size limit")
+	}
+	if w.Body.Len() != 100 {
+		t.Errorf("Expected full large body, got %d bytes", w.Body.Len())
+	}
+}
+
+func TestETag_Flush(t *testing.T) {
+	handler := middleware.ETag(1024)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("Expected the ETag writer to implement http.Flusher")
+		}
+		_, _ = io.WriteString(w, "data: 1\n\n")
+		flusher.Flush()
+		_, _ = io.WriteString(w, "data: 2\n\n")
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	if !w.Flushed {
+		t.Error("Expected the underlying writer to be flushed")
+	}
+	if w.Header().Get("ETag") != "" {
+		t.Errorf("Expected no ETag on a flushed response, got %q", w.Header().Get("ETag"))
+	}
+	if w.Body.String() != "data: 1\n\ndata: 2\n\n" {
+		t.Errorf("Expected the streamed body, got %q", w.Body.String())
+	}
+}
@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+func TestFeatureGate(t *testing.T) {
+	failing := middleware.FlagProviderFunc(func(*http.Request, string) (bool, error) {
+		return false, errors.New("flag service down")
+	})
+	t.Setenv("FF_NEW_CHECKOUT", "true")
+
+	tests := []struct {
+		name         string
+		flag         string
+		provider     middleware.FlagProvider
+		opts         []middleware.FeatureGateOption
+		expectedCode int
+	}{
+		{name: "enabled", flag: "beta", provider: middleware.StaticFlags{"beta": true}, expectedCode: http.StatusOK},
+		{name: "disabled", flag: "beta", provider: middleware.StaticFlags{"beta": false}, expectedCode: http.StatusNotFound},
+		{name: "unknown", flag: "other", provider: middleware.StaticFlags{"beta": true}, expectedCode: http.StatusNotFound},
+		{name: "forbidden", flag: "beta", provider: middleware.StaticFlags{}, opts: []middleware.FeatureGateOption{middleware.GateForbidden()}, expectedCode: http.StatusForbidden},
+		{name: "provider error", flag: "beta", provider: failing, expectedCode: http.StatusNotFound},
+		{name: "env", flag: "new-checkout", provider: middleware.EnvFlags("FF_"), expectedCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.FeatureGate(tt.flag, tt.provider, tt.opts...)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/beta", nil))
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+		})
+	}
+}
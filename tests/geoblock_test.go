@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+var testGeoDB = middleware.GeoResolverFunc(func(_ context.Context, ip netip.Addr) (string, error) {
+	switch ip.String() {
+	case "192.0.2.1":
+		return "no", nil
+	case "198.51.100.1":
+		return "RU", nil
+	case "203.0.113.1":
+		return "", errors.New("lookup failed")
+	}
+	return "", nil
+})
+
+func TestGeoBlock(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		opts       []middleware.GeoBlockOption
+		wantStatus int
+	}{
+		{name: "allowed", remoteAddr: "192.0.2.1:1234", wantStatus: http.StatusOK},
+		{name: "blocked", remoteAddr: "198.51.100.1:1234", wantStatus: http.StatusUnavailableForLegalReasons},
+		{name: "unknown", remoteAddr: "10.0.0.1:1234", wantStatus: http.StatusForbidden},
+		{name: "resolver error", remoteAddr: "203.0.113.1:1234", wantStatus: http.StatusForbidden},
+		{name: "unknown allowed", remoteAddr: "10.0.0.1:1234", opts: []middleware.GeoBlockOption{middleware.GeoAllowUnknown()}, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.GeoBlock(testGeoDB, []string{"NO", "se"}, tt.opts...)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestGeoBlock_LogsCountry(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	handler := middleware.Chain(http.NotFoundHandler(),
+		middleware.RequestLoggerWith(middleware.LoggerOptions{Logger: logger}),
+		middleware.GeoBlock(testGeoDB, []string{"NO"}),
+	)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "198.51.100.1:1234"
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	if !strings.Contains(buf.String(), "country=RU") {
+		t.Errorf("Expected country in log, got %q", buf.String())
+	}
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/apicoretest"
+	"github.com/piheta/apicore/response"
+)
+
+func goldenUsers() *apicoretest.Response {
+	type user struct {
+		ID        string    `json:"id"`
+		Email     string    `json:"email"`
+		Big       int64     `json:"big"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	h := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		id := time.Now().Format("150405.000000000")
+		users := []user{{ID: id, Email: "a@example.com", Big: 1<<53 + 1, CreatedAt: time.Now()}}
+		_ = response.Paginated(w, http.StatusOK, users, response.PageInfo{PerPage: 1, HasMore: true, NextCursor: id})
+	})
+	return apicoretest.DoHandler(h, httptest.NewRequest(http.MethodGet, "/users", nil))
+}
+
+func TestGolden(t *testing.T) {
+	cursor := apicoretest.MaskPattern(regexp.MustCompile(`"next_cursor": "[^"]*"`), `"next_cursor": "<cursor>"`)
+	opts := []apicoretest.GoldenOption{apicoretest.MaskKeys("id"), apicoretest.MaskTimestamps(), cursor}
+
+	apicoretest.Golden(t, goldenUsers(), "testdata/paginated_users.json", opts...)
+
+	tests := []struct {
+		name string
+		path string
+		opts []apicoretest.GoldenOption
+	}{
+		{"unmasked fields differ", "testdata/paginated_users.json", []apicoretest.GoldenOption{apicoretest.MaskKeys("id")}},
+		{"missing file", "testdata/missing.json", opts},
+	}
+	for _, tt := range tests {
+		if !fails(t, func(tb testing.TB) { apicoretest.Golden(tb, goldenUsers(), tt.path, tt.opts...) }) {
+			t.Errorf("%s: expected Golden to fail", tt.name)
+		}
+	}
+}
+
+func TestGolden_Update(t *testing.T) {
+	t.Setenv(apicoretest.UpdateEnv, "1")
+
+	path := filepath.Join(t.TempDir(), "nested", "raw.txt")
+	resp := &apicoretest.Response{StatusCode: http.StatusOK, Body: []byte("id=42 ok")}
+	upper := apicoretest.Normalize(bytes.ToUpper)
+	apicoretest.Golden(t, resp, path, apicoretest.MaskPattern(regexp.MustCompile(`id=\d+`), "id=N"), upper)
+
+	got, err := os.ReadFile(path)
+	if err != nil || string(got) != "ID=N OK" {
+		t.Errorf("Expected golden file to be written, got %q (%v)", got, err)
+	}
+}
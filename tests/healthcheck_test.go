@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/healthcheck"
+)
+
+func TestHealthcheck_Readiness(t *testing.T) {
+	checker := healthcheck.New(healthcheck.Options{CacheTTL: time.Minute})
+
+	var pings atomic.Int32
+	checker.Register("postgres", func(_ context.Context) error {
+		pings.Add(1)
+		return nil
+	})
+	checker.Register("redis", func(_ context.Context) error {
+		return errors.New("connection refused")
+	})
+	checker.Register("slow", func(_ context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}, healthcheck.WithTimeout(10*time.Millisecond))
+
+	for range 2 {
+		w := httptest.NewRecorder()
+		checker.ReadinessHandler()(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+		}
+
+		var report healthcheck.Report
+		if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+			t.Fatalf("Failed to decode report: %v", err)
+		}
+		if report.Checks["postgres"].Status != healthcheck.StatusUp {
+			t.Errorf("Expected postgres up, got %q", report.Checks["postgres"].Status)
+		}
+		if report.Checks["redis"].Error != "connection refused" {
+			t.Errorf("Expected redis error, got %q", report.Checks["redis"].Error)
+		}
+		if report.Checks["slow"].Status != healthcheck.StatusDown {
+			t.Errorf("Expected slow probe to time out, got %q", report.Checks["slow"].Status)
+		}
+	}
+
+	if pings.Load() != 1 {
+		t.Errorf("Expected cached probe result, got %d probe runs", pings.Load())
+	}
+}
+
+func TestHealthcheck_Liveness(t *testing.T) {
+	checker := healthcheck.New(healthcheck.Options{})
+	checker.Register("down", func(_ context.Context) error { return errors.New("down") })
+
+	w := httptest.NewRecorder()
+	checker.LivenessHandler()(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected liveness to ignore probes, got status %d", w.Code)
+	}
+}
+
+func TestHealthcheck_Degraded(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(c *healthcheck.Checker)
+		status   int
+		want     healthcheck.Status
+		warnings int
+	}{
+		{
+			name: "degraded error",
+			register: func(c *healthcheck.Checker) {
+				c.Register("postgres", func(context.Context) error { return nil })
+				c.Register("replica", func(context.Context) error {
+					return healthcheck.Degraded(errors.New("replication lag 45s"))
+				})
+			},
+			status: http.StatusOK, want: healthcheck.StatusDegraded, warnings: 1,
+		},
+		{
+			name: "non-critical probe",
+			register: func(c *healthcheck.Checker) {
+				c.Register("cache", func(context.Context) error { return errors.New("timeout") }, healthcheck.NonCritical())
+			},
+			status: http.StatusOK, want: healthcheck.StatusDegraded, warnings: 1,
+		},
+		{
+			name: "non-critical timeout",
+			register: func(c *healthcheck.Checker) {
+				c.Register("cache", func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				}, healthcheck.NonCritical(), healthcheck.WithTimeout(10*time.Millisecond))
+			},
+			status: http.StatusOK, want: healthcheck.StatusDegraded, warnings: 1,
+		},
+		{
+			name: "down wins over degraded",
+			register: func(c *healthcheck.Checker) {
+				c.Register("cache", func(context.Context) error { return errors.New("timeout") }, healthcheck.NonCritical())
+				c.Register("postgres", func(context.Context) error { return errors.New("refused") })
+			},
+			status: http.StatusServiceUnavailable, want: healthcheck.StatusDown, warnings: 1,
+		},
+		{
+			name: "nil degraded is up",
+			register: func(c *healthcheck.Checker) {
+				c.Register("replica", func(context.Context) error { return healthcheck.Degraded(nil) })
+			},
+			status: http.StatusOK, want: healthcheck.StatusUp, warnings: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := healthcheck.New(healthcheck.Options{})
+			tt.register(checker)
+
+			w := httptest.NewRecorder()
+			checker.ReadinessHandler()(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+
+			var report healthcheck.Report
+			if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+				t.Fatalf("Failed to decode report: %v", err)
+			}
+			if report.Status != tt.want || len(report.Warnings) != tt.warnings {
+				t.Errorf("Expected %q with %d warnings, got %q %v", tt.want, tt.warnings, report.Status, report.Warnings)
+			}
+		})
+	}
+}
+
+func TestHealthcheck_CacheJitter(t *testing.T) {
+	checker := healthcheck.New(healthcheck.Options{CacheTTL: 40 * time.Millisecond, CacheJitter: 0.5})
+
+	var runs atomic.Int32
+	checker.Register("postgres", func(context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+
+	checker.Check(context.Background())
+	time.Sleep(10 * time.Millisecond)
+	checker.Check(context.Background())
+	if runs.Load() != 1 {
+		t.Errorf("Expected result cached within TTL minus jitter, got %d runs", runs.Load())
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	checker.Check(context.Background())
+	if runs.Load() != 2 {
+		t.Errorf("Expected probe to rerun after TTL plus jitter, got %d runs", runs.Load())
+	}
+}
+
+func TestHealthcheck_CanceledRequestNotCached(t *testing.T) {
+	checker := healthcheck.New(healthcheck.Options{CacheTTL: time.Minute})
+	checker.Register("postgres", func(ctx context.Context) error {
+		select {
+		case <-time.After(10 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if report := checker.Check(ctx); report.Status != healthcheck.StatusUp {
+		t.Fatalf("Expected a canceled check to still run the probe, got %q", report.Status)
+	}
+	if report := checker.Check(context.Background()); report.Status != healthcheck.StatusUp {
+		t.Errorf("Expected cached status up, got %q", report.Status)
+	}
+}
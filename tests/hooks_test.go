@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+type recordedHooks struct {
+	panics []any
+	stacks [][]string
+	status []int
+	errs   []error
+}
+
+func (h *recordedHooks) OnPanic(_ *http.Request, recovered any, stack []string) {
+	h.panics = append(h.panics, recovered)
+	h.stacks = append(h.stacks, stack)
+}
+
+func (h *recordedHooks) On5xx(_ *http.Request, status int, err error) {
+	h.status = append(h.status, status)
+	h.errs = append(h.errs, err)
+}
+
+func TestHooks(t *testing.T) {
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	defer slog.SetDefault(prev)
+
+	panicking := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})
+	failing := middleware.Public(func(http.ResponseWriter, *http.Request) error {
+		return errors.New("db down")
+	})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		build      func(h middleware.ErrorHooks) http.Handler
+		wantPanics int
+		want5xx    int
+	}{
+		{
+			name: "panic inside recover",
+			build: func(h middleware.ErrorHooks) http.Handler {
+				return middleware.Chain(panicking, middleware.Recover, middleware.Hooks(h))
+			},
+			wantPanics: 1,
+		},
+		{
+			name: "panic outside recover",
+			build: func(h middleware.ErrorHooks) http.Handler {
+				return middleware.Chain(panicking, middleware.Hooks(h), middleware.Recover)
+			},
+			wantPanics: 1,
+		},
+		{
+			name:    "server error",
+			build:   func(h middleware.ErrorHooks) http.Handler { return middleware.Hooks(h)(failing) },
+			want5xx: 1,
+		},
+		{
+			name:  "success",
+			build: func(h middleware.ErrorHooks) http.Handler { return middleware.Hooks(h)(ok) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks := &recordedHooks{}
+			w := httptest.NewRecorder()
+			tt.build(hooks).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+			if len(hooks.panics) != tt.wantPanics {
+				t.Errorf("Expected %d OnPanic calls, got %d", tt.wantPanics, len(hooks.panics))
+			}
+			for i, v := range hooks.panics {
+				if v != "boom" || len(hooks.stacks[i]) == 0 {
+					t.Errorf("Expected panic value and stack, got %v %v", v, hooks.stacks[i])
+				}
+			}
+			if len(hooks.status) != tt.want5xx {
+				t.Errorf("Expected %d On5xx calls, got %d", tt.want5xx, len(hooks.status))
+			}
+			if tt.want5xx > 0 && (hooks.status[0] != http.StatusInternalServerError || hooks.errs[0] == nil || hooks.errs[0].Error() != "db down") {
+				t.Errorf("Expected On5xx with original error, got %d %v", hooks.status[0], hooks.errs[0])
+			}
+		})
+	}
+}
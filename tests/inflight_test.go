@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+func TestMaxInFlight(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 4)
+	handler := middleware.MaxInFlight(1, 1, 20*time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+		return w
+	}
+
+	var wg sync.WaitGroup
+	var first *httptest.ResponseRecorder
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		first = serve()
+	}()
+	<-started
+
+	// One request may queue; it times out after the wait period.
+	var queued *httptest.ResponseRecorder
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		queued = serve()
+	}()
+	time.Sleep(5 * time.Millisecond)
+
+	// The queue is full, so this one is shed immediately.
+	shed := serve()
+	if shed.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, shed.Code)
+	}
+	if shed.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After header")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if first.Code != http.StatusOK {
+		t.Errorf("Expected first request to succeed, got %d", first.Code)
+	}
+	if queued.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected queued request to time out with %d, got %d", http.StatusServiceUnavailable, queued.Code)
+	}
+}
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+func sleepMiddleware(d time.Duration) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(d)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestInstrumentedChain(t *testing.T) {
+	var mu sync.Mutex
+	observed := map[string]time.Duration{}
+	var order []string
+	observe := func(_ *http.Request, name string, self time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		observed[name] = self
+		order = append(order, name)
+	}
+
+	handler := middleware.InstrumentedChain(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}), observe,
+		middleware.Named("fast", headerMiddleware("fast")),
+		middleware.Named("slow", sleepMiddleware(40*time.Millisecond)),
+	)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if got := []string{"handler", "slow", "fast"}; len(order) != 3 || order[0] != got[0] || order[1] != got[1] || order[2] != got[2] {
+		t.Errorf("Expected observations innermost first %v, got %v", got, order)
+	}
+	if observed["slow"] < 40*time.Millisecond {
+		t.Errorf("Expected slow middleware to account for its sleep, got %v", observed["slow"])
+	}
+	if observed["fast"] >= 20*time.Millisecond {
+		t.Errorf("Expected fast middleware to exclude inner layers, got %v", observed["fast"])
+	}
+	if observed["handler"] < 5*time.Millisecond || observed["handler"] >= 40*time.Millisecond {
+		t.Errorf("Expected handler self time around 5ms, got %v", observed["handler"])
+	}
+}
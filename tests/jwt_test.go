@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/response"
+)
+
+func encodeSegment(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to encode token segment: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func signHS256(t *testing.T, secret []byte, claims map[string]any) string {
+	t.Helper()
+	input := encodeSegment(t, map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+	input := encodeSegment(t, map[string]string{"alg": "ES256", "kid": kid}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(input))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func claimsHandler(w http.ResponseWriter, r *http.Request) error {
+	claims, _ := middleware.GetClaims(r.Context())
+	return response.JSON(w, http.StatusOK, map[string]string{"sub": claims.Subject()})
+}
+
+func TestProtected_HMAC(t *testing.T) {
+	secret := []byte("secret")
+	handler := middleware.Protected(claimsHandler, middleware.JWTConfig{
+		Secret:   secret,
+		Issuer:   "issuer",
+		Audience: "api",
+	})
+
+	valid := map[string]any{"sub": "user-1", "iss": "issuer", "aud": []string{"api"}, "exp": time.Now().Add(time.Hour).Unix()}
+	expired := map[string]any{"sub": "user-1", "iss": "issuer", "aud": "api", "exp": time.Now().Add(-time.Hour).Unix()}
+	wrongAud := map[string]any{"sub": "user-1", "iss": "issuer", "aud": "other"}
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantMsg    string
+	}{
+		{name: "valid", header: "Bearer " + signHS256(t, secret, valid), wantStatus: http.StatusOK},
+		{name: "missing", header: "", wantStatus: http.StatusUnauthorized, wantMsg: "missing bearer token"},
+		{name: "expired", header: "Bearer " + signHS256(t, secret, expired), wantStatus: http.StatusUnauthorized, wantMsg: "token expired"},
+		{name: "wrong audience", header: "Bearer " + signHS256(t, secret, wrongAud), wantStatus: http.StatusUnauthorized, wantMsg: "invalid token"},
+		{name: "wrong secret", header: "Bearer " + signHS256(t, []byte("other"), valid), wantStatus: http.StatusUnauthorized, wantMsg: "invalid token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			handler(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus != http.StatusUnauthorized {
+				return
+			}
+			if w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("Expected WWW-Authenticate challenge")
+			}
+			var result apierr.APIError
+			if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if result.Message != tt.wantMsg {
+				t.Errorf("Expected message %q, got %v", tt.wantMsg, result.Message)
+			}
+		})
+	}
+}
+
+func TestProtected_JWKS(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	fetches := 0
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches++
+		_ = response.JSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+			"kty": "EC",
+			"kid": "k1",
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		}}})
+	}))
+	defer jwksServer.Close()
+
+	handler := middleware.Protected(claimsHandler, middleware.JWTConfig{
+		JWKS: middleware.NewJWKS(jwksServer.URL, time.Hour, nil),
+	})
+
+	token := signES256(t, key, "k1", map[string]any{"sub": "user-2"})
+	for range 2 {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/test", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		handler(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("Expected JWKS to be fetched once and cached, got %d fetches", fetches)
+	}
+}
+
+func TestJWKS_ThrottlesFailedFetches(t *testing.T) {
+	var fetches atomic.Int32
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer jwksServer.Close()
+
+	jwks := middleware.NewJWKS(jwksServer.URL, time.Hour, nil)
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := jwks.Key(context.Background(), "k1"); err == nil {
+				t.Errorf("Expected an error while the JWKS endpoint is down")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("Expected one fetch while retries back off, got %d", n)
+	}
+}
@@ -0,0 +1,534 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/metaerr"
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/router"
+)
+
+func serveLogged(mw func(http.Handler) http.Handler, method, path string, status int, header http.Header) {
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}))
+	r := httptest.NewRequest(method, path, nil)
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func TestRequestLoggerWith_FieldsAndLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	mw := middleware.RequestLoggerWith(middleware.LoggerOptions{
+		Logger: logger,
+		Fields: []middleware.LogField{middleware.FieldStatus, middleware.FieldUserAgent, middleware.FieldRequestID},
+	})
+	serveLogged(mw, http.MethodGet, "/v2/users", http.StatusOK, http.Header{
+		"User-Agent":   {"test-agent"},
+		"X-Request-Id": {"abc123"},
+	})
+
+	out := buf.String()
+	for _, want := range []string{"status=200", "user_agent=test-agent", "request_id=abc123"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected log to contain %q, got %q", want, out)
+		}
+	}
+	if strings.Contains(out, "ip=") {
+		t.Errorf("Expected ip to be omitted, got %q", out)
+	}
+}
+
+func TestRequestLoggerWith_Predicates(t *testing.T) {
+	var buf bytes.Buffer
+	mw := middleware.RequestLoggerWith(middleware.LoggerOptions{
+		Logger:  slog.New(slog.NewTextHandler(&buf, nil)),
+		Exclude: func(r *http.Request) bool { return r.URL.Path == "/healthz" },
+	})
+
+	serveLogged(mw, http.MethodGet, "/healthz", http.StatusOK, nil)
+	if buf.Len() != 0 {
+		t.Errorf("Expected excluded path not to be logged, got %q", buf.String())
+	}
+
+	serveLogged(mw, http.MethodGet, "/v2/users", http.StatusNotFound, nil)
+	if !strings.Contains(buf.String(), "level=WARN") {
+		t.Errorf("Expected 404 to be logged at WARN, got %q", buf.String())
+	}
+}
+
+func TestRequestLogger_LogsAllPaths(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(prev)
+
+	serveLogged(middleware.RequestLogger, http.MethodGet, "/v2/users", http.StatusOK, nil)
+
+	if !strings.Contains(buf.String(), "path=/v2/users") {
+		t.Errorf("Expected request outside /api to be logged, got %q", buf.String())
+	}
+}
+
+func TestSkipPaths(t *testing.T) {
+	var buf bytes.Buffer
+	mw := middleware.RequestLoggerWith(middleware.LoggerOptions{
+		Logger:  slog.New(slog.NewTextHandler(&buf, nil)),
+		Exclude: middleware.SkipPaths("/healthz", "/readyz"),
+	})
+
+	serveLogged(mw, http.MethodGet, "/readyz", http.StatusOK, nil)
+	if buf.Len() != 0 {
+		t.Errorf("Expected health check not to be logged, got %q", buf.String())
+	}
+}
+
+func TestRequestLoggerWith_Sampling(t *testing.T) {
+	var buf bytes.Buffer
+	mw := middleware.RequestLoggerWith(middleware.LoggerOptions{
+		Logger:      slog.New(slog.NewTextHandler(&buf, nil)),
+		SampleRates: map[int]float64{2: 0},
+	})
+
+	for range 10 {
+		serveLogged(mw, http.MethodGet, "/users", http.StatusOK, nil)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected successes to be sampled out, got %q", buf.String())
+	}
+
+	serveLogged(mw, http.MethodGet, "/users", http.StatusInternalServerError, nil)
+	if !strings.Contains(buf.String(), "status=500") {
+		t.Errorf("Expected failures to always be logged, got %q", buf.String())
+	}
+
+	buf.Reset()
+	mw = middleware.RequestLoggerWith(middleware.LoggerOptions{
+		Logger:      slog.New(slog.NewTextHandler(&buf, nil)),
+		SampleRates: map[int]float64{2: 1},
+	})
+	serveLogged(mw, http.MethodGet, "/users", http.StatusOK, nil)
+	if !strings.Contains(buf.String(), "sample_rate=1") {
+		t.Errorf("Expected sample_rate attribute, got %q", buf.String())
+	}
+}
+
+func TestRequestLoggerWith_SlowRequests(t *testing.T) {
+	var buf bytes.Buffer
+	var slowCalls int
+	mw := middleware.RequestLoggerWith(middleware.LoggerOptions{
+		Logger:        slog.New(slog.NewTextHandler(&buf, nil)),
+		SampleRates:   map[int]float64{2: 0},
+		SlowThreshold: time.Millisecond,
+		OnSlow:        func(_ *http.Request, _ time.Duration) { slowCalls++ },
+	})
+
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	out := buf.String()
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, "slow=true") {
+		t.Errorf("Expected slow request logged at WARN with slow=true, got %q", out)
+	}
+	if slowCalls != 1 {
+		t.Errorf("Expected OnSlow to be called once, got %d", slowCalls)
+	}
+}
+
+func TestRequestLogger_PreservesWriterInterfaces(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	mw := middleware.RequestLoggerWith(middleware.LoggerOptions{Logger: logger})
+
+	logged := make(chan struct{})
+	server := httptest.NewServer(middleware.Chain(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, ok := w.(io.ReaderFrom); !ok {
+			t.Errorf("Expected io.ReaderFrom to be preserved")
+		}
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("Hijack failed: %v", err)
+			return
+		}
+		defer conn.Close()
+		_, _ = brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\n")
+		_ = brw.Flush()
+	}), func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer close(logged)
+			next.ServeHTTP(w, r)
+		})
+	}, mw))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	_, _ = conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: test\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\n"))
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("Expected status %d, got %d", http.StatusSwitchingProtocols, resp.StatusCode)
+	}
+
+	<-logged
+	if !strings.Contains(buf.String(), "status=101") {
+		t.Errorf("Expected hijacked request to be logged as 101, got %q", buf.String())
+	}
+}
+
+func TestRequestLogger_DoesNotAddWriterInterfaces(t *testing.T) {
+	handler := middleware.RequestLogger(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, ok := w.(http.Hijacker); ok {
+			t.Errorf("Expected no http.Hijacker when the underlying writer lacks it")
+		}
+		if _, ok := w.(http.Flusher); !ok {
+			t.Errorf("Expected http.Flusher to be preserved")
+		}
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestRequestLoggerWith_Sinks(t *testing.T) {
+	var all, errorsOnly bytes.Buffer
+	mw := middleware.RequestLoggerWith(middleware.LoggerOptions{
+		Sinks: []middleware.LogSink{
+			{Handler: slog.NewTextHandler(&all, nil)},
+			{Handler: slog.NewJSONHandler(&errorsOnly, nil), MinLevel: slog.LevelError},
+		},
+	})
+
+	serveLogged(mw, http.MethodGet, "/ok", http.StatusOK, nil)
+	serveLogged(mw, http.MethodGet, "/fail", http.StatusInternalServerError, nil)
+
+	if got := strings.Count(all.String(), "msg=REQ"); got != 2 {
+		t.Errorf("Expected 2 lines in the unfiltered sink, got %d: %q", got, all.String())
+	}
+	if strings.Contains(errorsOnly.String(), "/ok") || !strings.Contains(errorsOnly.String(), `"path":"/fail"`) {
+		t.Errorf("Expected only the 5xx line in the error sink, got %q", errorsOnly.String())
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	rf, err := middleware.NewRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("NewRotatingFile failed: %v", err)
+	}
+	defer rf.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := rf.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	want := map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	}
+	for file, content := range want {
+		got, err := os.ReadFile(file)
+		if err != nil || string(got) != content {
+			t.Errorf("Expected %s to contain %q, got %q (%v)", filepath.Base(file), content, got, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected at most 2 backups")
+	}
+}
+
+func TestSetLogger(t *testing.T) {
+	var buf bytes.Buffer
+	middleware.SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { middleware.SetLogger(nil) })
+
+	serveLogged(middleware.RequestLogger, http.MethodGet, "/users", http.StatusOK, nil)
+	handler := middleware.Public(func(http.ResponseWriter, *http.Request) error {
+		return errors.New("unmapped")
+	})
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	out := buf.String()
+	for _, want := range []string{"msg=REQ", "path=/users", `msg="Error missed mappers!"`, "error=unmapped"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected log to contain %q, got %q", want, out)
+		}
+	}
+
+	var own bytes.Buffer
+	buf.Reset()
+	mw := middleware.RequestLoggerWith(middleware.LoggerOptions{Logger: slog.New(slog.NewTextHandler(&own, nil))})
+	serveLogged(mw, http.MethodGet, "/own", http.StatusOK, nil)
+	if buf.Len() != 0 || !strings.Contains(own.String(), "path=/own") {
+		t.Errorf("Expected explicit logger to win, got package %q and own %q", buf.String(), own.String())
+	}
+}
+
+func TestRequestLoggerWith_Canonical(t *testing.T) {
+	var buf bytes.Buffer
+	mw := middleware.RequestLoggerWith(middleware.LoggerOptions{
+		Logger:    slog.New(slog.NewTextHandler(&buf, nil)),
+		Canonical: true,
+		Tenant:    func(r *http.Request) string { return r.Header.Get("X-Tenant") },
+	})
+
+	handler := mw(middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+		ctx := r.Context()
+		*r = *r.WithContext(context.WithValue(ctx, middleware.ClaimsContextKey, middleware.Claims{"sub": "user-7"}))
+		middleware.RecordTiming(ctx, "db", 2*time.Millisecond)
+		middleware.RecordTiming(ctx, "db", 3*time.Millisecond)
+		middleware.StartTiming(ctx, "cache")()
+		middleware.AddLogAttrs(ctx, "cart_items", 3)
+		return metaerr.WithMetadata(errors.New("payment declined"), "order_id", "o-1")
+	}))
+	r := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	r.Header.Set("X-Tenant", "acme")
+	r.Header.Set("User-Agent", "test-agent")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	out := buf.String()
+	if lines := strings.Count(out, "\n"); lines != 1 {
+		t.Fatalf("Expected one log line, got %d: %q", lines, out)
+	}
+	for _, want := range []string{
+		"msg=canonical-log-line", "status=500", "user_agent=test-agent", "user=user-7", "tenant=acme",
+		"db_ms=5.00", "db_count=2", "cache_count=1", "cart_items=3", "order_id=o-1", "error_detail=", "bytes=",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected log to contain %q, got %q", want, out)
+		}
+	}
+
+	// Without a canonical logger, recording is a no-op.
+	middleware.RecordTiming(context.Background(), "db", time.Millisecond)
+	middleware.AddLogAttrs(context.Background(), "k", "v")
+}
+
+func TestRequestLoggerWith_AccessLogFormats(t *testing.T) {
+	handler := func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	}
+
+	tests := []struct {
+		name   string
+		format middleware.LogFormat
+		header http.Header
+		want   string
+	}{
+		{
+			"common", middleware.FormatCommon, nil,
+			`192.0.2.1 - - [` + `TIME` + `] "GET /users?page=2 HTTP/1.1" 201 5`,
+		},
+		{
+			"combined", middleware.FormatCombined, http.Header{"User-Agent": {`evil" agent`}, "Referer": {"https://example.com/"}},
+			`192.0.2.1 - - [` + `TIME` + `] "GET /users?page=2 HTTP/1.1" 201 5 "https://example.com/" "evil\" agent"`,
+		},
+		{
+			"combined without headers", middleware.FormatCombined, http.Header{"User-Agent": {""}},
+			`192.0.2.1 - - [` + `TIME` + `] "GET /users?page=2 HTTP/1.1" 201 5 "-" "-"`,
+		},
+		{
+			"basic auth user", middleware.FormatCommon, http.Header{"Authorization": {"Basic YWxpY2U6c2VjcmV0"}},
+			`192.0.2.1 - alice [` + `TIME` + `] "GET /users?page=2 HTTP/1.1" 201 5`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			mw := middleware.RequestLoggerWith(middleware.LoggerOptions{Format: tt.format, Output: &buf})
+
+			r := httptest.NewRequest(http.MethodGet, "/users?page=2", nil)
+			for k, v := range tt.header {
+				r.Header[k] = v
+			}
+			mw(http.HandlerFunc(handler)).ServeHTTP(httptest.NewRecorder(), r)
+
+			line := buf.String()
+			open, end := strings.Index(line, "["), strings.Index(line, "]")
+			if open < 0 || end < open {
+				t.Fatalf("Expected a timestamp, got %q", line)
+			}
+			if _, err := time.Parse("02/Jan/2006:15:04:05 -0700", line[open+1:end]); err != nil {
+				t.Errorf("Expected CLF timestamp, got %q", line[open+1:end])
+			}
+			if got := line[:open+1] + "TIME" + line[end:]; got != tt.want+"\n" {
+				t.Errorf("Expected %q, got %q", tt.want+"\n", got)
+			}
+		})
+	}
+}
+
+// blockingHandler records messages and blocks in Handle until release is closed.
+type blockingHandler struct {
+	mu      sync.Mutex
+	msgs    []string
+	release chan struct{}
+}
+
+func (h *blockingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *blockingHandler) Handle(_ context.Context, r slog.Record) error {
+	<-h.release
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	msg := r.Message
+	r.Attrs(func(a slog.Attr) bool {
+		msg += " " + a.String()
+		return true
+	})
+	h.msgs = append(h.msgs, msg)
+	return nil
+}
+
+func (h *blockingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *blockingHandler) WithGroup(string) slog.Handler      { return h }
+
+func TestAsyncHandler(t *testing.T) {
+	inner := &blockingHandler{release: make(chan struct{})}
+	var dropped []string
+	async := middleware.NewAsyncHandler(inner, middleware.AsyncOptions{
+		QueueSize: 2,
+		OnDrop:    func(r slog.Record) { dropped = append(dropped, r.Message) },
+	})
+	logger := slog.New(async)
+
+	// The first record is taken by the writer, which blocks on it; the queue
+	// then holds two more, so the fourth and fifth drop the oldest.
+	logger.Info("first")
+	time.Sleep(20 * time.Millisecond)
+	start := time.Now()
+	for _, msg := range []string{"second", "third", "fourth", "fifth"} {
+		logger.Info(msg, "n", msg)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Expected logging not to block, took %v", elapsed)
+	}
+	if async.Dropped() != 2 || strings.Join(dropped, ",") != "second,third" {
+		t.Errorf("Expected second and third to drop, got %d %v", async.Dropped(), dropped)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := async.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Flush to time out while the writer is blocked, got %v", err)
+	}
+
+	close(inner.release)
+	if err := async.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := strings.Join(inner.msgs, ","); got != "first,fourth n=fourth,fifth n=fifth" {
+		t.Errorf("Expected queued records to flush in order, got %q", got)
+	}
+
+	logger.Info("after close")
+	if inner.msgs[len(inner.msgs)-1] != "after close" {
+		t.Errorf("Expected records after Close to be written synchronously, got %v", inner.msgs)
+	}
+}
+
+func TestAsyncHandler_Flush(t *testing.T) {
+	var buf bytes.Buffer
+	async := middleware.NewAsyncHandler(slog.NewTextHandler(&buf, nil), middleware.AsyncOptions{})
+	defer async.Close(context.Background())
+
+	mw := middleware.RequestLoggerWith(middleware.LoggerOptions{Logger: slog.New(async).With("app", "api")})
+	serveLogged(mw, http.MethodGet, "/users", http.StatusOK, nil)
+
+	if err := async.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "app=api") || !strings.Contains(out, "path=/users") {
+		t.Errorf("Expected flushed request log, got %q", out)
+	}
+}
+
+func TestRequestLoggerWith_Histograms(t *testing.T) {
+	hist := middleware.NewLatencyHistograms(50*time.Millisecond, 10*time.Millisecond)
+	mw := middleware.RequestLoggerWith(middleware.LoggerOptions{
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Histograms:  hist,
+		SampleRates: map[int]float64{2: 0},
+		Exclude:     middleware.SkipPaths("/healthz"),
+	})
+
+	rt := router.New(mw)
+	rt.HandleHTTP(http.MethodGet, "/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "slow" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		if r.PathValue("id") == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	rt.HandleHTTP(http.MethodGet, "/healthz", http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/slow", "/users/missing", "/healthz", "/nope"} {
+		rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	got := make(map[string]middleware.HistogramSnapshot)
+	for _, snap := range hist.Snapshot() {
+		got[snap.Route+" "+snap.Class] = snap
+	}
+
+	tests := []struct {
+		series  string
+		count   int64
+		buckets []middleware.HistogramBucket
+	}{
+		{"GET /users/{id} 2xx", 3, []middleware.HistogramBucket{{LeMs: 10, Count: 2}, {LeMs: 50, Count: 3}, {LeMs: -1, Count: 3}}},
+		{"GET /users/{id} 4xx", 1, []middleware.HistogramBucket{{LeMs: 10, Count: 1}, {LeMs: 50, Count: 1}, {LeMs: -1, Count: 1}}},
+		{"unmatched 4xx", 1, []middleware.HistogramBucket{{LeMs: 10, Count: 1}, {LeMs: 50, Count: 1}, {LeMs: -1, Count: 1}}},
+	}
+	if len(got) != len(tests) {
+		t.Errorf("Expected %d series, got %v", len(tests), got)
+	}
+	for _, tt := range tests {
+		snap := got[tt.series]
+		if snap.Count != tt.count || !slices.Equal(snap.Buckets, tt.buckets) {
+			t.Errorf("%s: expected %d requests in %v, got %+v", tt.series, tt.count, tt.buckets, snap)
+		}
+	}
+	if snap := got["GET /users/{id} 2xx"]; snap.SumMs < 20 {
+		t.Errorf("Expected sum to include the slow request, got %v", snap.SumMs)
+	}
+
+	w := httptest.NewRecorder()
+	hist.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"class":"2xx"`) || hist.String() != strings.TrimSpace(w.Body.String()) {
+		t.Errorf("Expected histograms as JSON, got %s", w.Body)
+	}
+}
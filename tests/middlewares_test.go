@@ -1,10 +1,15 @@
 package tests
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/piheta/apicore/apierr"
@@ -193,3 +198,204 @@ func BenchmarkPublic(b *testing.B) {
 		handler(w, r)
 	}
 }
+
+func TestRequestLogger_LogsResponseSize(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(prev)
+
+	handler := middleware.RequestLogger(middleware.Public(func(w http.ResponseWriter, _ *http.Request) error {
+		return response.JSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+	handler.ServeHTTP(w, r)
+
+	want := "bytes=" + strconv.Itoa(w.Body.Len())
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("Expected log to contain %q, got %q", want, buf.String())
+	}
+}
+
+func TestMaxBody(t *testing.T) {
+	handler := middleware.MaxBody(16)(middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return err
+		}
+		return response.JSON(w, http.StatusOK, body)
+	}))
+
+	tests := []struct {
+		name          string
+		body          string
+		chunked       bool
+		expectedCode  int
+		expectedError string
+	}{
+		{name: "within limit", body: `{"a":"b"}`, expectedCode: http.StatusOK},
+		{name: "declared too large", body: `{"a":"bbbbbbbbbbbbbbbbbbbb"}`, expectedCode: http.StatusRequestEntityTooLarge, expectedError: "payload_too_large"},
+		{name: "streamed too large", body: `{"a":"bbbbbbbbbbbbbbbbbbbb"}`, chunked: true, expectedCode: http.StatusRequestEntityTooLarge, expectedError: "payload_too_large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(tt.body))
+			if tt.chunked {
+				r.ContentLength = -1
+			}
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if tt.expectedError == "" {
+				return
+			}
+			var result apierr.APIError
+			if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if result.Type != tt.expectedError {
+				t.Errorf("Expected type=%s, got %q", tt.expectedError, result.Type)
+			}
+		})
+	}
+}
+
+func TestMethodOverride(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		header      string
+		contentType string
+		body        string
+		want        string
+	}{
+		{name: "header", method: http.MethodPost, header: "DELETE", want: http.MethodDelete},
+		{name: "form field", method: http.MethodPost, contentType: "application/x-www-form-urlencoded", body: "_method=patch", want: http.MethodPatch},
+		{name: "disallowed override", method: http.MethodPost, header: "CONNECT", want: http.MethodPost},
+		{name: "non-post ignored", method: http.MethodGet, header: "DELETE", want: http.MethodGet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := middleware.MethodOverride(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got = r.Method
+			}))
+
+			r := httptest.NewRequest(tt.method, "/test", strings.NewReader(tt.body))
+			if tt.header != "" {
+				r.Header.Set("X-HTTP-Method-Override", tt.header)
+			}
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if got != tt.want {
+				t.Errorf("Method = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireContentType(t *testing.T) {
+	handler := middleware.RequireContentType("application/json")(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name         string
+		contentType  string
+		body         string
+		expectedCode int
+	}{
+		{name: "json", contentType: "application/json", body: "{}", expectedCode: http.StatusOK},
+		{name: "json with charset", contentType: "Application/JSON; charset=utf-8", body: "{}", expectedCode: http.StatusOK},
+		{name: "form", contentType: "application/x-www-form-urlencoded", body: "a=b", expectedCode: http.StatusUnsupportedMediaType},
+		{name: "missing", contentType: "", body: "{}", expectedCode: http.StatusUnsupportedMediaType},
+		{name: "no body", contentType: "", body: "", expectedCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			r := httptest.NewRequest(http.MethodPost, "/test", body)
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestClientFilter(t *testing.T) {
+	handler := middleware.ClientFilter(middleware.ClientFilterOptions{
+		BlockedUserAgents: []*regexp.Regexp{regexp.MustCompile(`(?i)badbot`)},
+		VersionHeader:     "X-Client-Version",
+		MinVersion:        "2.4.0",
+	})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name         string
+		userAgent    string
+		version      string
+		expectedCode int
+	}{
+		{name: "current", userAgent: "app/ios", version: "2.4.0", expectedCode: http.StatusOK},
+		{name: "newer", userAgent: "app/ios", version: "v2.10", expectedCode: http.StatusOK},
+		{name: "older", userAgent: "app/ios", version: "2.3.9", expectedCode: http.StatusUpgradeRequired},
+		{name: "prerelease of minimum", userAgent: "app/ios", version: "2.4.0-rc.1", expectedCode: http.StatusUpgradeRequired},
+		{name: "missing version", userAgent: "app/ios", version: "", expectedCode: http.StatusUpgradeRequired},
+		{name: "invalid version", userAgent: "app/ios", version: "latest", expectedCode: http.StatusUpgradeRequired},
+		{name: "blocked agent", userAgent: "BadBot/1.0", version: "3.0.0", expectedCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/test", nil)
+			r.Header.Set("User-Agent", tt.userAgent)
+			if tt.version != "" {
+				r.Header.Set("X-Client-Version", tt.version)
+			}
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestClientFilter_AllowList(t *testing.T) {
+	handler := middleware.ClientFilter(middleware.ClientFilterOptions{
+		AllowedUserAgents: []*regexp.Regexp{regexp.MustCompile(`^app/`)},
+	})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/test", nil)
+	r.Header.Set("User-Agent", "curl/8.0")
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+func newTestCert(t *testing.T, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestClientCert(t *testing.T) {
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	ca, caKey := newTestCert(t, caTemplate, nil, nil)
+	otherCA, otherKey := newTestCert(t, caTemplate, nil, nil)
+
+	spiffe, _ := url.Parse("spiffe://example.org/billing")
+	clientTemplate := func(serial int64) *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "billing"},
+			URIs:         []*url.URL{spiffe},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+	}
+	client, _ := newTestCert(t, clientTemplate(2), ca, caKey)
+	untrusted, _ := newTestCert(t, clientTemplate(3), otherCA, otherKey)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	tests := []struct {
+		name       string
+		allowed    []string
+		cert       *x509.Certificate
+		wantStatus int
+	}{
+		{name: "trusted", cert: client, wantStatus: http.StatusOK},
+		{name: "allowed by SAN", allowed: []string{"spiffe://example.org/billing"}, cert: client, wantStatus: http.StatusOK},
+		{name: "not allowed", allowed: []string{"spiffe://example.org/orders"}, cert: client, wantStatus: http.StatusForbidden},
+		{name: "untrusted", cert: untrusted, wantStatus: http.StatusUnauthorized},
+		{name: "missing", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var principal string
+			handler := middleware.ClientCert(middleware.ClientCertOptions{Roots: roots, Allowed: tt.allowed})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if p, ok := middleware.GetCertPrincipal(r.Context()); ok {
+					principal = p.Name
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "https://internal/ledger", nil)
+			if tt.cert != nil {
+				r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{tt.cert}}
+			}
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus == http.StatusOK && principal != "spiffe://example.org/billing" {
+				t.Errorf("Expected SPIFFE ID as principal, got %q", principal)
+			}
+		})
+	}
+}
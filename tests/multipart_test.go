@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/piheta/apicore/bind"
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/response"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type testPart struct {
+	field, filename string
+	content         []byte
+}
+
+func newMultipartRequest(t *testing.T, parts ...testPart) *http.Request {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, p := range parts {
+		var w io.Writer
+		var err error
+		if p.filename != "" {
+			w, err = mw.CreateFormFile(p.field, p.filename)
+		} else {
+			w, err = mw.CreateFormField(p.field)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write(p.content)
+	}
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestBindMultipart(t *testing.T) {
+	type uploadRequest struct {
+		Title  string               `form:"title" validate:"required"`
+		Avatar *bind.UploadedFile   `file:"avatar,size=1KB,types=image/png image/jpeg" validate:"required"`
+		Docs   []*bind.UploadedFile `file:"docs,types=text/*,count=2"`
+	}
+
+	avatar := testPart{"avatar", "me.png", pngHeader}
+	title := testPart{"title", "", []byte("hello")}
+	doc := testPart{"docs", "a.txt", []byte("plain text")}
+
+	tests := []struct {
+		name         string
+		parts        []testPart
+		expectedCode int
+		expectedType string
+	}{
+		{name: "valid", parts: []testPart{title, avatar, doc, doc}, expectedCode: http.StatusOK},
+		{name: "missing file", parts: []testPart{title}, expectedCode: http.StatusUnprocessableEntity, expectedType: "validation"},
+		{name: "sniffed type", parts: []testPart{title, {"avatar", "me.png", []byte("not really a png")}}, expectedCode: http.StatusUnsupportedMediaType, expectedType: "unsupported_media_type"},
+		{name: "too large", parts: []testPart{title, {"avatar", "me.png", append(pngHeader, make([]byte, 1024)...)}}, expectedCode: http.StatusRequestEntityTooLarge, expectedType: "payload_too_large"},
+		{name: "too many", parts: []testPart{title, avatar, doc, doc, doc}, expectedCode: http.StatusRequestEntityTooLarge, expectedType: "too_many_files"},
+		{name: "single file field", parts: []testPart{title, avatar, avatar}, expectedCode: http.StatusRequestEntityTooLarge, expectedType: "too_many_files"},
+	}
+
+	handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+		var req uploadRequest
+		if err := bind.Multipart(r, &req); err != nil {
+			return err
+		}
+		if req.Title != "hello" || req.Avatar.ContentType != "image/png" || len(req.Docs) != 2 {
+			t.Errorf("Unexpected request %+v", req)
+		}
+
+		f, err := req.Avatar.Open()
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		content, _ := io.ReadAll(f)
+		if !bytes.Equal(content, pngHeader) {
+			t.Errorf("Expected avatar content %q, got %q", pngHeader, content)
+		}
+		return response.Status(w, http.StatusOK)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, newMultipartRequest(t, tt.parts...))
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.expectedType != "" {
+				if got := decodeErrorType(t, w); got != tt.expectedType {
+					t.Errorf("Expected type %q, got %q", tt.expectedType, got)
+				}
+			}
+		})
+	}
+}
+
+func TestBindMultipart_WrongContentType(t *testing.T) {
+	var dst struct{}
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	middleware.Public(func(_ http.ResponseWriter, r *http.Request) error {
+		return bind.Multipart(r, &dst)
+	}).ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected status %d, got %d", http.StatusUnsupportedMediaType, w.Code)
+	}
+}
+
+func TestBindMultipart_MalformedFileTag(t *testing.T) {
+	tests := []struct {
+		name string
+		dst  any
+	}{
+		{name: "size", dst: &struct {
+			Avatar *bind.UploadedFile `file:"avatar,size=10XB"`
+		}{}},
+		{name: "count", dst: &struct {
+			Docs []*bind.UploadedFile `file:"docs,count=x"`
+		}{}},
+		{name: "unknown option", dst: &struct {
+			Avatar *bind.UploadedFile `file:"avatar,maxsize=1MB"`
+		}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := bind.Check(tt.dst); err == nil {
+				t.Error("Expected Check to report the malformed file tag")
+			}
+
+			w := httptest.NewRecorder()
+			middleware.Public(func(_ http.ResponseWriter, r *http.Request) error {
+				return bind.Multipart(r, tt.dst)
+			}).ServeHTTP(w, newMultipartRequest(t, testPart{field: "avatar", filename: "a.png", content: pngHeader}))
+
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+			}
+		})
+	}
+}
+
+func TestBindParts(t *testing.T) {
+	limits := bind.FileLimits{MaxSize: 600, Types: []string{"image/*"}, MaxCount: 2}
+	avatar := testPart{"avatar", "me.png", pngHeader}
+
+	tests := []struct {
+		name         string
+		parts        []testPart
+		expectedCode int
+		expectedSeen []string
+	}{
+		{name: "streams parts", parts: []testPart{{"title", "", []byte("hi")}, avatar, avatar}, expectedCode: http.StatusOK, expectedSeen: []string{"title:", "avatar:image/png", "avatar:image/png"}},
+		{name: "type", parts: []testPart{{"doc", "a.txt", []byte("text")}}, expectedCode: http.StatusUnsupportedMediaType},
+		{name: "size while streaming", parts: []testPart{{"avatar", "big.png", append(pngHeader, make([]byte, 700)...)}}, expectedCode: http.StatusRequestEntityTooLarge, expectedSeen: []string{"avatar:image/png"}},
+		{name: "count", parts: []testPart{avatar, avatar, avatar}, expectedCode: http.StatusRequestEntityTooLarge, expectedSeen: []string{"avatar:image/png", "avatar:image/png"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen []string
+			handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+				err := bind.Parts(r, limits, func(p *bind.Part) error {
+					seen = append(seen, p.Name+":"+p.ContentType)
+					_, err := io.Copy(io.Discard, p)
+					return err
+				})
+				if err != nil {
+					return err
+				}
+				return response.Status(w, http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, newMultipartRequest(t, tt.parts...))
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if strings.Join(seen, ",") != strings.Join(tt.expectedSeen, ",") {
+				t.Errorf("Expected parts %v, got %v", tt.expectedSeen, seen)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/response"
+)
+
+func TestOIDC(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	idp := httptest.NewServer(mux)
+	defer idp.Close()
+
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = response.JSON(w, http.StatusOK, map[string]string{"issuer": idp.URL, "jwks_uri": idp.URL + "/keys"})
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, _ *http.Request) {
+		_ = response.JSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+			"kty": "EC",
+			"kid": "rotated",
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		}}})
+	})
+
+	provider := middleware.NewOIDCProvider(middleware.OIDCConfig{IssuerURL: idp.URL, ClientID: "my-app"})
+	handler := middleware.OIDC(provider)(middleware.Public(claimsHandler))
+
+	tests := []struct {
+		name       string
+		claims     map[string]any
+		wantStatus int
+	}{
+		{name: "valid", claims: map[string]any{"iss": idp.URL, "aud": "my-app", "sub": "u1", "exp": time.Now().Add(time.Minute).Unix()}, wantStatus: http.StatusOK},
+		{name: "wrong issuer", claims: map[string]any{"iss": "https://evil", "aud": "my-app"}, wantStatus: http.StatusUnauthorized},
+		{name: "wrong client", claims: map[string]any{"iss": idp.URL, "aud": "other"}, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/test", nil)
+			r.Header.Set("Authorization", "Bearer "+signES256(t, key, "rotated", tt.claims))
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestOIDC_DiscoveryFailure(t *testing.T) {
+	idp := httptest.NewServer(http.NotFoundHandler())
+	defer idp.Close()
+
+	provider := middleware.NewOIDCProvider(middleware.OIDCConfig{IssuerURL: idp.URL})
+	handler := middleware.OIDC(provider)(middleware.Public(claimsHandler))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/test", nil)
+	r.Header.Set("Authorization", "Bearer a.b.c")
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
+
+func TestOIDC_DiscoveryBackoff(t *testing.T) {
+	var fetches atomic.Int32
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer idp.Close()
+
+	provider := middleware.NewOIDCProvider(middleware.OIDCConfig{IssuerURL: idp.URL})
+	for range 3 {
+		if _, err := provider.Verify(context.Background(), "a.b.c"); err == nil {
+			t.Fatalf("Expected an error while discovery fails")
+		}
+	}
+
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("Expected one discovery fetch while retries back off, got %d", n)
+	}
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/bind"
+	"github.com/piheta/apicore/response"
+)
+
+func TestParsePagination(t *testing.T) {
+	opts := bind.PaginationOptions{MaxPerPage: 50, MaxPage: 100}
+
+	tests := []struct {
+		name         string
+		query        string
+		expected     bind.Pagination
+		expectedCode int
+	}{
+		{name: "defaults", query: "", expected: bind.Pagination{Page: 1, PerPage: 20, Limit: 20}},
+		{name: "page", query: "?page=3&per_page=10", expected: bind.Pagination{Page: 3, PerPage: 10, Offset: 20, Limit: 10}},
+		{name: "cursor", query: "?cursor=abc&per_page=5", expected: bind.Pagination{PerPage: 5, Cursor: "abc", Limit: 5}},
+		{name: "per_page over max", query: "?per_page=51", expectedCode: http.StatusBadRequest},
+		{name: "per_page zero", query: "?per_page=0", expectedCode: http.StatusBadRequest},
+		{name: "page not a number", query: "?page=two", expectedCode: http.StatusBadRequest},
+		{name: "negative page", query: "?page=-1", expectedCode: http.StatusBadRequest},
+		{name: "page too deep", query: "?page=101", expectedCode: http.StatusBadRequest},
+		{name: "page and cursor", query: "?page=2&cursor=abc", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := bind.ParsePagination(httptest.NewRequest(http.MethodGet, "/users"+tt.query, nil), opts)
+
+			if tt.expectedCode != 0 {
+				apiErr := apierr.MapError(err, nil)
+				if apiErr == nil || apiErr.StatusCode != tt.expectedCode || apiErr.Type != "invalid_pagination" {
+					t.Fatalf("Expected %d invalid_pagination, got %v", tt.expectedCode, apiErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if p != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, p)
+			}
+		})
+	}
+}
+
+func TestParsePagination_OffsetOverflow(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/users?page=9223372036854775807&per_page=100", nil)
+	p, err := bind.ParsePagination(r, bind.PaginationOptions{})
+
+	apiErr := apierr.MapError(err, nil)
+	if apiErr == nil || apiErr.StatusCode != http.StatusBadRequest || apiErr.Type != "invalid_pagination" {
+		t.Fatalf("Expected 400 invalid_pagination, got %v with %+v", apiErr, p)
+	}
+}
+
+func TestPaginationCursor(t *testing.T) {
+	type position struct {
+		CreatedAt string `json:"c"`
+		ID        int    `json:"i"`
+	}
+
+	cursor, err := bind.EncodeCursor(position{CreatedAt: "2024-05-31", ID: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := bind.ParsePagination(httptest.NewRequest(http.MethodGet, "/users?cursor="+cursor, nil), bind.PaginationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got position
+	if err := p.DecodeCursor(&got); err != nil || got.ID != 42 {
+		t.Errorf("Expected decoded cursor, got %+v (%v)", got, err)
+	}
+
+	p.Cursor = "!!not-base64"
+	if err := p.DecodeCursor(&got); apierr.MapError(err, nil).StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid cursor, got %v", err)
+	}
+}
+
+func TestPaginated(t *testing.T) {
+	p, _ := bind.ParsePagination(httptest.NewRequest(http.MethodGet, "/users?page=2&per_page=2", nil), bind.PaginationOptions{})
+
+	tests := []struct {
+		name     string
+		items    []string
+		info     response.PageInfo
+		expected string
+	}{
+		{name: "page", items: []string{"c", "d"}, info: p.Info(true, ""), expected: `{"items":["c","d"],"pagination":{"page":2,"per_page":2,"has_more":true}}` + "\n"},
+		{name: "nil items", items: nil, info: p.Info(false, ""), expected: `{"items":[],"pagination":{"page":2,"per_page":2,"has_more":false}}` + "\n"},
+		{name: "cursor", items: []string{"a"}, info: response.PageInfo{PerPage: 1, HasMore: true, NextCursor: "xyz"}, expected: `{"items":["a"],"pagination":{"per_page":1,"has_more":true,"next_cursor":"xyz"}}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if err := response.Paginated(w, http.StatusOK, tt.items, tt.info); err != nil {
+				t.Fatal(err)
+			}
+			if w.Body.String() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, w.Body.String())
+			}
+		})
+	}
+}
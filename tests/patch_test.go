@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/piheta/apicore/bind"
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/response"
+)
+
+type updateUserRequest struct {
+	Name     string  `json:"name" validate:"omitempty,min=2"`
+	Nickname *string `json:"nickname"`
+	Age      int     `json:"age"`
+	Address  struct {
+		City string `json:"city"`
+		Zip  string `json:"zip"`
+	} `json:"address"`
+}
+
+func TestBindPatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		opts          []bind.Option
+		expectedPaths []string
+		expectedNull  []string
+		expectedCode  int
+	}{
+		{name: "absent vs zero", body: `{"age":0}`, expectedPaths: []string{"age"}, expectedCode: http.StatusOK},
+		{name: "explicit null", body: `{"nickname":null,"name":"Ada"}`, expectedPaths: []string{"name", "nickname"}, expectedNull: []string{"nickname"}, expectedCode: http.StatusOK},
+		{name: "nested", body: `{"address":{"city":"Oslo"}}`, expectedPaths: []string{"address", "address.city"}, expectedCode: http.StatusOK},
+		{name: "empty", body: `{}`, expectedCode: http.StatusOK},
+		{name: "validated", body: `{"name":"A"}`, expectedCode: http.StatusUnprocessableEntity},
+		{name: "strict", body: `{"nmae":"Ada"}`, opts: []bind.Option{bind.Strict()}, expectedCode: http.StatusBadRequest},
+		{name: "invalid", body: `{"age":"old"}`, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mask bind.FieldMask
+			handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+				var req updateUserRequest
+				m, err := bind.Patch(r, &req, tt.opts...)
+				if err != nil {
+					return err
+				}
+				mask = m
+				return response.Status(w, http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/users/1", strings.NewReader(tt.body)))
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			if paths := mask.Paths(); len(paths) != len(tt.expectedPaths) || (len(paths) > 0 && !reflect.DeepEqual(paths, tt.expectedPaths)) {
+				t.Errorf("Expected paths %v, got %v", tt.expectedPaths, paths)
+			}
+			for _, path := range tt.expectedPaths {
+				expectedNull := false
+				for _, n := range tt.expectedNull {
+					expectedNull = expectedNull || n == path
+				}
+				if mask.IsNull(path) != expectedNull {
+					t.Errorf("Expected IsNull(%q) = %v", path, expectedNull)
+				}
+			}
+			if mask.Has("zip") || mask.Has("address.zip") {
+				t.Error("Expected absent fields not to be in mask")
+			}
+		})
+	}
+}
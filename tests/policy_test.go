@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/response"
+	"github.com/piheta/apicore/router"
+	"github.com/piheta/apicore/server"
+)
+
+func TestRouter_Policy(t *testing.T) {
+	readBody := func(w http.ResponseWriter, r *http.Request) error {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		limit, _ := middleware.GetMaxBody(r.Context())
+		return response.JSON(w, http.StatusOK, map[string]int64{"read": int64(len(body)), "limit": limit})
+	}
+	slow := func(w http.ResponseWriter, r *http.Request) error {
+		select {
+		case <-time.After(100 * time.Millisecond):
+			return response.JSON(w, http.StatusOK, "done")
+		case <-r.Context().Done():
+			return r.Context().Err()
+		}
+	}
+	ok := func(w http.ResponseWriter, _ *http.Request) error {
+		return response.JSON(w, http.StatusOK, "ok")
+	}
+
+	api := router.New()
+	api.SetPolicy(router.Policy{Timeout: 20 * time.Millisecond, MaxBody: 10, RateLimit: "default"})
+	api.RateLimitTier("default", middleware.RateLimitOptions{Rate: 0.001, Burst: 1})
+
+	files := router.New()
+	files.Handle(http.MethodPost, "/small", readBody)
+	files.Handle(http.MethodPost, "/upload", readBody).MaxBody(100).RateLimit(router.NoRateLimit)
+	files.Handle(http.MethodPost, "/unlimited", readBody).MaxBody(-1).RateLimit(router.NoRateLimit)
+	api.Mount("/files", files)
+
+	api.Handle(http.MethodGet, "/slow", slow).RateLimit(router.NoRateLimit)
+	api.Handle(http.MethodGet, "/export", slow).Timeout(-1).RateLimit(router.NoRateLimit)
+	api.Handle(http.MethodGet, "/limited", ok)
+	api.Handle(http.MethodGet, "/healthz", ok).RateLimit(router.NoRateLimit)
+
+	body := strings.Repeat("x", 20)
+	tests := []struct {
+		name           string
+		method, path   string
+		body           string
+		expectedStatus int
+	}{
+		{name: "inherited body limit", method: http.MethodPost, path: "/files/small", body: body, expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "raised body limit", method: http.MethodPost, path: "/files/upload", body: body, expectedStatus: http.StatusOK},
+		{name: "removed body limit", method: http.MethodPost, path: "/files/unlimited", body: body, expectedStatus: http.StatusOK},
+		{name: "inherited timeout", method: http.MethodGet, path: "/slow", expectedStatus: http.StatusGatewayTimeout},
+		{name: "disabled timeout", method: http.MethodGet, path: "/export", expectedStatus: http.StatusOK},
+		{name: "rate limited first", method: http.MethodGet, path: "/limited", expectedStatus: http.StatusOK},
+		{name: "rate limited second", method: http.MethodGet, path: "/limited", expectedStatus: http.StatusTooManyRequests},
+		{name: "exempt first", method: http.MethodGet, path: "/healthz", expectedStatus: http.StatusOK},
+		{name: "exempt second", method: http.MethodGet, path: "/healthz", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			api.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body)
+			}
+		})
+	}
+}
+
+func TestRouter_PolicyUnknownTier(t *testing.T) {
+	ok := func(http.ResponseWriter, *http.Request) error { return nil }
+	api := router.New(middleware.Recover)
+	api.RateLimitTier("uploads", middleware.RateLimitOptions{Rate: 1, Burst: 1})
+	api.Handle(http.MethodGet, "/", ok).RateLimit("uploads")
+	users := router.New()
+	users.Handle(http.MethodGet, "/{id}", ok).RateLimit("upload")
+	api.Mount("/users", users)
+
+	err := api.Check()
+	if err == nil || !strings.Contains(err.Error(), `"upload"`) || !strings.Contains(err.Error(), "GET /{id}") {
+		t.Errorf("Expected unknown tier error naming the route, got %v", err)
+	}
+
+	for range 2 {
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	}
+
+	valid := router.New()
+	valid.RateLimitTier("uploads", middleware.RateLimitOptions{Rate: 1})
+	hosted := valid.Host("{tenant}.example.com")
+	hosted.SetPolicy(router.Policy{RateLimit: "uploads"})
+	hosted.Handle(http.MethodGet, "/", ok)
+	if err := valid.Check(); err != nil {
+		t.Errorf("Expected tier of the outer router to resolve, got %v", err)
+	}
+}
+
+func TestServer_StartUnknownTier(t *testing.T) {
+	srv := server.New(server.Options{Addr: "127.0.0.1:0"})
+	srv.Handle(http.MethodGet, "/", func(http.ResponseWriter, *http.Request) error { return nil }).RateLimit("missing")
+
+	err := srv.Start()
+	if err == nil {
+		_ = srv.Stop(context.Background())
+		t.Fatal("Expected Start to fail on an unknown rate limit tier")
+	}
+	if !strings.Contains(err.Error(), "unknown rate limit tier") {
+		t.Errorf("Expected unknown tier error, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/proxy"
+)
+
+func TestProxy_Forwards(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	var got http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		_, _ = w.Write([]byte("upstream"))
+	}))
+	defer upstream.Close()
+
+	target, _ := url.Parse(upstream.URL)
+	handler := middleware.RequestID(proxy.New(target, proxy.Options{}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/items", nil)
+	r.Header.Set(middleware.RequestIDHeader, "req-1")
+	r.Header.Set("Traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK || w.Body.String() != "upstream" {
+		t.Fatalf("Expected proxied response, got %d %q", w.Code, w.Body.String())
+	}
+	if got.Get(middleware.RequestIDHeader) != "req-1" {
+		t.Errorf("Expected request ID to be forwarded, got %q", got.Get(middleware.RequestIDHeader))
+	}
+	parts := strings.Split(got.Get("Traceparent"), "-")
+	if len(parts) != 4 || parts[1] != traceID || parts[2] == "00f067aa0ba902b7" {
+		t.Errorf("Expected traceparent continuing the trace with a new span, got %q", got.Get("Traceparent"))
+	}
+	if got.Get("X-Forwarded-For") == "" {
+		t.Errorf("Expected X-Forwarded-For to be set")
+	}
+}
+
+func TestProxy_Traceparent(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	var got string
+	upstream := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(middleware.TraceparentHeader)
+	}))
+	defer upstream.Close()
+	target, _ := url.Parse(upstream.URL)
+	p := proxy.New(target, proxy.Options{})
+
+	tests := []struct {
+		name      string
+		header    string
+		extractor bool
+		trace     bool
+		wantTrace string
+		wantFlags string
+	}{
+		{name: "header", header: "00-" + traceID + "-00f067aa0ba902b7-00", wantTrace: traceID, wantFlags: "00"},
+		{name: "future version", header: "01-" + traceID + "-00f067aa0ba902b7-01-extra", wantTrace: traceID, wantFlags: "01"},
+		{name: "malformed", header: "00-" + traceID + "-00f067aa0ba902b7", wantFlags: "01"},
+		{name: "missing", wantFlags: "01"},
+		{name: "trace middleware", header: "00-" + traceID + "-00f067aa0ba902b7-01", trace: true, wantTrace: traceID, wantFlags: "01"},
+		{name: "span extractor", extractor: true, wantTrace: traceID, wantFlags: "01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.extractor {
+				middleware.SetSpanExtractor(func(context.Context) (middleware.SpanContext, bool) {
+					return middleware.SpanContext{TraceID: traceID, SpanID: "00f067aa0ba902b7", Sampled: true}, true
+				})
+				defer middleware.SetSpanExtractor(nil)
+			}
+			var server middleware.SpanContext
+			var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				server, _ = middleware.GetSpan(r.Context())
+				p.ServeHTTP(w, r)
+			})
+			if tt.trace {
+				handler = middleware.Trace(handler)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set(middleware.TraceparentHeader, tt.header)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			sc, ok := middleware.ParseTraceparent(got)
+			if !ok || !strings.HasPrefix(got, "00-") {
+				t.Fatalf("Expected a version 00 traceparent, got %q", got)
+			}
+			if tt.wantTrace != "" && sc.TraceID != tt.wantTrace {
+				t.Errorf("Expected trace %s, got %s", tt.wantTrace, sc.TraceID)
+			}
+			if tt.wantTrace == "" && sc.TraceID == traceID {
+				t.Errorf("Expected a new trace, got %s", sc.TraceID)
+			}
+			if sc.SpanID == "00f067aa0ba902b7" || sc.SpanID == server.SpanID {
+				t.Errorf("Expected a new span ID, got %s", sc.SpanID)
+			}
+			if !strings.HasSuffix(got, "-"+tt.wantFlags) {
+				t.Errorf("Expected flags %s, got %q", tt.wantFlags, got)
+			}
+		})
+	}
+}
+
+func TestProxy_UpstreamErrors(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closedURL := closed.URL
+	closed.Close()
+
+	tests := []struct {
+		name       string
+		target     string
+		transport  http.RoundTripper
+		wantStatus int
+		wantType   string
+	}{
+		{name: "unreachable", target: closedURL, wantStatus: http.StatusBadGateway, wantType: "bad_gateway"},
+		{name: "timeout", target: slow.URL, transport: &http.Transport{ResponseHeaderTimeout: 10 * time.Millisecond}, wantStatus: http.StatusGatewayTimeout, wantType: "upstream_timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+			target, _ := url.Parse(tt.target)
+			handler := middleware.RequestLoggerWith(middleware.LoggerOptions{Logger: logger})(proxy.New(target, proxy.Options{Transport: tt.transport}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			var result apierr.APIError
+			if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if result.Type != tt.wantType {
+				t.Errorf("Expected type %q, got %q", tt.wantType, result.Type)
+			}
+			if !strings.Contains(buf.String(), "upstream="+target.Host) || !strings.Contains(buf.String(), "upstream_ms=") {
+				t.Errorf("Expected upstream metadata in log, got %q", buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/middleware"
+)
+
+func TestQuota(t *testing.T) {
+	handler := middleware.Quota(middleware.StaticQuota(
+		middleware.QuotaLimit{Period: middleware.QuotaDaily, Limit: 2},
+		middleware.QuotaLimit{Period: middleware.QuotaMonthly, Limit: 100},
+	), middleware.QuotaOptions{})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(subject string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/reports", nil)
+		if subject != "" {
+			r = r.WithContext(context.WithValue(r.Context(), middleware.ClaimsContextKey, middleware.Claims{"sub": subject}))
+		}
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		w := serve("alice")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected status %d, got %d", i, http.StatusOK, w.Code)
+		}
+		if got := w.Header().Get("X-Quota-Remaining"); got != wantRemaining {
+			t.Errorf("Request %d: expected remaining %s, got %s", i, wantRemaining, got)
+		}
+		if got := w.Header().Get("X-Quota-Limit"); got != "2" {
+			t.Errorf("Request %d: expected daily limit to be reported, got %s", i, got)
+		}
+	}
+
+	w := serve("alice")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+	var result apierr.APIError
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Type != "quota_exceeded" {
+		t.Errorf("Expected type=quota_exceeded, got %q", result.Type)
+	}
+	reset, _ := strconv.ParseInt(w.Header().Get("X-Quota-Reset"), 10, 64)
+	if until := time.Until(time.Unix(reset, 0)); until <= 0 || until > 24*time.Hour {
+		t.Errorf("Expected reset within a day, got %v", until)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected Retry-After header")
+	}
+
+	if w := serve("bob"); w.Code != http.StatusOK {
+		t.Errorf("Expected other principals to have their own quota, got %d", w.Code)
+	}
+	if w := serve(""); w.Code != http.StatusOK || w.Header().Get("X-Quota-Limit") != "" {
+		t.Errorf("Expected unauthenticated requests to pass uncounted, got %d", w.Code)
+	}
+}
@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+func TestRateLimit(t *testing.T) {
+	handler := middleware.RateLimit(middleware.RateLimitOptions{
+		Rate:  1,
+		Burst: 2,
+		Key:   middleware.KeyByHeader("X-API-Key"),
+	})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	do := func(key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/test", nil)
+		r.Header.Set("X-API-Key", key)
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	for i := range 2 {
+		if w := do("a"); w.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected status %d, got %d", i, http.StatusOK, w.Code)
+		}
+	}
+
+	w := do("a")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After header on 429")
+	}
+
+	if w := do("b"); w.Code != http.StatusOK {
+		t.Errorf("Expected separate bucket for key b, got status %d", w.Code)
+	}
+	if w := do(""); w.Code != http.StatusOK {
+		t.Errorf("Expected empty key to be exempt, got status %d", w.Code)
+	}
+}
+
+func TestRateLimit_Headers(t *testing.T) {
+	handler := middleware.RateLimit(middleware.RateLimitOptions{
+		Rate:  1,
+		Burst: 2,
+		Key:   middleware.KeyByHeader("X-API-Key"),
+	})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		wantStatus    int
+		wantRemaining string
+	}{
+		{wantStatus: http.StatusOK, wantRemaining: "1"},
+		{wantStatus: http.StatusOK, wantRemaining: "0"},
+		{wantStatus: http.StatusTooManyRequests, wantRemaining: "0"},
+	}
+
+	for i, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/test", nil)
+		r.Header.Set("X-API-Key", "a")
+		handler.ServeHTTP(w, r)
+
+		if w.Code != tt.wantStatus {
+			t.Fatalf("Request %d: expected status %d, got %d", i, tt.wantStatus, w.Code)
+		}
+		h := w.Header()
+		for _, name := range []string{"X-RateLimit-Limit", "RateLimit-Limit"} {
+			if got := h.Get(name); got != "2" {
+				t.Errorf("Request %d: expected %s 2, got %q", i, name, got)
+			}
+		}
+		for _, name := range []string{"X-RateLimit-Remaining", "RateLimit-Remaining"} {
+			if got := h.Get(name); got != tt.wantRemaining {
+				t.Errorf("Request %d: expected %s %s, got %q", i, name, tt.wantRemaining, got)
+			}
+		}
+		reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil || reset < time.Now().Unix() {
+			t.Errorf("Request %d: expected X-RateLimit-Reset as a future Unix time, got %q", i, h.Get("X-RateLimit-Reset"))
+		}
+		if got := h.Get("RateLimit-Policy"); got != "2;w=2" {
+			t.Errorf("Request %d: expected RateLimit-Policy 2;w=2, got %q", i, got)
+		}
+	}
+}
+
+type fakeEvaler struct {
+	reply any
+	err   error
+	keys  []string
+}
+
+func (f *fakeEvaler) Eval(_ context.Context, _ string, keys []string, _ ...any) (any, error) {
+	f.keys = keys
+	return f.reply, f.err
+}
+
+func TestRedisStore(t *testing.T) {
+	evaler := &fakeEvaler{reply: []any{int64(0), int64(0), int64(1500), int64(3000)}}
+	store := middleware.NewRedisStore(evaler, "")
+
+	decision, err := store.Take(context.Background(), "1.2.3.4", 1, 3)
+	if err != nil {
+		t.Fatalf("Take() returned error: %v", err)
+	}
+	if decision.Allowed {
+		t.Error("Expected request to be denied")
+	}
+	if decision.RetryAfter != 1500*time.Millisecond {
+		t.Errorf("RetryAfter = %v, want 1.5s", decision.RetryAfter)
+	}
+	if len(evaler.keys) != 1 || evaler.keys[0] != "ratelimit:1.2.3.4" {
+		t.Errorf("Expected prefixed key, got %v", evaler.keys)
+	}
+}
+
+func TestRateLimit_StoreFailureFailsOpen(t *testing.T) {
+	store := middleware.NewRedisStore(&fakeEvaler{err: errors.New("connection refused")}, "")
+	handler := middleware.RateLimit(middleware.RateLimitOptions{Rate: 1, Store: store})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+func TestRealIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		want       string
+	}{
+		{name: "untrusted peer ignores headers", remoteAddr: "203.0.113.9:1234", header: http.Header{"X-Forwarded-For": {"1.2.3.4"}}, want: "203.0.113.9"},
+		{name: "trusted peer uses XFF", remoteAddr: "10.0.0.1:1234", header: http.Header{"X-Forwarded-For": {"1.2.3.4"}}, want: "1.2.3.4"},
+		{name: "skips trusted hops", remoteAddr: "10.0.0.1:1234", header: http.Header{"X-Forwarded-For": {"6.6.6.6, 1.2.3.4, 10.0.0.2"}}, want: "1.2.3.4"},
+		{name: "forwarded header", remoteAddr: "10.0.0.1:1234", header: http.Header{"Forwarded": {`for="[2001:db8::1]:443";proto=https`}}, want: "2001:db8::1"},
+		{name: "x-real-ip", remoteAddr: "10.0.0.1:1234", header: http.Header{"X-Real-Ip": {"1.2.3.4"}}, want: "1.2.3.4"},
+		{name: "no headers", remoteAddr: "10.0.0.1:1234", want: "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := middleware.RealIP("10.0.0.0/8")(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got = middleware.ClientIP(r)
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/test", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.header {
+				r.Header[k] = v
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRealIP_UsedByRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := middleware.RequestLoggerWith(middleware.LoggerOptions{Logger: slog.New(slog.NewTextHandler(&buf, nil))})
+	handler := logger(middleware.RealIP("10.0.0.0/8")(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	r := httptest.NewRequest(http.MethodGet, "/test", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	if !strings.Contains(buf.String(), "ip=1.2.3.4") {
+		t.Errorf("Expected resolved IP in log, got %q", buf.String())
+	}
+}
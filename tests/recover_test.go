@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/middleware"
+)
+
+func TestRecover(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer slog.SetDefault(prev)
+
+	var hookValue any
+	var hookStack []string
+	handler := middleware.RecoverWith(func(_ *http.Request, recovered any, stack []string) {
+		hookValue = recovered
+		hookStack = stack
+	})(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/test", nil)
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+
+	var result apierr.APIError
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Type != "internal" {
+		t.Errorf("Expected type=internal, got %q", result.Type)
+	}
+
+	if hookValue != "boom" {
+		t.Errorf("Expected hook to receive panic value, got %v", hookValue)
+	}
+	if len(hookStack) == 0 || !strings.Contains(hookStack[0], "tests.TestRecover") {
+		t.Errorf("Expected stack to start at the panicking function, got %v", hookStack)
+	}
+	if !strings.Contains(buf.String(), `"stack":[`) {
+		t.Errorf("Expected structured stack in log, got %q", buf.String())
+	}
+
+	originalErr, ok := r.Context().Value(apierr.OriginalErrorContextKey).(error)
+	if !ok || originalErr.Error() != "panic: boom" {
+		t.Errorf("Expected original error to be recorded, got %v", originalErr)
+	}
+}
+
+func TestRecoverWithOptions(t *testing.T) {
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	defer slog.SetDefault(prev)
+
+	t.Run("custom error", func(t *testing.T) {
+		handler := middleware.RecoverWithOptions(middleware.RecoverOptions{
+			Error: func(*http.Request, any) *apierr.APIError {
+				return apierr.NewError(http.StatusServiceUnavailable, "crashed", "try again later")
+			},
+		})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic("boom")
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+		}
+		var result apierr.APIError
+		if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if result.Type != "crashed" {
+			t.Errorf("Expected type=crashed, got %q", result.Type)
+		}
+	})
+
+	t.Run("abort partial response", func(t *testing.T) {
+		hooked := false
+		handler := middleware.RecoverWithOptions(middleware.RecoverOptions{
+			Hook:         func(*http.Request, any, []string) { hooked = true },
+			AbortPartial: true,
+		})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"items":[`))
+			panic("boom")
+		}))
+
+		defer func() {
+			if v := recover(); v != http.ErrAbortHandler {
+				t.Errorf("Expected http.ErrAbortHandler, got %v", v)
+			}
+			if !hooked {
+				t.Error("Expected hook to run before aborting")
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test", nil))
+	})
+}
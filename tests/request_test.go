@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/piheta/apicore/bind"
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/response"
+)
+
+type updateOrderRequest struct {
+	OrgID   string `path:"org" json:"org"`
+	OrderID int64  `path:"id" json:"-" validate:"gt=0"`
+	DryRun  bool   `query:"dry_run" json:"-"`
+	Limit   int    `query:"limit" json:"-" default:"10"`
+	IfMatch string `header:"If-Match" json:"-"`
+	Source  string `header:"X-Source" query:"source" json:"-"`
+	Note    string `json:"note" form:"note" mod:"trim" validate:"max=20"`
+}
+
+func TestBindRequest(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		contentType  string
+		body         string
+		headers      map[string]string
+		expected     updateOrderRequest
+		expectedCode int
+	}{
+		{
+			name:         "all sources",
+			target:       "/orgs/acme/orders/7?dry_run=true&source=query",
+			contentType:  "application/json",
+			body:         `{"org":"evil","note":"  rush  "}`,
+			headers:      map[string]string{"If-Match": `"v3"`, "X-Source": "header"},
+			expected:     updateOrderRequest{OrgID: "acme", OrderID: 7, DryRun: true, Limit: 10, IfMatch: `"v3"`, Source: "query", Note: "rush"},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "header when query absent",
+			target:       "/orgs/acme/orders/7",
+			headers:      map[string]string{"X-Source": "header"},
+			expected:     updateOrderRequest{OrgID: "acme", OrderID: 7, Limit: 10, Source: "header"},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "form body",
+			target:       "/orgs/acme/orders/7?limit=5",
+			contentType:  "application/x-www-form-urlencoded",
+			body:         "note=gift",
+			expected:     updateOrderRequest{OrgID: "acme", OrderID: 7, Limit: 5, Note: "gift"},
+			expectedCode: http.StatusOK,
+		},
+		{name: "validated once", target: "/orgs/acme/orders/0", expectedCode: http.StatusUnprocessableEntity},
+		{name: "bad query", target: "/orgs/acme/orders/7?dry_run=maybe", expectedCode: http.StatusBadRequest},
+		{name: "unsupported body", target: "/orgs/acme/orders/7", contentType: "text/plain", body: "hi", expectedCode: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got updateOrderRequest
+			mux := http.NewServeMux()
+			mux.Handle("POST /orgs/{org}/orders/{id}", middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+				if err := bind.Request(r, &got); err != nil {
+					return err
+				}
+				return response.Status(w, http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.expectedCode == http.StatusOK && got != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBindAs(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{name: "valid", body: `{"name":"ada","email":"ada@example.com"}`, expectedCode: http.StatusCreated},
+		{name: "invalid", body: `{"name":"ada"}`, expectedCode: http.StatusUnprocessableEntity},
+		{name: "strict", body: `{"name":"ada","email":"ada@example.com","admin":true}`, expectedCode: http.StatusBadRequest},
+	}
+
+	handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+		req, err := bind.As[createUserRequest](r, bind.Strict())
+		if err != nil {
+			return err
+		}
+		return response.JSON(w, http.StatusCreated, req)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if w.Code == http.StatusCreated && !strings.Contains(w.Body.String(), `"email":"ada@example.com"`) {
+				t.Errorf("Expected bound request in response, got %s", w.Body.String())
+			}
+		})
+	}
+}
@@ -1,12 +1,20 @@
 package tests
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/middleware"
 	"github.com/piheta/apicore/response"
 )
 
@@ -137,3 +145,248 @@ func (f *failingResponseWriter) Write(_ []byte) (int, error) {
 func (f *failingResponseWriter) WriteHeader(_ int) {
 	f.headerWritten = true
 }
+
+func TestJSONSafeIntegers(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	data := map[string]any{
+		"big":   int64(9007199254740993),
+		"neg":   int64(-9007199254740993),
+		"small": 42,
+		"float": 1.5e300,
+		"text":  "12345678901234567890",
+	}
+
+	if err := response.JSON(w, http.StatusOK, data, response.SafeIntegers()); err != nil {
+		t.Fatalf("JSON() returned error: %v", err)
+	}
+
+	var result map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if result["big"] != "9007199254740993" {
+		t.Errorf("Expected big as string, got %#v", result["big"])
+	}
+	if result["neg"] != "-9007199254740993" {
+		t.Errorf("Expected neg as string, got %#v", result["neg"])
+	}
+	if result["small"] != float64(42) {
+		t.Errorf("Expected small as number, got %#v", result["small"])
+	}
+	if result["float"] != 1.5e300 {
+		t.Errorf("Expected float untouched, got %#v", result["float"])
+	}
+	if result["text"] != "12345678901234567890" {
+		t.Errorf("Expected text untouched, got %#v", result["text"])
+	}
+}
+
+func TestInt64RoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    response.Int64
+		encoded string
+	}{
+		{name: "number", input: `42`, want: 42, encoded: `42`},
+		{name: "string", input: `"42"`, want: 42, encoded: `42`},
+		{name: "unsafe string", input: `"9007199254740993"`, want: 9007199254740993, encoded: `"9007199254740993"`},
+		{name: "unsafe number", input: `9007199254740993`, want: 9007199254740993, encoded: `"9007199254740993"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got response.Int64
+			if err := json.Unmarshal([]byte(tt.input), &got); err != nil {
+				t.Fatalf("Unmarshal() returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Unmarshal() = %d, want %d", got, tt.want)
+			}
+
+			encoded, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("Marshal() returned error: %v", err)
+			}
+			if string(encoded) != tt.encoded {
+				t.Errorf("Marshal() = %s, want %s", encoded, tt.encoded)
+			}
+		})
+	}
+
+	var u response.Uint64
+	if err := json.Unmarshal([]byte(`"18446744073709551615"`), &u); err != nil {
+		t.Fatalf("Unmarshal() returned error: %v", err)
+	}
+	if u != 18446744073709551615 {
+		t.Errorf("Unmarshal() = %d, want max uint64", u)
+	}
+}
+
+func TestJSONContentLength(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	_ = response.JSON(w, http.StatusOK, map[string]string{"key": "value"})
+
+	if got, want := w.Header().Get("Content-Length"), strconv.Itoa(w.Body.Len()); got != want {
+		t.Errorf("Content-Length = %q, want %q", got, want)
+	}
+}
+
+func TestJSONHijackPrefix(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       any
+		wantPrefix bool
+	}{
+		{name: "array", data: []int{1, 2}, wantPrefix: true},
+		{name: "object", data: map[string]int{"id": 1}, wantPrefix: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			_ = response.JSON(w, http.StatusOK, tt.data, response.HijackPrefix())
+
+			hasPrefix := strings.HasPrefix(w.Body.String(), ")]}',\n")
+			if hasPrefix != tt.wantPrefix {
+				t.Errorf("prefix present = %v, want %v (body %q)", hasPrefix, tt.wantPrefix, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestJSONWithDefaults(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := response.WithDefaults(rec, response.HijackPrefix())
+
+	_ = response.JSON(w, http.StatusOK, []string{"a"})
+
+	if !strings.HasPrefix(rec.Body.String(), ")]}',\n") {
+		t.Errorf("Expected default prefix option to apply, got %q", rec.Body.String())
+	}
+}
+
+func TestBatchWriter(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	bw := response.NewBatchWriter(w)
+	if err := bw.WritePart("1", http.StatusOK, map[string]int{"id": 1}); err != nil {
+		t.Fatalf("WritePart() returned error: %v", err)
+	}
+	if err := bw.WriteError("2", apierr.NewError(http.StatusNotFound, "not_found", "item not found")); err != nil {
+		t.Fatalf("WriteError() returned error: %v", err)
+	}
+	if err := bw.Close(); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q, want multipart/mixed", w.Header().Get("Content-Type"))
+	}
+
+	mr := multipart.NewReader(w.Body, params["boundary"])
+	wantStatus := []int{http.StatusOK, http.StatusNotFound}
+	for i, want := range wantStatus {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatalf("Part %d: %v", i, err)
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(part), nil)
+		if err != nil {
+			t.Fatalf("Part %d: failed to parse embedded response: %v", i, err)
+		}
+		if resp.StatusCode != want {
+			t.Errorf("Part %d: status = %d, want %d", i, resp.StatusCode, want)
+		}
+		_ = resp.Body.Close()
+	}
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Errorf("Expected exactly two parts, got err=%v", err)
+	}
+}
+
+func TestJSONReturnErrors(t *testing.T) {
+	t.Run("encode error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		err := response.JSON(w, http.StatusOK, map[string]any{"ch": make(chan int)}, response.ReturnErrors())
+		if err == nil {
+			t.Fatal("Expected encode error, got nil")
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("Expected nothing written, got %q", w.Body.String())
+		}
+	})
+
+	t.Run("write error", func(t *testing.T) {
+		// The header is already sent, so a returned error would only make
+		// Public write a second response.
+		w := &failingResponseWriter{}
+		if err := response.JSON(w, http.StatusOK, map[string]string{"key": "value"}, response.ReturnErrors()); err != nil {
+			t.Errorf("Expected write error not to be returned, got %v", err)
+		}
+		if !w.headerWritten {
+			t.Error("Expected header to be written")
+		}
+	})
+
+	t.Run("mapped by Public", func(t *testing.T) {
+		handler := middleware.Public(func(w http.ResponseWriter, _ *http.Request) error {
+			return response.JSON(w, http.StatusOK, make(chan int), response.ReturnErrors())
+		})
+
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+		var result apierr.APIError
+		if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if result.Type != "internal" {
+			t.Errorf("Expected type=internal, got %q", result.Type)
+		}
+	})
+}
+
+func TestJSONEncodeFailure(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []response.Option
+		wantStatus int
+		wantType   string
+	}{
+		{name: "default", wantStatus: http.StatusInternalServerError, wantType: "internal"},
+		{name: "configured", opts: []response.Option{response.EncodeFailure(http.StatusBadGateway, apierr.NewError(http.StatusBadGateway, "encoding", "could not render"))}, wantStatus: http.StatusBadGateway, wantType: "encoding"},
+		{name: "unencodable fallback", opts: []response.Option{response.EncodeFailure(http.StatusBadGateway, make(chan int))}, wantStatus: http.StatusInternalServerError, wantType: "internal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if err := response.JSON(w, http.StatusOK, make(chan int), tt.opts...); err != nil {
+				t.Fatalf("Expected nil error, got %v", err)
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Expected JSON content type, got %q", ct)
+			}
+			var result apierr.APIError
+			if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if result.Type != tt.wantType {
+				t.Errorf("Expected type %q, got %q", tt.wantType, result.Type)
+			}
+		})
+	}
+}
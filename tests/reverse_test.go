@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/piheta/apicore/response"
+	"github.com/piheta/apicore/router"
+)
+
+func TestRouter_URL(t *testing.T) {
+	ok := func(w http.ResponseWriter, _ *http.Request) error {
+		return response.JSON(w, http.StatusOK, "ok")
+	}
+
+	api := router.New()
+	api.Handle(http.MethodGet, "/{$}", ok).Name("home")
+	api.Handle(http.MethodGet, "/files/{path...}", ok).Name("file")
+
+	users := router.New()
+	users.Handle(http.MethodGet, "/{id}", ok).Name("user.get")
+	api.Mount("/v1/users", users)
+	api.Host("{tenant}.example.com").Handle(http.MethodGet, "/dashboard", ok).Name("tenant.dashboard")
+
+	tests := []struct {
+		name        string
+		route       string
+		pairs       []any
+		expectedURL string
+		expectError bool
+	}{
+		{name: "mounted route", route: "user.get", pairs: []any{"id", 42}, expectedURL: "/v1/users/42"},
+		{name: "escaped value", route: "user.get", pairs: []any{"id", "a b/c"}, expectedURL: "/v1/users/a%20b%2Fc"},
+		{name: "extra pairs become query", route: "user.get", pairs: []any{"id", 7, "expand", "orders"}, expectedURL: "/v1/users/7?expand=orders"},
+		{name: "rest wildcard keeps slashes", route: "file", pairs: []any{"path", "docs/read me.txt"}, expectedURL: "/files/docs/read%20me.txt"},
+		{name: "end anchor", route: "home", expectedURL: "/"},
+		{name: "host route", route: "tenant.dashboard", pairs: []any{"tenant", "acme"}, expectedURL: "/dashboard"},
+		{name: "missing parameter", route: "user.get", expectError: true},
+		{name: "odd pairs", route: "user.get", pairs: []any{"id"}, expectError: true},
+		{name: "unknown route", route: "nope", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := api.URL(tt.route, tt.pairs...)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("URL failed: %v", err)
+			}
+			if got != tt.expectedURL {
+				t.Errorf("Expected URL %q, got %q", tt.expectedURL, got)
+			}
+		})
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Host = "api.example.com"
+	r.TLS = &tls.ConnectionState{}
+	if got, _ := api.AbsoluteURL(r, "user.get", "id", 42); got != "https://api.example.com/v1/users/42" {
+		t.Errorf("Expected absolute URL from request host, got %q", got)
+	}
+	if got, _ := api.AbsoluteURL(r, "tenant.dashboard", "tenant", "acme"); got != "https://acme.example.com/dashboard" {
+		t.Errorf("Expected absolute URL from route host, got %q", got)
+	}
+}
+
+func TestCreated(t *testing.T) {
+	w := httptest.NewRecorder()
+	if err := response.Created(w, "/v1/users/42", map[string]int{"id": 42}); err != nil {
+		t.Fatalf("Created failed: %v", err)
+	}
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	if got := w.Header().Get("Location"); got != "/v1/users/42" {
+		t.Errorf("Expected Location /v1/users/42, got %q", got)
+	}
+}
@@ -0,0 +1,477 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/response"
+	"github.com/piheta/apicore/router"
+)
+
+func headerMiddleware(name string) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Middleware", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestJoinPattern(t *testing.T) {
+	tests := []struct {
+		prefix, pattern, want string
+	}{
+		{prefix: "/admin", pattern: "GET /users", want: "GET /admin/users"},
+		{prefix: "/admin", pattern: "/users/{id}", want: "/admin/users/{id}"},
+		{prefix: "/v1", pattern: "POST api.example.com/items", want: "POST api.example.com/v1/items"},
+		{prefix: "", pattern: "GET /", want: "GET /"},
+	}
+
+	for _, tt := range tests {
+		if got := router.JoinPattern(tt.prefix, tt.pattern); got != tt.want {
+			t.Errorf("JoinPattern(%q, %q) = %q, want %q", tt.prefix, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestGroup_ScopedMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	admin := router.NewGroup(mux, "/admin", headerMiddleware("auth"))
+	admin.Use(headerMiddleware("audit"))
+	admin.Handle("GET /users", ok)
+
+	public := router.NewGroup(mux, "/public", headerMiddleware("ratelimit"))
+	public.Handle("GET /items", ok)
+	public.Group("/v2", headerMiddleware("v2")).Handle("GET /items", ok)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "/admin/users", want: []string{"auth", "audit"}},
+		{path: "/public/items", want: []string{"ratelimit"}},
+		{path: "/public/v2/items", want: []string{"ratelimit", "v2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+			got := w.Header().Values("X-Middleware")
+			if len(got) != len(tt.want) {
+				t.Fatalf("Middleware = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Middleware = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAutoMethods(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, _ *http.Request) {
+		_ = response.JSON(w, http.StatusOK, map[string]string{"name": "ann"})
+	})
+	mux.HandleFunc("POST /users", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("OPTIONS /custom", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := router.AutoMethods(mux)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantAllow  string
+	}{
+		{name: "options", method: http.MethodOptions, path: "/users", wantStatus: http.StatusNoContent, wantAllow: "GET, HEAD, POST, OPTIONS"},
+		{name: "explicit options", method: http.MethodOptions, path: "/custom", wantStatus: http.StatusTeapot},
+		{name: "unknown path", method: http.MethodOptions, path: "/missing", wantStatus: http.StatusNotFound},
+		{name: "method not allowed", method: http.MethodDelete, path: "/users", wantStatus: http.StatusMethodNotAllowed, wantAllow: "GET, HEAD, POST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if got := w.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Expected Allow %q, got %q", tt.wantAllow, got)
+			}
+		})
+	}
+}
+
+func TestAutoMethods_Head(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, _ *http.Request) {
+		if err := response.JSON(w, http.StatusOK, map[string]string{"name": "ann"}, response.ReturnErrors()); err != nil {
+			t.Errorf("Expected write to succeed on HEAD, got %v", err)
+		}
+	})
+
+	server := httptest.NewServer(router.AutoMethods(mux))
+	defer server.Close()
+
+	get, err := http.Get(server.URL + "/users")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	get.Body.Close()
+
+	head, err := http.Head(server.URL + "/users")
+	if err != nil {
+		t.Fatalf("HEAD failed: %v", err)
+	}
+	head.Body.Close()
+
+	if head.StatusCode != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, head.StatusCode)
+	}
+	if head.ContentLength != get.ContentLength || head.ContentLength <= 0 {
+		t.Errorf("Expected HEAD Content-Length %d, got %d", get.ContentLength, head.ContentLength)
+	}
+}
+
+func TestRouter_Handle(t *testing.T) {
+	var route string
+	capture := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			route = middleware.GetRoutePattern(r.Context())
+		})
+	}
+
+	r := router.New(capture)
+	r.Use(headerMiddleware("global"))
+	r.Handle(http.MethodGet, "/users/{id}", func(w http.ResponseWriter, r *http.Request) error {
+		return response.JSON(w, http.StatusOK, map[string]string{"id": r.PathValue("id")})
+	})
+	r.Handle(http.MethodPost, "/users", func(w http.ResponseWriter, _ *http.Request) error {
+		return apierr.NewError(http.StatusForbidden, "forbidden", "nope")
+	}, headerMiddleware("auth"))
+
+	tests := []struct {
+		method, path   string
+		expectedStatus int
+		expectedRoute  string
+		expectedMws    []string
+	}{
+		{method: http.MethodGet, path: "/users/7", expectedStatus: http.StatusOK, expectedRoute: "GET /users/{id}", expectedMws: []string{"global"}},
+		{method: http.MethodPost, path: "/users", expectedStatus: http.StatusForbidden, expectedRoute: "POST /users", expectedMws: []string{"global", "auth"}},
+		{method: http.MethodGet, path: "/missing", expectedStatus: http.StatusNotFound, expectedRoute: "", expectedMws: []string{"global"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			route = ""
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if route != tt.expectedRoute {
+				t.Errorf("Expected route %q, got %q", tt.expectedRoute, route)
+			}
+			if got := w.Header().Values("X-Middleware"); !slices.Equal(got, tt.expectedMws) {
+				t.Errorf("Expected middleware %v, got %v", tt.expectedMws, got)
+			}
+		})
+	}
+
+	want := []string{"GET /users/{id}", "POST /users"}
+	if got := routeStrings(r.Routes()); !slices.Equal(got, want) {
+		t.Errorf("Expected routes %v, got %v", want, got)
+	}
+}
+
+func TestRouter_Mount(t *testing.T) {
+	var route string
+	capture := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			route = middleware.GetRoutePattern(r.Context())
+		})
+	}
+	ok := func(w http.ResponseWriter, r *http.Request) error {
+		return response.JSON(w, http.StatusOK, map[string]string{"id": r.PathValue("id")})
+	}
+
+	users := router.New(headerMiddleware("users"))
+	users.Handle(http.MethodGet, "/{id}", ok)
+
+	settings := router.New(headerMiddleware("settings"))
+	settings.Handle(http.MethodGet, "/", ok)
+	users.Mount("/{id}/settings", settings)
+
+	api := router.New(capture, headerMiddleware("api"))
+	api.Mount("/v1/users/", users)
+	users.Handle(http.MethodDelete, "/{id}", ok, headerMiddleware("admin"))
+
+	tests := []struct {
+		method, path   string
+		expectedStatus int
+		expectedRoute  string
+		expectedMws    []string
+	}{
+		{method: http.MethodGet, path: "/v1/users/7", expectedStatus: http.StatusOK, expectedRoute: "GET /v1/users/{id}", expectedMws: []string{"api", "users"}},
+		{method: http.MethodDelete, path: "/v1/users/7", expectedStatus: http.StatusOK, expectedRoute: "DELETE /v1/users/{id}", expectedMws: []string{"api", "users", "admin"}},
+		{method: http.MethodGet, path: "/v1/users/7/settings/", expectedStatus: http.StatusOK, expectedRoute: "GET /v1/users/{id}/settings/", expectedMws: []string{"api", "users", "settings"}},
+		{method: http.MethodGet, path: "/7", expectedStatus: http.StatusNotFound, expectedRoute: "", expectedMws: []string{"api"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			route = ""
+			w := httptest.NewRecorder()
+			api.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if route != tt.expectedRoute {
+				t.Errorf("Expected route %q, got %q", tt.expectedRoute, route)
+			}
+			if got := w.Header().Values("X-Middleware"); !slices.Equal(got, tt.expectedMws) {
+				t.Errorf("Expected middleware %v, got %v", tt.expectedMws, got)
+			}
+		})
+	}
+
+	want := []string{"GET /v1/users/{id}", "GET /v1/users/{id}/settings/", "DELETE /v1/users/{id}"}
+	if got := routeStrings(api.Routes()); !slices.Equal(got, want) {
+		t.Errorf("Expected routes %v, got %v", want, got)
+	}
+}
+
+func routeStrings(routes []router.Route) []string {
+	names := make([]string, len(routes))
+	for i, route := range routes {
+		names[i] = route.String()
+	}
+	return names
+}
+
+type userHandler struct{}
+
+func (userHandler) Get(w http.ResponseWriter, _ *http.Request) error {
+	return response.JSON(w, http.StatusOK, "user")
+}
+
+func TestRouter_Routes(t *testing.T) {
+	users := router.New(middleware.RequestID)
+	users.Handle(http.MethodGet, "/{id}", userHandler{}.Get, middleware.MaxBody(1024))
+
+	api := router.New(middleware.Recover)
+	api.Mount("/users", users)
+	api.HandleHTTP(http.MethodGet, "/debug/routes", api.RoutesHandler())
+
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/routes", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var got []router.Route
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode routes: %v", err)
+	}
+	want := []router.Route{
+		{
+			Method:     "GET",
+			Pattern:    "/users/{id}",
+			Middleware: []string{"middleware.Recover", "middleware.RequestID", "middleware.MaxBody"},
+			Handler:    "tests.userHandler.Get",
+		},
+		{
+			Method:     "GET",
+			Pattern:    "/debug/routes",
+			Middleware: []string{"middleware.Recover"},
+			Handler:    "middleware.Public",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected routes %+v, got %+v", want, got)
+	}
+}
+
+func TestRouter_NotFoundMethodNotAllowed(t *testing.T) {
+	ok := func(w http.ResponseWriter, _ *http.Request) error {
+		return response.JSON(w, http.StatusOK, "ok")
+	}
+
+	r := router.New(headerMiddleware("logger"))
+	r.Handle(http.MethodGet, "/users", ok)
+	r.Handle(http.MethodPost, "/users", ok)
+
+	custom := router.New()
+	custom.Handle(http.MethodGet, "/users", ok)
+	custom.NotFound(func(http.ResponseWriter, *http.Request) error {
+		return apierr.NewError(http.StatusNotFound, "no_such_endpoint", "see https://docs.example.com")
+	})
+
+	tests := []struct {
+		name           string
+		router         *router.Router
+		method, path   string
+		expectedStatus int
+		expectedType   string
+		expectedAllow  string
+	}{
+		{name: "not found", router: r, method: http.MethodGet, path: "/missing", expectedStatus: http.StatusNotFound, expectedType: "not_found"},
+		{name: "method not allowed", router: r, method: http.MethodDelete, path: "/users", expectedStatus: http.StatusMethodNotAllowed, expectedType: "method_not_allowed", expectedAllow: "GET, HEAD, POST, OPTIONS"},
+		{name: "custom not found", router: custom, method: http.MethodGet, path: "/missing", expectedStatus: http.StatusNotFound, expectedType: "no_such_endpoint"},
+		{name: "default method not allowed", router: custom, method: http.MethodPut, path: "/users", expectedStatus: http.StatusMethodNotAllowed, expectedType: "method_not_allowed", expectedAllow: "GET, HEAD, OPTIONS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if errType := decodeErrorType(t, w); errType != tt.expectedType {
+				t.Errorf("Expected type %q, got %q", tt.expectedType, errType)
+			}
+			if got := w.Header().Get("Allow"); got != tt.expectedAllow {
+				t.Errorf("Expected Allow %q, got %q", tt.expectedAllow, got)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if got := w.Header().Get("X-Middleware"); got != "logger" {
+		t.Errorf("Expected 404 to pass through router middleware, got %q", got)
+	}
+}
+
+func TestRouter_Host(t *testing.T) {
+	var route string
+	capture := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			route = middleware.GetRoutePattern(r.Context())
+		})
+	}
+	reply := func(name string) middleware.APIFunc {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			return response.JSON(w, http.StatusOK, name+":"+r.PathValue("tenant"))
+		}
+	}
+
+	api := router.New(capture)
+	api.Handle(http.MethodGet, "/health", reply("root"))
+	api.Host("admin.example.com", headerMiddleware("staff")).Handle(http.MethodGet, "/users", reply("admin"))
+	api.Host("{tenant}.example.com", headerMiddleware("tenant")).Handle(http.MethodGet, "/users", reply("tenant"))
+
+	tests := []struct {
+		host, path     string
+		expectedStatus int
+		expectedBody   string
+		expectedRoute  string
+		expectedMws    []string
+	}{
+		{host: "admin.example.com", path: "/users", expectedStatus: http.StatusOK, expectedBody: `"admin:"`, expectedRoute: "GET admin.example.com/users", expectedMws: []string{"staff"}},
+		{host: "acme.example.com:8080", path: "/users", expectedStatus: http.StatusOK, expectedBody: `"tenant:acme"`, expectedRoute: "GET {tenant}.example.com/users", expectedMws: []string{"tenant"}},
+		{host: "ACME.example.com", path: "/users", expectedStatus: http.StatusOK, expectedBody: `"tenant:acme"`, expectedRoute: "GET {tenant}.example.com/users", expectedMws: []string{"tenant"}},
+		{host: "acme.example.com", path: "/health", expectedStatus: http.StatusNotFound, expectedMws: []string{"tenant"}},
+		{host: "a.b.example.com", path: "/users", expectedStatus: http.StatusNotFound},
+		{host: "example.com", path: "/health", expectedStatus: http.StatusOK, expectedBody: `"root:"`, expectedRoute: "GET /health"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host+tt.path, func(t *testing.T) {
+			route = ""
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Host = tt.host
+			w := httptest.NewRecorder()
+			api.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedBody != "" && strings.TrimSpace(w.Body.String()) != tt.expectedBody {
+				t.Errorf("Expected body %s, got %s", tt.expectedBody, w.Body)
+			}
+			if route != tt.expectedRoute {
+				t.Errorf("Expected route %q, got %q", tt.expectedRoute, route)
+			}
+			if got := w.Header().Values("X-Middleware"); !slices.Equal(got, tt.expectedMws) {
+				t.Errorf("Expected middleware %v, got %v", tt.expectedMws, got)
+			}
+		})
+	}
+
+	want := []string{"GET /health", "GET admin.example.com/users", "GET {tenant}.example.com/users"}
+	if got := routeStrings(api.Routes()); !slices.Equal(got, want) {
+		t.Errorf("Expected routes %v, got %v", want, got)
+	}
+}
+
+func TestRouter_Options(t *testing.T) {
+	ok := func(w http.ResponseWriter, _ *http.Request) error {
+		return response.JSON(w, http.StatusOK, "ok")
+	}
+
+	r := router.New()
+	r.Handle(http.MethodGet, "/items/{id}", ok)
+	r.Handle(http.MethodDelete, "/items/{id}", ok)
+	r.Handle("PURGE", "/items/{id}", ok)
+	r.Handle(http.MethodPost, "/items", ok)
+	r.HandleHTTP(http.MethodOptions, "/custom", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Allow", "OPTIONS")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		method, path   string
+		expectedStatus int
+		expectedAllow  string
+	}{
+		{method: http.MethodOptions, path: "/items/7", expectedStatus: http.StatusNoContent, expectedAllow: "GET, HEAD, DELETE, PURGE, OPTIONS"},
+		{method: http.MethodOptions, path: "/items", expectedStatus: http.StatusNoContent, expectedAllow: "POST, OPTIONS"},
+		{method: http.MethodPut, path: "/items/7", expectedStatus: http.StatusMethodNotAllowed, expectedAllow: "GET, HEAD, DELETE, PURGE, OPTIONS"},
+		{method: http.MethodOptions, path: "/custom", expectedStatus: http.StatusOK, expectedAllow: "OPTIONS"},
+		{method: http.MethodOptions, path: "/missing", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if got := w.Header().Get("Allow"); got != tt.expectedAllow {
+				t.Errorf("Expected Allow %q, got %q", tt.expectedAllow, got)
+			}
+		})
+	}
+}
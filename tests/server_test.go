@@ -0,0 +1,353 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/healthcheck"
+	"github.com/piheta/apicore/response"
+	"github.com/piheta/apicore/server"
+)
+
+func TestServer_GracefulStop(t *testing.T) {
+	srv := server.New(server.Options{Addr: "127.0.0.1:0"})
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	srv.Handle(http.MethodGet, "/slow", func(w http.ResponseWriter, _ *http.Request) error {
+		close(entered)
+		<-release
+		return response.JSON(w, http.StatusOK, "done")
+	})
+
+	var mu sync.Mutex
+	var calls []string
+	hook := func(name string, err error) func(context.Context) error {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, name)
+			return err
+		}
+	}
+	srv.OnShutdown(hook("db", nil))
+	srv.OnShutdown(hook("cache", errors.New("cache close failed")))
+
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := srv.Start(); err == nil {
+		t.Error("Expected error starting twice")
+	}
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(fmt.Sprintf("http://%s/slow", srv.Addr()))
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-entered
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- srv.Stop(context.Background()) }()
+
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned before the in-flight request finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if code := <-status; code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, code)
+	}
+	err := <-stopped
+	if err == nil || err.Error() != "cache close failed" {
+		t.Errorf("Expected hook error, got %v", err)
+	}
+	if want := []string{"cache", "db"}; !slices.Equal(calls, want) {
+		t.Errorf("Expected hooks %v, got %v", want, calls)
+	}
+}
+
+func TestServer_Run(t *testing.T) {
+	srv := server.New(server.Options{Addr: "127.0.0.1:0"})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- srv.Run(ctx) }()
+
+	deadline := time.Now().Add(time.Second)
+	for srv.Addr() == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if srv.Addr() == nil {
+		t.Fatal("Server did not start")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected clean shutdown, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+}
+
+// fakeCertManager serves a self-signed certificate and a canned ACME challenge.
+type fakeCertManager struct {
+	cert *tls.Certificate
+	mu   sync.Mutex
+	sni  []string
+}
+
+func newFakeCertManager(t *testing.T) *fakeCertManager {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     []string{"api.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeCertManager{cert: &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}}
+}
+
+func (m *fakeCertManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sni = append(m.sni, hello.ServerName)
+	return m.cert, nil
+}
+
+func (m *fakeCertManager) HTTPHandler(fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/.well-known/acme-challenge/") {
+			_, _ = w.Write([]byte("challenge"))
+			return
+		}
+		fallback.ServeHTTP(w, r)
+	})
+}
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestServer_CertManager(t *testing.T) {
+	certs := newFakeCertManager(t)
+	addr, redirectAddr := freeAddr(t), freeAddr(t)
+	_, port, _ := net.SplitHostPort(addr)
+	srv := server.New(server.Options{Addr: addr, RedirectAddr: redirectAddr, CertManager: certs})
+	srv.Handle(http.MethodGet, "/ping", func(w http.ResponseWriter, _ *http.Request) error {
+		return response.JSON(w, http.StatusOK, "pong")
+	})
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer srv.Stop(context.Background())
+
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{ServerName: "api.example.com", InsecureSkipVerify: true}},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get("https://" + addr + "/ping")
+	if err != nil {
+		t.Fatalf("HTTPS request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	if !slices.Contains(certs.sni, "api.example.com") {
+		t.Errorf("Expected certificate lookup for api.example.com, got %v", certs.sni)
+	}
+
+	tests := []struct {
+		method, path     string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{method: http.MethodGet, path: "/ping?x=1", expectedStatus: http.StatusMovedPermanently, expectedLocation: "https://api.example.com:" + port + "/ping?x=1"},
+		{method: http.MethodPost, path: "/ping", expectedStatus: http.StatusPermanentRedirect, expectedLocation: "https://api.example.com:" + port + "/ping"},
+		{method: http.MethodGet, path: "/.well-known/acme-challenge/token", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, "http://"+redirectAddr+tt.path, nil)
+			req.Host = "api.example.com"
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("HTTP request failed: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if got := resp.Header.Get("Location"); got != tt.expectedLocation {
+				t.Errorf("Expected Location %q, got %q", tt.expectedLocation, got)
+			}
+		})
+	}
+}
+
+func TestServer_H2C(t *testing.T) {
+	srv := server.New(server.Options{Addr: "127.0.0.1:0", H2C: true})
+	srv.Handle(http.MethodGet, "/proto", func(w http.ResponseWriter, r *http.Request) error {
+		return response.JSON(w, http.StatusOK, r.Proto)
+	})
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer srv.Stop(context.Background())
+
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: &protocols}}
+
+	resp, err := client.Get(fmt.Sprintf("http://%s/proto", srv.Addr()))
+	if err != nil {
+		t.Fatalf("h2c request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Errorf("Expected HTTP/2, got %s", resp.Proto)
+	}
+}
+
+// fakeHTTP3 records how the Server drives an HTTP/3 server.
+type fakeHTTP3 struct {
+	addr    string
+	handler http.Handler
+	stop    chan struct{}
+}
+
+func (f *fakeHTTP3) ListenAndServe() error {
+	<-f.stop
+	return http.ErrServerClosed
+}
+
+func (f *fakeHTTP3) Shutdown(context.Context) error {
+	close(f.stop)
+	return nil
+}
+
+func TestServer_HTTP3(t *testing.T) {
+	h3 := &fakeHTTP3{stop: make(chan struct{})}
+	srv := server.New(server.Options{
+		Addr: "127.0.0.1:0",
+		HTTP3: func(addr string, h http.Handler, _ *tls.Config) server.HTTP3Server {
+			h3.addr, h3.handler = addr, h
+			return h3
+		},
+	})
+	srv.Handle(http.MethodGet, "/ping", func(w http.ResponseWriter, _ *http.Request) error {
+		return response.JSON(w, http.StatusOK, "pong")
+	})
+	if h3.addr != "127.0.0.1:0" || h3.handler == nil {
+		t.Fatalf("Expected HTTP/3 server for the same address and handler, got %q", h3.addr)
+	}
+
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	resp, err := http.Get(fmt.Sprintf("http://%s/ping", srv.Addr()))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("Alt-Svc"); got != `h3=":0"; ma=86400` {
+		t.Errorf("Expected Alt-Svc header, got %q", got)
+	}
+
+	if err := srv.Stop(context.Background()); err != nil {
+		t.Errorf("Stop failed: %v", err)
+	}
+	select {
+	case <-h3.stop:
+	default:
+		t.Error("Expected HTTP/3 server to be shut down")
+	}
+}
+
+func TestServer_DrainReadiness(t *testing.T) {
+	health := healthcheck.New(healthcheck.Options{})
+	srv := server.New(server.Options{Addr: "127.0.0.1:0", Health: health, DrainDelay: 150 * time.Millisecond})
+	srv.HandleHTTP(http.MethodGet, "/readyz", health.ReadinessHandler())
+
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	readyz := func() (int, healthcheck.Status) {
+		resp, err := http.Get(fmt.Sprintf("http://%s/readyz", srv.Addr()))
+		if err != nil {
+			return 0, ""
+		}
+		defer resp.Body.Close()
+		var report healthcheck.Report
+		_ = json.NewDecoder(resp.Body).Decode(&report)
+		return resp.StatusCode, report.Status
+	}
+
+	if code, status := readyz(); code != http.StatusOK || status != healthcheck.StatusUp {
+		t.Fatalf("Expected ready before Stop, got %d %q", code, status)
+	}
+
+	start := time.Now()
+	stopped := make(chan error, 1)
+	go func() { stopped <- srv.Stop(context.Background()) }()
+	time.Sleep(30 * time.Millisecond)
+
+	// Still serving during the drain delay, but reporting not ready.
+	if code, status := readyz(); code != http.StatusServiceUnavailable || status != healthcheck.StatusDraining {
+		t.Errorf("Expected draining readiness, got %d %q", code, status)
+	}
+
+	if err := <-stopped; err != nil {
+		t.Errorf("Stop failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("Expected Stop to wait for the drain delay, took %v", elapsed)
+	}
+	if code, _ := readyz(); code != 0 {
+		t.Errorf("Expected connections to be refused after Stop, got %d", code)
+	}
+}
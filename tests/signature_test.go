@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/middleware"
+)
+
+func TestVerifySignature(t *testing.T) {
+	keys := func(_ context.Context, keyID string) ([]byte, error) {
+		switch keyID {
+		case "partner-1":
+			return []byte("s3cret"), nil
+		case "broken":
+			return nil, errors.New("key store down")
+		}
+		return nil, nil
+	}
+
+	var gotKeyID, gotBody string
+	handler := middleware.VerifySignature(middleware.SignatureOptions{
+		Keys:            keys,
+		RequiredHeaders: []string{"Content-Type"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKeyID = middleware.GetSignatureKeyID(r.Context())
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/v1/orders?b=2&a=1&a=0", strings.NewReader(`{"qty":1}`))
+		r.Header.Set("Content-Type", "application/json")
+		return r
+	}
+
+	tests := []struct {
+		name       string
+		prepare    func(r *http.Request)
+		wantStatus int
+		wantType   string
+	}{
+		{
+			name:       "valid",
+			prepare:    func(r *http.Request) { _ = middleware.SignRequest(r, "partner-1", []byte("s3cret"), "content-type") },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "tampered body",
+			prepare: func(r *http.Request) {
+				_ = middleware.SignRequest(r, "partner-1", []byte("s3cret"), "content-type")
+				r.Body = io.NopCloser(strings.NewReader(`{"qty":100}`))
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantType:   "invalid_signature",
+		},
+		{
+			name: "tampered query",
+			prepare: func(r *http.Request) {
+				_ = middleware.SignRequest(r, "partner-1", []byte("s3cret"), "content-type")
+				r.URL.RawQuery = "a=1"
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantType:   "invalid_signature",
+		},
+		{
+			name:       "required header unsigned",
+			prepare:    func(r *http.Request) { _ = middleware.SignRequest(r, "partner-1", []byte("s3cret")) },
+			wantStatus: http.StatusUnauthorized,
+			wantType:   "invalid_signature",
+		},
+		{
+			name:       "wrong secret",
+			prepare:    func(r *http.Request) { _ = middleware.SignRequest(r, "partner-1", []byte("guess"), "content-type") },
+			wantStatus: http.StatusUnauthorized,
+			wantType:   "invalid_signature",
+		},
+		{
+			name:       "unknown key",
+			prepare:    func(r *http.Request) { _ = middleware.SignRequest(r, "nobody", []byte("s3cret"), "content-type") },
+			wantStatus: http.StatusUnauthorized,
+			wantType:   "invalid_signature",
+		},
+		{
+			name: "stale",
+			prepare: func(r *http.Request) {
+				r.Header.Set(middleware.SignatureDateHeader, time.Now().Add(-time.Hour).UTC().Format("20060102T150405Z"))
+				_ = middleware.SignRequest(r, "partner-1", []byte("s3cret"), "content-type")
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantType:   "stale_signature",
+		},
+		{
+			name:       "key lookup failure",
+			prepare:    func(r *http.Request) { _ = middleware.SignRequest(r, "broken", []byte("s3cret"), "content-type") },
+			wantStatus: http.StatusInternalServerError,
+			wantType:   "internal",
+		},
+		{
+			name:       "missing",
+			prepare:    func(*http.Request) {},
+			wantStatus: http.StatusUnauthorized,
+			wantType:   "invalid_signature",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotKeyID, gotBody = "", ""
+			r := newRequest()
+			tt.prepare(r)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if gotKeyID != "partner-1" || gotBody != `{"qty":1}` {
+					t.Errorf("Expected key ID and restored body, got %q %q", gotKeyID, gotBody)
+				}
+				return
+			}
+			var result apierr.APIError
+			if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if result.Type != tt.wantType {
+				t.Errorf("Expected type %q, got %q", tt.wantType, result.Type)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/bind"
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/response"
+)
+
+func TestBindSlice(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+		expectedMsg  map[string]any
+	}{
+		{name: "all valid", body: `[{"name":"ada","email":"ada@example.com"},{"name":"bob","email":"bob@example.com"}]`, expectedCode: http.StatusOK},
+		{
+			name:         "index keyed errors",
+			body:         `[{"name":"ada","email":"ada@example.com"},{"name":"b"},{"name":1}]`,
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedMsg: map[string]any{
+				"1": map[string]any{"name": "min", "email": "required"},
+				"2": "invalid JSON format",
+			},
+		},
+		{name: "not an array", body: `{"name":"ada"}`, expectedCode: http.StatusBadRequest},
+	}
+
+	handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+		if _, err := bind.Slice[createUserRequest](r); err != nil {
+			return err
+		}
+		return response.Status(w, http.StatusOK)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/bulk", strings.NewReader(tt.body)))
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.expectedMsg == nil {
+				return
+			}
+			var body struct {
+				Msg map[string]any `json:"msg"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			got, _ := json.Marshal(body.Msg)
+			expected, _ := json.Marshal(tt.expectedMsg)
+			if string(got) != string(expected) {
+				t.Errorf("Expected msg %s, got %s", expected, got)
+			}
+		})
+	}
+}
+
+func TestBindSlice_MultiStatus(t *testing.T) {
+	handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+		items, err := bind.Slice[createUserRequest](r)
+		var itemErrs bind.ItemErrors
+		if err != nil && !errors.As(err, &itemErrs) {
+			return err
+		}
+
+		results := make([]response.ItemStatus, len(items))
+		for i, item := range items {
+			if apiErr := itemErrs.APIError(i); apiErr != nil {
+				results[i] = response.ItemStatus{Index: i, Status: apiErr.StatusCode, Body: apiErr}
+				continue
+			}
+			results[i] = response.ItemStatus{Index: i, Status: http.StatusCreated, Body: item.Name}
+		}
+		return response.MultiStatus(w, results)
+	})
+
+	body := `[{"name":"ada","email":"ada@example.com"},{"name":"bob","email":"not-an-email"}]`
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/bulk", strings.NewReader(body)))
+
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("Expected status %d, got %d", http.StatusMultiStatus, w.Code)
+	}
+
+	var resp struct {
+		Results []struct {
+			Index  int `json:"index"`
+			Status int `json:"status"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Results) != 2 || resp.Results[0].Status != http.StatusCreated || resp.Results[1].Status != http.StatusUnprocessableEntity {
+		t.Errorf("Unexpected results %+v", resp.Results)
+	}
+}
+
+func TestBindSlice_CountsErrorOnce(t *testing.T) {
+	handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+		if _, err := bind.Slice[createUserRequest](r); err != nil {
+			return err
+		}
+		return response.Status(w, http.StatusOK)
+	})
+	count := func() int64 {
+		if v, ok := apierr.ErrorTypes.Get("validation").(*expvar.Int); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	before := count()
+
+	body := `[{"name":"b"},{"email":"x"},{}]`
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/bulk", strings.NewReader(body)))
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	if got := count() - before; got != 1 {
+		t.Errorf("Expected one validation error counted, got %d", got)
+	}
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/router"
+)
+
+func TestSLO(t *testing.T) {
+	var mu sync.Mutex
+	var burns []string
+	slo := middleware.NewSLO(middleware.SLOOptions{
+		Objective:   0.99,
+		MinRequests: 10,
+		Slow:        20 * time.Millisecond,
+		OnBurn: func(route string, rate float64) {
+			mu.Lock()
+			defer mu.Unlock()
+			burns = append(burns, route)
+		},
+	})
+
+	rt := router.New(slo.Middleware)
+	rt.HandleHTTP(http.MethodGet, "/ok", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	rt.HandleHTTP(http.MethodGet, "/flaky/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "fail" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	rt.HandleHTTP(http.MethodGet, "/slow", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(30 * time.Millisecond)
+	}))
+	rt.HandleHTTP(http.MethodGet, "/slo", slo.Handler())
+
+	serve := func(path string, n int) {
+		for range n {
+			rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}
+	}
+	serve("/ok", 20)
+	serve("/flaky/a", 8)
+	serve("/flaky/fail", 2)
+	serve("/flaky/fail", 2)
+	serve("/slow", 1)
+	serve("/missing", 1)
+
+	reports := make(map[string]middleware.SLOReport)
+	for _, rep := range slo.Report() {
+		reports[rep.Route] = rep
+	}
+
+	tests := []struct {
+		route    string
+		requests int64
+		errors   int64
+	}{
+		{"GET /ok", 20, 0},
+		{"GET /flaky/{id}", 12, 4},
+		{"GET /slow", 1, 1},
+		{"unmatched", 1, 0},
+	}
+	for _, tt := range tests {
+		rep := reports[tt.route]
+		if rep.Requests != tt.requests || rep.Errors != tt.errors {
+			t.Errorf("%s: expected %d requests and %d errors, got %+v", tt.route, tt.requests, tt.errors, rep)
+		}
+	}
+
+	if rep := reports["GET /ok"]; rep.SuccessRate != 1 || rep.BurnRate != 0 || rep.P99 > 5 {
+		t.Errorf("Expected healthy /ok window, got %+v", rep)
+	}
+	if rep := reports["GET /slow"]; rep.P50 < 25 {
+		t.Errorf("Expected /slow p50 of at least 25ms, got %v", rep.P50)
+	}
+
+	// The burn rate of /flaky crosses the threshold once it has 10 requests,
+	// and the hook fires once until it recovers.
+	mu.Lock()
+	if len(burns) != 1 || burns[0] != "GET /flaky/{id}" {
+		t.Errorf("Expected one burn alert for /flaky, got %v", burns)
+	}
+	mu.Unlock()
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slo", nil))
+	var body struct {
+		Objective float64                `json:"objective"`
+		Routes    []middleware.SLOReport `json:"routes"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Objective != 0.99 || len(body.Routes) != 4 {
+		t.Errorf("Expected /slo report, got %s (%v)", w.Body, err)
+	}
+
+	var _ expvar.Var = slo
+	if err := json.Unmarshal([]byte(slo.String()), &body); err != nil {
+		t.Errorf("Expected String to return JSON, got %v", err)
+	}
+}
+
+func TestSLO_WindowExpires(t *testing.T) {
+	slo := middleware.NewSLO(middleware.SLOOptions{Window: 60 * time.Millisecond})
+	handler := slo.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if reports := slo.Report(); len(reports) != 1 || reports[0].Errors != 1 {
+		t.Fatalf("Expected one failed request, got %+v", reports)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if reports := slo.Report(); len(reports) != 0 {
+		t.Errorf("Expected window to expire, got %+v", reports)
+	}
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+func TestSplit(t *testing.T) {
+	control := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("control"))
+	})
+	alternate := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("treatment"))
+	})
+
+	tests := []struct {
+		name    string
+		percent float64
+		want    string
+	}{
+		{name: "none", percent: 0, want: "control"},
+		{name: "all", percent: 100, want: "treatment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.Split(middleware.SplitOptions{
+				Experiment: "checkout",
+				Percent:    tt.percent,
+				Alternate:  alternate,
+			})(control)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if w.Body.String() != tt.want {
+				t.Errorf("Expected body %q, got %q", tt.want, w.Body.String())
+			}
+			if got := w.Header().Get(middleware.VariantHeader); got != "checkout="+tt.want {
+				t.Errorf("Expected variant header %q, got %q", "checkout="+tt.want, got)
+			}
+			if len(w.Result().Cookies()) != 1 {
+				t.Errorf("Expected a bucketing cookie to be set")
+			}
+		})
+	}
+}
+
+func TestSplit_Deterministic(t *testing.T) {
+	handler := middleware.Split(middleware.SplitOptions{
+		Experiment: "search",
+		Percent:    50,
+		Alternate: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("treatment"))
+		}),
+	})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("control"))
+	}))
+
+	serve := func(id string) string {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(&http.Cookie{Name: "bucket_id", Value: id})
+		handler.ServeHTTP(w, r)
+		if len(w.Result().Cookies()) != 0 {
+			t.Errorf("Expected existing bucketing cookie to be reused")
+		}
+		return w.Body.String()
+	}
+
+	treated := 0
+	for i := range 1000 {
+		id := "user-" + strconv.Itoa(i)
+		first := serve(id)
+		if serve(id) != first {
+			t.Fatalf("Expected %s to stay in variant %q", id, first)
+		}
+		if first == "treatment" {
+			treated++
+		}
+	}
+	if treated < 400 || treated > 600 {
+		t.Errorf("Expected roughly half of users in treatment, got %d/1000", treated)
+	}
+}
+
+func TestSplit_LogsVariant(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	handler := middleware.RequestLogger(middleware.Split(middleware.SplitOptions{
+		Experiment: "checkout",
+		Percent:    100,
+		Alternate:  http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}),
+	})(http.NotFoundHandler()))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !strings.Contains(buf.String(), "experiment=checkout variant=treatment") {
+		t.Errorf("Expected variant in log, got %q", buf.String())
+	}
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/piheta/apicore/router"
+)
+
+func TestStatic(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":                {Data: []byte("<html>app</html>")},
+		"assets/app.3f9a2c1e.js":    {Data: []byte("console.log(1)")},
+		"assets/index-BxT2k9Qa.css": {Data: []byte("body{}")},
+		"assets/bootstrap5.min.css": {Data: []byte("body{}")},
+		"favicon32x32.png":          {Data: []byte("png")},
+		"robots.txt":                {Data: []byte("User-agent: *")},
+		".env":                      {Data: []byte("SECRET=1")},
+		"docs/readme.md":            {Data: []byte("# docs")},
+	}
+
+	tests := []struct {
+		name        string
+		spa         bool
+		method      string
+		path        string
+		wantStatus  int
+		wantBody    string
+		wantCaching string
+	}{
+		{name: "root index", path: "/", wantStatus: http.StatusOK, wantBody: "<html>app</html>", wantCaching: "no-cache"},
+		{name: "hashed asset", path: "/assets/app.3f9a2c1e.js", wantStatus: http.StatusOK, wantBody: "console.log(1)", wantCaching: "public, max-age=31536000, immutable"},
+		{name: "base64 hashed asset", path: "/assets/index-BxT2k9Qa.css", wantStatus: http.StatusOK, wantCaching: "public, max-age=31536000, immutable"},
+		{name: "versioned name", path: "/assets/bootstrap5.min.css", wantStatus: http.StatusOK, wantCaching: "no-cache"},
+		{name: "sized name", path: "/favicon32x32.png", wantStatus: http.StatusOK, wantCaching: "no-cache"},
+		{name: "plain file", path: "/robots.txt", wantStatus: http.StatusOK, wantCaching: "no-cache"},
+		{name: "dotfile", path: "/.env", wantStatus: http.StatusNotFound},
+		{name: "traversal", path: "/../../etc/passwd", wantStatus: http.StatusNotFound},
+		{name: "directory without index", path: "/docs/", wantStatus: http.StatusNotFound},
+		{name: "unknown route", path: "/settings/profile", wantStatus: http.StatusNotFound},
+		{name: "spa route", spa: true, path: "/settings/profile", wantStatus: http.StatusOK, wantBody: "<html>app</html>", wantCaching: "no-cache"},
+		{name: "spa missing asset", spa: true, path: "/assets/missing.js", wantStatus: http.StatusNotFound},
+		{name: "spa dotfile", spa: true, path: "/.git", wantStatus: http.StatusNotFound},
+		{name: "post", method: http.MethodPost, path: "/", wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			handler := router.Static(fsys, router.StaticOptions{SPA: tt.spa})
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(method, "/", nil)
+			r.URL.Path = tt.path
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("Expected body %q, got %q", tt.wantBody, w.Body.String())
+			}
+			if got := w.Header().Get("Cache-Control"); tt.wantCaching != "" && got != tt.wantCaching {
+				t.Errorf("Expected Cache-Control %q, got %q", tt.wantCaching, got)
+			}
+		})
+	}
+}
+
+func TestStaticDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "hello.txt"), []byte("hello"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	handler := router.StaticDir(dir, router.StaticOptions{})
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{path: "/hello.txt", wantStatus: http.StatusOK},
+		{path: "/missing.txt", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.wantStatus, w.Code)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/bind"
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/response"
+)
+
+func TestBindStream(t *testing.T) {
+	valid := `{"name":"ada","email":"ada@example.com"}`
+
+	tests := []struct {
+		name          string
+		body          string
+		opts          []bind.Option
+		expectedCode  int
+		expectedSeen  int
+		expectedIndex string
+	}{
+		{name: "all items", body: "[" + strings.Repeat(valid+",", 99) + valid + "]", expectedCode: http.StatusOK, expectedSeen: 100},
+		{name: "empty array", body: `[]`, expectedCode: http.StatusOK},
+		{name: "invalid item", body: "[" + valid + `,{"name":"b"}]`, expectedCode: http.StatusUnprocessableEntity, expectedSeen: 1, expectedIndex: "1"},
+		{name: "handler error keeps status", body: "[" + valid + "," + valid + "," + `{"name":"taken","email":"t@example.com"}]`, expectedCode: http.StatusConflict, expectedSeen: 2, expectedIndex: "2"},
+		{name: "malformed item", body: "[" + valid + `,{"name":]`, expectedCode: http.StatusBadRequest, expectedSeen: 1, expectedIndex: "1"},
+		{name: "strict", body: `[{"name":"ada","email":"ada@example.com","x":1}]`, opts: []bind.Option{bind.Strict()}, expectedCode: http.StatusBadRequest, expectedIndex: "0"},
+		{name: "not an array", body: valid, expectedCode: http.StatusBadRequest},
+		{name: "trailing data", body: "[" + valid + "]{}", expectedCode: http.StatusBadRequest, expectedSeen: 1},
+		{name: "size limit", body: "[" + strings.Repeat(valid+",", 99) + valid + "]", opts: []bind.Option{bind.MaxBytes(1000)}, expectedCode: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := 0
+			handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+				err := bind.Stream(r, func(item createUserRequest) error {
+					if item.Name == "taken" {
+						return apierr.NewError(http.StatusConflict, "conflict", "name taken")
+					}
+					seen++
+					return nil
+				}, tt.opts...)
+				if err != nil {
+					return err
+				}
+				return response.Status(w, http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(tt.body))
+			req.ContentLength = -1
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.expectedCode != http.StatusRequestEntityTooLarge && seen != tt.expectedSeen {
+				t.Errorf("Expected %d items handled, got %d", tt.expectedSeen, seen)
+			}
+			if tt.expectedIndex != "" {
+				var body struct {
+					Msg map[string]any `json:"msg"`
+				}
+				_ = json.Unmarshal(w.Body.Bytes(), &body)
+				if _, ok := body.Msg[tt.expectedIndex]; !ok {
+					t.Errorf("Expected error keyed by index %s, got %s", tt.expectedIndex, w.Body.String())
+				}
+			}
+		})
+	}
+}
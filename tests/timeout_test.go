@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/response"
+)
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    middleware.APIFunc
+		wantStatus int
+	}{
+		{
+			name: "fast handler",
+			handler: func(w http.ResponseWriter, _ *http.Request) error {
+				return response.JSON(w, http.StatusCreated, map[string]string{"status": "ok"})
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "slow handler ignoring context",
+			handler: func(w http.ResponseWriter, _ *http.Request) error {
+				time.Sleep(50 * time.Millisecond)
+				return response.JSON(w, http.StatusOK, map[string]string{"status": "late"})
+			},
+			wantStatus: http.StatusGatewayTimeout,
+		},
+		{
+			name: "handler returning deadline error",
+			handler: func(_ http.ResponseWriter, r *http.Request) error {
+				<-r.Context().Done()
+				return r.Context().Err()
+			},
+			wantStatus: http.StatusGatewayTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.Timeout(10 * time.Millisecond)(middleware.Public(tt.handler))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/test", nil)
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+
+			var result map[string]any
+			dec := json.NewDecoder(w.Body)
+			if err := dec.Decode(&result); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if dec.More() {
+				t.Error("Expected a single response document")
+			}
+		})
+	}
+}
+
+func TestTimeout_Unwrap(t *testing.T) {
+	handler := middleware.Timeout(time.Second)(middleware.Public(func(w http.ResponseWriter, _ *http.Request) error {
+		return response.JSON(w, http.StatusOK, []string{"a"})
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(response.WithDefaults(rec, response.HijackPrefix()), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !strings.HasPrefix(rec.Body.String(), ")]}',\n") {
+		t.Errorf("Expected options set outside Timeout to apply, got %q", rec.Body.String())
+	}
+}
+
+func TestTimeout_FlushThenOverrun(t *testing.T) {
+	finished := make(chan error, 1)
+	handler := middleware.Timeout(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("partial"))
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Expected Flush to be a buffered no-op, got %v", err)
+		}
+		time.Sleep(60 * time.Millisecond)
+		finished <- http.NewResponseController(w).Flush()
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("Expected status %d, got %d", http.StatusGatewayTimeout, w.Code)
+	}
+	if w.Flushed || strings.Contains(w.Body.String(), "partial") {
+		t.Errorf("Expected only the 504 to reach the client, got flushed=%v body %q", w.Flushed, w.Body.String())
+	}
+	if err := <-finished; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Errorf("Expected Flush after the timeout to fail with ErrHandlerTimeout, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/piheta/apicore/metaerr"
+	"github.com/piheta/apicore/middleware"
+)
+
+func TestTrace(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	tests := []struct {
+		name        string
+		traceparent string
+		continued   bool
+		sampled     bool
+	}{
+		{"continues sampled trace", "00-" + traceID + "-00f067aa0ba902b7-01", true, true},
+		{"continues unsampled trace", "00-" + traceID + "-00f067aa0ba902b7-00", true, false},
+		{"accepts later versions", "01-" + traceID + "-00f067aa0ba902b7-01-extra", true, true},
+		{"starts trace without header", "", false, false},
+		{"rejects uppercase", "00-" + strings.ToUpper(traceID) + "-00f067aa0ba902b7-01", false, false},
+		{"rejects zero trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"rejects zero span ID", "00-" + traceID + "-0000000000000000-01", false, false},
+		{"rejects version ff", "ff-" + traceID + "-00f067aa0ba902b7-01", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sc middleware.SpanContext
+			var header string
+			handler := middleware.Trace(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				sc, _ = middleware.GetSpan(r.Context())
+				header = r.Header.Get("traceparent")
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.traceparent != "" {
+				r.Header.Set("traceparent", tt.traceparent)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if len(sc.TraceID) != 32 || len(sc.SpanID) != 16 {
+				t.Fatalf("Expected trace and span IDs, got %+v", sc)
+			}
+			if (sc.TraceID == traceID) != tt.continued {
+				t.Errorf("Expected continued=%v, got trace ID %q", tt.continued, sc.TraceID)
+			}
+			if sc.SpanID == "00f067aa0ba902b7" {
+				t.Errorf("Expected a new span ID, got the caller's")
+			}
+			if sc.Sampled != tt.sampled {
+				t.Errorf("Expected sampled=%v, got %v", tt.sampled, sc.Sampled)
+			}
+			if header != sc.Traceparent() {
+				t.Errorf("Expected forwarded traceparent %q, got %q", sc.Traceparent(), header)
+			}
+		})
+	}
+}
+
+func TestTrace_LogLines(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	handler := middleware.Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		middleware.Log(r.Context()).Info("handling")
+		w.WriteHeader(http.StatusOK)
+	}), middleware.RequestLoggerWith(middleware.LoggerOptions{Logger: logger}), middleware.InjectLogger(logger), middleware.Trace)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %q", buf.String())
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "trace_id=4bf92f3577b34da6a3ce929d0e0e4736") || !strings.Contains(line, "span_id=") {
+			t.Errorf("Expected trace_id and span_id in %q", line)
+		}
+	}
+}
+
+func TestTrace_NoSpan(t *testing.T) {
+	var buf bytes.Buffer
+	handler := middleware.RequestLoggerWith(middleware.LoggerOptions{Logger: slog.New(slog.NewTextHandler(&buf, nil))})(http.NotFoundHandler())
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if strings.Contains(buf.String(), "trace_id") {
+		t.Errorf("Expected no trace_id without tracing, got %q", buf.String())
+	}
+}
+
+func TestSetSpanExtractor(t *testing.T) {
+	type spanKey struct{}
+	middleware.SetSpanExtractor(func(ctx context.Context) (middleware.SpanContext, bool) {
+		sc, ok := ctx.Value(spanKey{}).(middleware.SpanContext)
+		return sc, ok
+	})
+	defer middleware.SetSpanExtractor(nil)
+
+	ctx := context.WithValue(context.Background(), spanKey{}, middleware.SpanContext{TraceID: "t1", SpanID: "s1"})
+	err := metaerr.WithContext(ctx, errors.New("queue full"))
+
+	got := metaerr.GetMetadataMap(err)
+	if got["trace_id"] == nil || got["span_id"] == nil {
+		t.Errorf("Expected trace metadata on error, got %v", got)
+	}
+	if err := metaerr.WithContext(context.Background(), errors.New("x")); metaerr.HasMetadata(err) {
+		t.Errorf("Expected no metadata without a span")
+	}
+}
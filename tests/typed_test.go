@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/bind"
+	"github.com/piheta/apicore/router"
+)
+
+type typedUserRequest struct {
+	OrgID int64  `path:"org" json:"-" validate:"gt=0"`
+	Name  string `json:"name" validate:"required,min=2"`
+}
+
+type typedUserResponse struct {
+	ID    int64  `json:"id"`
+	OrgID int64  `json:"org_id"`
+	Name  string `json:"name"`
+}
+
+func createTypedUser(_ context.Context, req typedUserRequest) (typedUserResponse, error) {
+	if req.Name == "taken" {
+		return typedUserResponse{}, apierr.NewError(http.StatusConflict, "conflict", "name taken")
+	}
+	return typedUserResponse{ID: 1, OrgID: req.OrgID, Name: req.Name}, nil
+}
+
+func TestHandleTyped(t *testing.T) {
+	r := router.New()
+	router.HandleTyped(r, http.MethodPost, "/orgs/{org}/users", createTypedUser,
+		router.Status(http.StatusCreated), router.BindOptions(bind.Strict()), router.With(headerMiddleware("auth")))
+	r.Handle(http.MethodDelete, "/orgs/{org}/users", router.Typed(func(context.Context, typedUserRequest) (struct{}, error) {
+		return struct{}{}, nil
+	}, router.Status(http.StatusNoContent)))
+
+	tests := []struct {
+		name           string
+		method, path   string
+		body           string
+		expectedStatus int
+		expectedType   string
+		expectedBody   typedUserResponse
+	}{
+		{name: "created", method: http.MethodPost, path: "/orgs/7/users", body: `{"name":"Ada"}`, expectedStatus: http.StatusCreated, expectedBody: typedUserResponse{ID: 1, OrgID: 7, Name: "Ada"}},
+		{name: "validation", method: http.MethodPost, path: "/orgs/7/users", body: `{"name":"A"}`, expectedStatus: http.StatusUnprocessableEntity, expectedType: "validation"},
+		{name: "bind options", method: http.MethodPost, path: "/orgs/7/users", body: `{"name":"Ada","role":"admin"}`, expectedStatus: http.StatusBadRequest, expectedType: "unknown_field"},
+		{name: "handler error", method: http.MethodPost, path: "/orgs/7/users", body: `{"name":"taken"}`, expectedStatus: http.StatusConflict, expectedType: "conflict"},
+		{name: "no content", method: http.MethodDelete, path: "/orgs/7/users", body: `{"name":"Ada"}`, expectedStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body)
+			}
+			switch {
+			case tt.expectedType != "":
+				if errType := decodeErrorType(t, w); errType != tt.expectedType {
+					t.Errorf("Expected type %q, got %q", tt.expectedType, errType)
+				}
+			case tt.expectedStatus == http.StatusNoContent:
+				if w.Body.Len() != 0 {
+					t.Errorf("Expected empty body, got %s", w.Body)
+				}
+			default:
+				var got typedUserResponse
+				if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+					t.Fatalf("Failed to decode body: %v", err)
+				}
+				if got != tt.expectedBody {
+					t.Errorf("Expected body %+v, got %+v", tt.expectedBody, got)
+				}
+				if got := w.Header().Get("X-Middleware"); got != "auth" {
+					t.Errorf("Expected route middleware, got %q", got)
+				}
+			}
+		})
+	}
+
+	route := r.Routes()[0]
+	if route.Request != reflect.TypeFor[typedUserRequest]() || route.Response != reflect.TypeFor[typedUserResponse]() {
+		t.Errorf("Expected request and response types in route metadata, got %v and %v", route.Request, route.Response)
+	}
+	if route.Handler != "tests.createTypedUser" {
+		t.Errorf("Expected handler tests.createTypedUser, got %q", route.Handler)
+	}
+}
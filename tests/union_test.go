@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/piheta/apicore/bind"
+	"github.com/piheta/apicore/middleware"
+	"github.com/piheta/apicore/response"
+)
+
+type paymentMethod interface {
+	kind() string
+}
+
+type cardMethod struct {
+	Type   string `json:"type"`
+	Number string `json:"number" validate:"required,numeric,len=16"`
+}
+
+func (*cardMethod) kind() string { return "card" }
+
+type ibanMethod struct {
+	Type string `json:"type"`
+	IBAN string `json:"iban" validate:"required,alphanum"`
+}
+
+func (*ibanMethod) kind() string { return "iban" }
+
+var paymentMethods = bind.NewUnion("type", map[string]func() paymentMethod{
+	"card": func() paymentMethod { return &cardMethod{} },
+	"iban": func() paymentMethod { return &ibanMethod{} },
+})
+
+type paymentRequest struct {
+	Amount int           `json:"amount"`
+	Method paymentMethod `json:"-"`
+}
+
+func (p *paymentRequest) UnmarshalJSON(b []byte) error {
+	var fields struct {
+		Amount int             `json:"amount"`
+		Method json.RawMessage `json:"method"`
+	}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	m, err := paymentMethods.Decode(fields.Method)
+	p.Amount, p.Method = fields.Amount, m
+	return err
+}
+
+func TestUnionBind(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		opts         []bind.Option
+		expectedKind string
+		expectedCode int
+		expectedMsg  string
+	}{
+		{name: "card", body: `{"type":"card","number":"4242424242424242"}`, expectedKind: "card", expectedCode: http.StatusOK},
+		{name: "iban", body: `{"type":"iban","iban":"NO9386011117947"}`, expectedKind: "iban", expectedCode: http.StatusOK},
+		{name: "variant validated", body: `{"type":"card","number":"42"}`, expectedCode: http.StatusUnprocessableEntity},
+		{name: "unknown", body: `{"type":"cash"}`, expectedCode: http.StatusBadRequest, expectedMsg: `unknown type "cash", expected one of: card, iban`},
+		{name: "missing", body: `{"number":"4242424242424242"}`, expectedCode: http.StatusBadRequest, expectedMsg: `missing "type" field, expected one of: card, iban`},
+		{name: "not a string", body: `{"type":1}`, expectedCode: http.StatusBadRequest, expectedMsg: `"type" must be a string, expected one of: card, iban`},
+		{name: "invalid json", body: `{"type":`, expectedCode: http.StatusBadRequest},
+		{name: "unknown field", body: `{"type":"card","number":"4242424242424242","cvc":"123"}`, expectedKind: "card", expectedCode: http.StatusOK},
+		{name: "strict unknown field", body: `{"type":"card","number":"4242424242424242","cvc":"123"}`, opts: []bind.Option{bind.Strict()}, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got paymentMethod
+			handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+				m, err := paymentMethods.Bind(r, tt.opts...)
+				if err != nil {
+					return err
+				}
+				got = m
+				return response.Status(w, http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/payment-methods", strings.NewReader(tt.body)))
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.expectedKind != "" && got.kind() != tt.expectedKind {
+				t.Errorf("Expected kind %s, got %s", tt.expectedKind, got.kind())
+			}
+			if tt.expectedMsg != "" {
+				var body struct {
+					Type string `json:"type"`
+					Msg  string `json:"msg"`
+				}
+				_ = json.Unmarshal(w.Body.Bytes(), &body)
+				if body.Type != "unknown_type" || body.Msg != tt.expectedMsg {
+					t.Errorf("Expected unknown_type %q, got %s %q", tt.expectedMsg, body.Type, body.Msg)
+				}
+			}
+		})
+	}
+}
+
+func TestUnion_Nested(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{name: "valid", body: `{"amount":100,"method":{"type":"iban","iban":"NO9386011117947"}}`, expectedCode: http.StatusOK},
+		{name: "nested variant validated", body: `{"amount":100,"method":{"type":"iban","iban":"NO-93"}}`, expectedCode: http.StatusUnprocessableEntity},
+		{name: "unknown", body: `{"amount":100,"method":{"type":"cash"}}`, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.Public(func(w http.ResponseWriter, r *http.Request) error {
+				var req paymentRequest
+				if err := bind.JSON(r, &req); err != nil {
+					return err
+				}
+				if req.Amount != 100 || req.Method.kind() != "iban" {
+					t.Errorf("Unexpected request %+v", req)
+				}
+				return response.Status(w, http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(tt.body)))
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+		})
+	}
+}
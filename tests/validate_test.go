@@ -0,0 +1,382 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/validate"
+)
+
+type address struct {
+	City string `validate:"required"`
+	Zip  string `validate:"len=5,numeric"`
+}
+
+type order struct {
+	ID       string            `validate:"required,uuid"`
+	Status   string            `validate:"oneof=pending paid shipped"`
+	Quantity int               `validate:"min=1,max=100"`
+	Website  string            `validate:"omitempty,url"`
+	Code     string            `validate:"omitempty,alphanum"`
+	Tags     []string          `validate:"max=3,dive,required,alpha"`
+	Address  address           ``
+	Items    []address         ``
+	Meta     map[string]string `validate:"dive,max=5"`
+	internal string
+}
+
+func validOrder() order {
+	return order{
+		ID:       "0b6e8a4e-4a5b-4c5f-9b7a-1f2e3d4c5b6a",
+		Status:   "paid",
+		Quantity: 2,
+		Tags:     []string{"gift"},
+		Address:  address{City: "Oslo", Zip: "01500"},
+	}
+}
+
+func TestValidate_Struct(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(o *order)
+		namespaces []string
+		tags       []string
+	}{
+		{name: "valid", modify: func(*order) {}},
+		{name: "required", modify: func(o *order) { o.ID = "" }, namespaces: []string{"ID"}, tags: []string{"required"}},
+		{name: "uuid", modify: func(o *order) { o.ID = "123" }, namespaces: []string{"ID"}, tags: []string{"uuid"}},
+		{name: "oneof", modify: func(o *order) { o.Status = "lost" }, namespaces: []string{"Status"}, tags: []string{"oneof"}},
+		{name: "min and max", modify: func(o *order) { o.Quantity = 101 }, namespaces: []string{"Quantity"}, tags: []string{"max"}},
+		{name: "omitempty url", modify: func(o *order) { o.Website = "not a url" }, namespaces: []string{"Website"}, tags: []string{"url"}},
+		{name: "dive", modify: func(o *order) { o.Tags = []string{"ok", "", "n0"} }, namespaces: []string{"Tags[1]", "Tags[2]"}, tags: []string{"required", "alpha"}},
+		{name: "slice length", modify: func(o *order) { o.Tags = []string{"a", "b", "c", "d"} }, namespaces: []string{"Tags"}, tags: []string{"max"}},
+		{name: "nested", modify: func(o *order) { o.Address.Zip = "12a45" }, namespaces: []string{"Address.Zip"}, tags: []string{"numeric"}},
+		{name: "nested slice", modify: func(o *order) { o.Items = []address{{City: "Bergen", Zip: "05000"}, {Zip: "05000"}} }, namespaces: []string{"Items[1].City"}, tags: []string{"required"}},
+		{name: "map values", modify: func(o *order) { o.Meta = map[string]string{"k": "toolong"} }, namespaces: []string{"Meta[k]"}, tags: []string{"max"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := validOrder()
+			tt.modify(&o)
+
+			err := validate.Struct(&o)
+			if len(tt.tags) == 0 {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			var errs validate.Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Expected validate.Errors, got %v", err)
+			}
+			var namespaces, tags []string
+			for _, fe := range errs {
+				namespaces = append(namespaces, fe.Namespace())
+				tags = append(tags, fe.Tag())
+			}
+			if !reflect.DeepEqual(namespaces, tt.namespaces) {
+				t.Errorf("Expected namespaces %v, got %v", tt.namespaces, namespaces)
+			}
+			if !reflect.DeepEqual(tags, tt.tags) {
+				t.Errorf("Expected tags %v, got %v", tt.tags, tags)
+			}
+		})
+	}
+}
+
+func TestValidate_RegisterRule(t *testing.T) {
+	v := validate.New(validate.Options{})
+	v.RegisterRule("even", func(_ context.Context, fv reflect.Value, _ string) bool {
+		return fv.Int()%2 == 0
+	})
+
+	type payload struct {
+		N int `validate:"even"`
+	}
+
+	if err := v.Struct(payload{N: 2}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := v.Struct(payload{N: 3}); err == nil {
+		t.Error("Expected error for odd value")
+	}
+}
+
+func TestValidate_UnknownRule(t *testing.T) {
+	v := validate.New(validate.Options{})
+
+	type item struct {
+		SKU string `validate:"required,skuu"`
+	}
+	type payload struct {
+		Name  string `validate:"required"`
+		Items []item `validate:"omitempty"`
+	}
+
+	if err := v.Check(payload{}); err == nil || !strings.Contains(err.Error(), `"skuu"`) {
+		t.Errorf("Expected Check to report the unknown rule, got %v", err)
+	}
+	// The unknown rule is reported even though no item is validated.
+	if err := v.Struct(payload{Name: "ada"}); err == nil || !strings.Contains(err.Error(), `"skuu"`) {
+		t.Errorf("Expected Struct to report the unknown rule, got %v", err)
+	}
+
+	v.RegisterRule("skuu", func(context.Context, reflect.Value, string) bool { return true })
+	if err := v.Struct(payload{Name: "ada", Items: []item{{SKU: "a"}}}); err != nil {
+		t.Errorf("Expected no error once the rule is registered, got %v", err)
+	}
+}
+
+func TestValidate_InvalidParam(t *testing.T) {
+	v := validate.New(validate.Options{})
+
+	type payload struct {
+		Name string `validate:"min=abc"`
+	}
+
+	if err := v.Check(payload{}); err == nil || !strings.Contains(err.Error(), `"abc"`) {
+		t.Errorf("Expected Check to report the invalid parameter, got %v", err)
+	}
+	if err := v.Struct(payload{Name: "ada"}); err == nil || !strings.Contains(err.Error(), `"abc"`) {
+		t.Errorf("Expected Struct to report the invalid parameter, got %v", err)
+	}
+
+	// A replaced rule may take any parameter.
+	v.RegisterRule("min", func(context.Context, reflect.Value, string) bool { return true })
+	if err := v.Struct(payload{Name: "ada"}); err != nil {
+		t.Errorf("Expected no error for a custom min rule, got %v", err)
+	}
+}
+
+func TestValidate_NotStruct(t *testing.T) {
+	if err := validate.Struct(42); err == nil {
+		t.Error("Expected error for non-struct")
+	}
+}
+
+func TestValidate_CommonRules(t *testing.T) {
+	type payment struct {
+		Phone    string `validate:"e164"`
+		Currency string `validate:"iso4217"`
+	}
+
+	tests := []struct {
+		name     string
+		payment  payment
+		expected []string
+	}{
+		{name: "valid", payment: payment{Phone: "+4791234567", Currency: "NOK"}},
+		{name: "phone without plus", payment: payment{Phone: "4791234567", Currency: "EUR"}, expected: []string{"e164"}},
+		{name: "phone too long", payment: payment{Phone: "+1234567890123456", Currency: "EUR"}, expected: []string{"e164"}},
+		{name: "unknown currency", payment: payment{Phone: "+4791234567", Currency: "XYZ"}, expected: []string{"iso4217"}},
+		{name: "lowercase currency", payment: payment{Phone: "+4791234567", Currency: "usd"}, expected: []string{"iso4217"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tags []string
+			var errs validate.Errors
+			if errors.As(validate.Struct(tt.payment), &errs) {
+				for _, fe := range errs {
+					tags = append(tags, fe.Tag())
+				}
+			}
+			if !reflect.DeepEqual(tags, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, tags)
+			}
+		})
+	}
+}
+
+func TestValidate_Translations(t *testing.T) {
+	v := validate.New(validate.Options{FieldName: validate.JSONFieldName})
+	v.SetTranslator("en", validate.English)
+	v.SetTranslator("nb", validate.Translations{"required": "{field} er påkrevd"})
+
+	type signup struct {
+		Email string `json:"email" validate:"required"`
+		Name  string `json:"name,omitempty" validate:"min=2"`
+	}
+
+	tests := []struct {
+		name     string
+		locales  string
+		expected map[string]string
+	}{
+		{name: "default locale", expected: map[string]string{"email": "email is required", "name": "name must be at least 2"}},
+		{name: "region falls back to base", locales: "nb-NO,en;q=0.5", expected: map[string]string{"email": "email er påkrevd", "name": "name must be at least 2"}},
+		{name: "unknown locale", locales: "fr", expected: map[string]string{"email": "email is required", "name": "name must be at least 2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.locales != "" {
+				ctx = validate.WithLocale(ctx, tt.locales)
+			}
+
+			var errs validate.Errors
+			if !errors.As(v.StructCtx(ctx, signup{Name: "a"}), &errs) {
+				t.Fatal("Expected validation errors")
+			}
+			got := make(map[string]string)
+			for _, fe := range errs {
+				got[fe.Field()] = fe.Message()
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidate_Scenario(t *testing.T) {
+	type userInput struct {
+		Email string `validate:"required,email" validate_update:"omitempty,email"`
+		Role  string `validate:"-" validate_create:"required,oneof=admin member"`
+		Name  string `validate:"omitempty,min=2"`
+	}
+
+	tests := []struct {
+		name     string
+		scenario string
+		input    userInput
+		expected []string
+	}{
+		{name: "default requires email", input: userInput{}, expected: []string{"Email:required"}},
+		{name: "create requires role", scenario: "create", input: userInput{Email: "a@example.com"}, expected: []string{"Role:required"}},
+		{name: "update allows partial", scenario: "update", input: userInput{Name: "Ada"}},
+		{name: "update still checks format", scenario: "update", input: userInput{Email: "nope", Name: "A"}, expected: []string{"Email:email", "Name:min"}},
+		{name: "unscoped scenario", scenario: "archive", input: userInput{}, expected: []string{"Email:required"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.scenario != "" {
+				ctx = validate.WithScenario(ctx, tt.scenario)
+			}
+
+			var got []string
+			var errs validate.Errors
+			if errors.As(validate.StructCtx(ctx, tt.input), &errs) {
+				for _, fe := range errs {
+					got = append(got, fe.Field()+":"+fe.Tag())
+				}
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+type ctxTenantKey struct{}
+
+func TestValidate_AsyncRules(t *testing.T) {
+	var calls atomic.Int32
+	v := validate.New(validate.Options{FieldName: validate.JSONFieldName})
+	v.RegisterAsyncRule("unique_email", func(ctx context.Context, fv reflect.Value, _ string) (bool, error) {
+		calls.Add(1)
+		if ctx.Value(ctxTenantKey{}) != "acme" {
+			return false, errors.New("missing tenant")
+		}
+		switch fv.String() {
+		case "down@example.com":
+			return false, errors.New("database unavailable")
+		case "taken@example.com":
+			return false, nil
+		}
+		return true, nil
+	})
+
+	type signup struct {
+		Email string `json:"email" validate:"required,unique_email,email"`
+		Name  string `json:"name" validate:"required"`
+	}
+
+	tests := []struct {
+		name          string
+		input         signup
+		expected      []string
+		expectedErr   bool
+		expectedCalls int32
+	}{
+		{name: "valid", input: signup{Email: "new@example.com", Name: "Ada"}, expectedCalls: 1},
+		{name: "merged with static failures", input: signup{Email: "taken@example.com"}, expected: []string{"name:required", "email:unique_email"}, expectedCalls: 1},
+		{name: "skipped after static failure", input: signup{Email: "not-an-email", Name: "Ada"}, expected: []string{"email:email"}},
+		{name: "check error", input: signup{Email: "down@example.com", Name: "Ada"}, expectedErr: true, expectedCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			err := v.StructCtx(context.WithValue(context.Background(), ctxTenantKey{}, "acme"), tt.input)
+
+			if got := calls.Load(); got != tt.expectedCalls {
+				t.Errorf("Expected %d async calls, got %d", tt.expectedCalls, got)
+			}
+			var errs validate.Errors
+			if tt.expectedErr {
+				if err == nil || errors.As(err, &errs) {
+					t.Fatalf("Expected check error, got %v", err)
+				}
+				if apierr.MapError(err, nil).StatusCode != 500 {
+					t.Errorf("Expected check error to map to 500")
+				}
+				return
+			}
+
+			var got []string
+			if errors.As(err, &errs) {
+				for _, fe := range errs {
+					got = append(got, fe.Field()+":"+fe.Tag())
+				}
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidate_AsyncRulesConcurrent(t *testing.T) {
+	v := validate.New(validate.Options{})
+	var inFlight, peak atomic.Int32
+	v.RegisterAsyncRule("slow", func(_ context.Context, fv reflect.Value, _ string) (bool, error) {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		inFlight.Add(-1)
+		return fv.Int()%2 == 0, nil
+	})
+
+	type batch struct {
+		IDs []int `validate:"dive,slow"`
+	}
+
+	var errs validate.Errors
+	if !errors.As(v.Struct(batch{IDs: []int{2, 3, 4, 5}}), &errs) {
+		t.Fatal("Expected validation errors")
+	}
+	if len(errs) != 2 || errs[0].Namespace() != "IDs[1]" || errs[1].Namespace() != "IDs[3]" {
+		t.Errorf("Expected IDs[1] and IDs[3] in order, got %v", errs)
+	}
+	if peak.Load() < 2 {
+		t.Errorf("Expected async rules to run concurrently, peak was %d", peak.Load())
+	}
+}
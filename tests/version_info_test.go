@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/piheta/apicore/middleware"
+)
+
+func TestVersionHandler(t *testing.T) {
+	tests := []struct {
+		name string
+		info middleware.BuildInfo
+		want middleware.BuildInfo
+	}{
+		{"from build", middleware.BuildInfo{}, middleware.ReadBuildInfo()},
+		{"overrides", middleware.BuildInfo{Version: "v1.4.2", Commit: "abc123"}, middleware.BuildInfo{
+			Version: "v1.4.2", Commit: "abc123", Date: middleware.ReadBuildInfo().Date, GoVersion: runtime.Version(),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			middleware.VersionHandler(tt.info).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+			var got middleware.BuildInfo
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Expected JSON body, got %q", w.Body)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+			if got.GoVersion != runtime.Version() {
+				t.Errorf("Expected Go version %q, got %q", runtime.Version(), got.GoVersion)
+			}
+		})
+	}
+}
+
+func TestRequestLogger_Version(t *testing.T) {
+	var buf bytes.Buffer
+	mw := middleware.RequestLoggerWith(middleware.LoggerOptions{Logger: slog.New(slog.NewTextHandler(&buf, nil)), Version: "v1.4.2"})
+	serveLogged(mw, http.MethodGet, "/users", http.StatusOK, nil)
+
+	if !strings.Contains(buf.String(), "version=v1.4.2") {
+		t.Errorf("Expected version in access log, got %q", buf.String())
+	}
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/response"
+	"github.com/piheta/apicore/router"
+)
+
+func TestRouter_Version(t *testing.T) {
+	sunset := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	handler := func(w http.ResponseWriter, r *http.Request) error {
+		return response.JSON(w, http.StatusOK, map[string]string{"version": router.GetVersion(r.Context())})
+	}
+
+	api := router.New()
+	api.Version("v1", router.VersionOptions{Sunset: sunset, Link: "https://docs.example.com/v2"}).Handle(http.MethodGet, "/users", handler)
+	api.Version("v2", router.VersionOptions{Default: true}).Handle(http.MethodGet, "/users", handler)
+	api.Handle(http.MethodGet, "/healthz", handler)
+
+	tests := []struct {
+		name            string
+		path            string
+		acceptVersion   string
+		expectedStatus  int
+		expectedVersion string
+		expectedType    string
+		expectedSunset  string
+	}{
+		{name: "path prefix", path: "/v1/users", expectedStatus: http.StatusOK, expectedVersion: "v1", expectedSunset: "Tue, 01 Jan 2030 00:00:00 GMT"},
+		{name: "path prefix wins over header", path: "/v2/users", acceptVersion: "v1", expectedStatus: http.StatusOK, expectedVersion: "v2"},
+		{name: "header", path: "/users", acceptVersion: "v1", expectedStatus: http.StatusOK, expectedVersion: "v1", expectedSunset: "Tue, 01 Jan 2030 00:00:00 GMT"},
+		{name: "header without v", path: "/users", acceptVersion: "2", expectedStatus: http.StatusOK, expectedVersion: "v2"},
+		{name: "default", path: "/users", expectedStatus: http.StatusOK, expectedVersion: "v2"},
+		{name: "unknown header", path: "/users", acceptVersion: "v9", expectedStatus: http.StatusBadRequest, expectedType: "unsupported_version"},
+		{name: "unknown prefix", path: "/v9/users", expectedStatus: http.StatusBadRequest, expectedType: "unsupported_version"},
+		{name: "unversioned route", path: "/healthz", expectedStatus: http.StatusOK},
+		{name: "unversioned route with header", path: "/healthz", acceptVersion: "v1", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptVersion != "" {
+				req.Header.Set("Accept-Version", tt.acceptVersion)
+			}
+			w := httptest.NewRecorder()
+			api.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body)
+			}
+			if tt.expectedType != "" {
+				if errType := decodeErrorType(t, w); errType != tt.expectedType {
+					t.Errorf("Expected type %q, got %q", tt.expectedType, errType)
+				}
+				return
+			}
+
+			var body map[string]string
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode body: %v", err)
+			}
+			if body["version"] != tt.expectedVersion {
+				t.Errorf("Expected version %q, got %q", tt.expectedVersion, body["version"])
+			}
+			if got := w.Header().Get("Sunset"); got != tt.expectedSunset {
+				t.Errorf("Expected Sunset %q, got %q", tt.expectedSunset, got)
+			}
+			if deprecated := w.Header().Get("Deprecation") == "true"; deprecated != (tt.expectedSunset != "") {
+				t.Errorf("Expected deprecated=%v, got Deprecation %q", tt.expectedSunset != "", w.Header().Get("Deprecation"))
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/piheta/apicore/router"
+	"github.com/piheta/apicore/view"
+)
+
+func writeTemplate(t *testing.T, dir, name, content string, modTime time.Time) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTemplates_Render(t *testing.T) {
+	tests := []struct {
+		name         string
+		dev          bool
+		expectedBody string
+	}{
+		{name: "production keeps parsed templates", dev: false, expectedBody: "<h1>Hello ADA</h1>"},
+		{name: "dev reloads edited templates", dev: true, expectedBody: "<h2>Hi Ada</h2>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			start := time.Now().Add(-time.Hour)
+			writeTemplate(t, dir, "page.html", `{{define "page"}}<h1>Hello {{upper .}}</h1>{{end}}`, start)
+
+			tmpl, err := view.New(view.Options{
+				FS:    os.DirFS(dir),
+				Funcs: map[string]any{"upper": strings.ToUpper},
+				Dev:   tt.dev,
+			})
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			if err := tmpl.Render(w, http.StatusOK, "page", "Ada"); err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if got := w.Body.String(); got != "<h1>Hello ADA</h1>" {
+				t.Errorf("Expected initial body, got %q", got)
+			}
+			if got := w.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+				t.Errorf("Expected HTML content type, got %q", got)
+			}
+
+			writeTemplate(t, dir, "page.html", `{{define "page"}}<h2>Hi {{.}}</h2>{{end}}`, start.Add(time.Minute))
+			w = httptest.NewRecorder()
+			if err := tmpl.Render(w, http.StatusOK, "page", "Ada"); err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if got := w.Body.String(); got != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, got)
+			}
+		})
+	}
+}
+
+func TestTemplates_RenderError(t *testing.T) {
+	tmpl, err := view.New(view.Options{FS: fstest.MapFS{
+		"page.html": {Data: []byte(`{{define "page"}}{{.Missing.Field}}{{end}}`)},
+	}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	if err := tmpl.Render(w, http.StatusOK, "page", map[string]any{"Missing": 1}); err == nil {
+		t.Error("Expected execution error")
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Expected nothing written on error, got %q", w.Body)
+	}
+
+	if _, err := view.New(view.Options{FS: fstest.MapFS{}}); err == nil {
+		t.Error("Expected error without templates")
+	}
+}
+
+func TestStatic_DevDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.3f9a2c1e.js"), []byte("console.log('dev')"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	embedded := fstest.MapFS{"app.3f9a2c1e.js": {Data: []byte("console.log('embedded')")}}
+
+	tests := []struct {
+		name          string
+		opts          router.StaticOptions
+		expectedBody  string
+		expectedCache string
+	}{
+		{name: "embedded", opts: router.StaticOptions{}, expectedBody: "embedded", expectedCache: "immutable"},
+		{name: "dev dir", opts: router.StaticOptions{DevDir: dir}, expectedBody: "dev", expectedCache: "no-store"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.Static(embedded, tt.opts).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/app.3f9a2c1e.js", nil))
+
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("Expected body from %s, got %q", tt.expectedBody, w.Body)
+			}
+			if got := w.Header().Get("Cache-Control"); !strings.Contains(got, tt.expectedCache) {
+				t.Errorf("Expected Cache-Control with %q, got %q", tt.expectedCache, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/piheta/apicore/apierr"
+	"github.com/piheta/apicore/middleware"
+)
+
+func hmacHex(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyHMAC_Stripe(t *testing.T) {
+	var gotBody string
+	handler := middleware.VerifyHMAC(
+		middleware.StaticSecret("whsec"),
+		"Stripe-Signature",
+		middleware.StripeScheme,
+		middleware.WithReplayStore(middleware.NewMemoryReplayStore()),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	body := `{"event":"paid"}`
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	valid := "t=" + now + ",v1=" + hmacHex("whsec", now+"."+body)
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantType   string
+	}{
+		{name: "valid", header: valid, wantStatus: http.StatusOK},
+		{name: "replayed", header: valid, wantStatus: http.StatusUnauthorized, wantType: "replayed_request"},
+		{name: "wrong secret", header: "t=" + now + ",v1=" + hmacHex("other", now+"."+body), wantStatus: http.StatusUnauthorized, wantType: "invalid_signature"},
+		{name: "stale", header: "t=" + old + ",v1=" + hmacHex("whsec", old+"."+body), wantStatus: http.StatusUnauthorized, wantType: "stale_signature"},
+		{name: "malformed", header: "garbage", wantStatus: http.StatusUnauthorized, wantType: "invalid_signature"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/webhooks/stripe", strings.NewReader(body))
+			r.Header.Set("Stripe-Signature", tt.header)
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantType == "" {
+				if gotBody != body {
+					t.Errorf("Expected body to be restored, got %q", gotBody)
+				}
+				return
+			}
+			var result apierr.APIError
+			if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if result.Type != tt.wantType {
+				t.Errorf("Expected type=%s, got %q", tt.wantType, result.Type)
+			}
+		})
+	}
+}
+
+func TestVerifyHMAC_GitHubAndSlack(t *testing.T) {
+	body := `{"action":"opened"}`
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	github := middleware.VerifyHMAC(middleware.StaticSecret("gh"), "X-Hub-Signature-256", middleware.GitHubScheme)(ok)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/webhooks/github", strings.NewReader(body))
+	r.Header.Set("X-Hub-Signature-256", "sha256="+hmacHex("gh", body))
+	github.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("GitHub: expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	slack := middleware.VerifyHMAC(middleware.StaticSecret("sl"), "X-Slack-Signature", middleware.SlackScheme)(ok)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/webhooks/slack", strings.NewReader(body))
+	r.Header.Set("X-Slack-Request-Timestamp", ts)
+	r.Header.Set("X-Slack-Signature", "v0="+hmacHex("sl", "v0:"+ts+":"+body))
+	slack.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("Slack: expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+// ttlStore is a ReplayStore recording the TTL of every key.
+type ttlStore struct {
+	ttls map[string]time.Duration
+}
+
+func (s *ttlStore) Seen(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	_, seen := s.ttls[key]
+	s.ttls[key] = ttl
+	return seen, nil
+}
+
+func TestVerifyHMAC_Replays(t *testing.T) {
+	body := `{"action":"opened"}`
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	store := &ttlStore{ttls: make(map[string]time.Duration)}
+	github := middleware.VerifyHMAC(middleware.StaticSecret("gh"), "X-Hub-Signature-256", middleware.GitHubScheme,
+		middleware.WithReplayStore(store), middleware.WithMaxBytes(64))(ok)
+
+	sig := hmacHex("gh", body)
+	tests := []struct {
+		name       string
+		header     string
+		body       string
+		wantStatus int
+	}{
+		{name: "first delivery", header: "sha256=" + sig, body: body, wantStatus: http.StatusOK},
+		{name: "exact replay", header: "sha256=" + sig, body: body, wantStatus: http.StatusUnauthorized},
+		{name: "upper-cased replay", header: "sha256=" + strings.ToUpper(sig), body: body, wantStatus: http.StatusUnauthorized},
+		{name: "body too large", header: "sha256=" + sig, body: strings.Repeat("x", 65), wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/webhooks/github", strings.NewReader(tt.body))
+			r.Header.Set("X-Hub-Signature-256", tt.header)
+			github.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+
+	if ttl := store.ttls["hmac:"+sig]; ttl != middleware.DefaultReplayTTL {
+		t.Errorf("Expected replay TTL %v for a scheme without timestamp, got %v", middleware.DefaultReplayTTL, ttl)
+	}
+}
+
+func TestRejectReplays(t *testing.T) {
+	handler := middleware.RejectReplays(middleware.NonceOptions{})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		nonce      string
+		wantStatus int
+	}{
+		{name: "first use", nonce: "n-1", wantStatus: http.StatusOK},
+		{name: "replay", nonce: "n-1", wantStatus: http.StatusConflict},
+		{name: "new nonce", nonce: "n-2", wantStatus: http.StatusOK},
+		{name: "missing", nonce: "", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/transfers", nil)
+			if tt.nonce != "" {
+				r.Header.Set("X-Nonce", tt.nonce)
+			}
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
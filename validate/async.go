@@ -0,0 +1,86 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// AsyncRule is a rule that consults external state, such as checking that an
+// email is not already registered. It reports whether v is valid, or an error
+// when the check itself failed, e.g. the database is unreachable.
+type AsyncRule func(ctx context.Context, v reflect.Value, param string) (bool, error)
+
+// asyncConcurrency caps how many async checks of one validation run at once.
+const asyncConcurrency = 8
+
+type pendingCheck struct {
+	fe    FieldError
+	value reflect.Value
+	rule  AsyncRule
+}
+
+// RegisterAsyncRule adds the async rule called name, used in tags like any other:
+//
+//	v.RegisterAsyncRule("unique_email", func(ctx context.Context, fv reflect.Value, _ string) (bool, error) {
+//		taken, err := users.EmailExists(ctx, fv.String())
+//		return !taken, err
+//	})
+//
+//	Email string `validate:"required,email,unique_email"`
+//
+// Async rules run concurrently with the validation's context once every
+// static rule has been checked, and only for fields whose static rules passed,
+// so malformed input never reaches the database. Their failures are merged
+// into the same Errors. An error from the rule aborts validation and is
+// returned instead of Errors.
+func (v *Validator) RegisterAsyncRule(name string, rule AsyncRule) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.async == nil {
+		v.async = make(map[string]AsyncRule)
+	}
+	v.async[name] = rule
+	v.checked = nil
+}
+
+func (v *Validator) asyncRule(name string) (AsyncRule, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	r, ok := v.async[name]
+	return r, ok
+}
+
+// runAsync runs w's pending checks, appending failures in tag order.
+func (v *Validator) runAsync(ctx context.Context, w *walk) error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+
+	valid := make([]bool, len(w.pending))
+	errs := make([]error, len(w.pending))
+	sem := make(chan struct{}, asyncConcurrency)
+
+	var wg sync.WaitGroup
+	for i, check := range w.pending {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			valid[i], errs[i] = check.rule(ctx, check.value, check.fe.param)
+		}()
+	}
+	wg.Wait()
+
+	for i, check := range w.pending {
+		if errs[i] != nil {
+			return fmt.Errorf("validate: %s on %s: %w", check.fe.tag, check.fe.namespace, errs[i])
+		}
+		if !valid[i] {
+			w.errs = append(w.errs, v.finishError(ctx, check.fe))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// checkKey identifies the tags of a struct type as seen under a scenario.
+type checkKey struct {
+	t        reflect.Type
+	scenario string
+}
+
+// Check reports an error when the tags of s's type, a struct or pointer to
+// struct, name a rule the Validator does not know or give a size rule such as
+// min a non-numeric parameter, so typos in tags fail at startup or in tests
+// rather than on every request:
+//
+//	if err := v.Check(CreateUserRequest{}); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// StructCtx runs the same check on the first use of each type and returns
+// its error instead of validating.
+func (v *Validator) Check(s any) error {
+	t := indirectType(reflect.TypeOf(s))
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("validate: expected struct, got %T", s)
+	}
+	return v.checkType(context.Background(), t)
+}
+
+// checkType checks the tags of struct type t under ctx's scenario, caching the
+// result until rules change.
+func (v *Validator) checkType(ctx context.Context, t reflect.Type) error {
+	scenario, _ := ctx.Value(scenarioKey{}).(string)
+	key := checkKey{t: t, scenario: scenario}
+
+	v.mu.RLock()
+	err, ok := v.checked[key]
+	v.mu.RUnlock()
+	if ok {
+		return err
+	}
+
+	err = v.checkStruct(t, scenario, t.Name(), make(map[reflect.Type]bool))
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.checked == nil {
+		v.checked = make(map[checkKey]error)
+	}
+	v.checked[key] = err
+	return err
+}
+
+func (v *Validator) checkStruct(t reflect.Type, scenario, namespace string, seen map[reflect.Type]bool) error {
+	if seen[t] || t.PkgPath() == "time" {
+		return nil
+	}
+	seen[t] = true
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get(v.tagName)
+		if scenario != "" {
+			if scoped, ok := f.Tag.Lookup(v.tagName + "_" + scenario); ok {
+				tag = scoped
+			}
+		}
+		if tag == "-" {
+			continue
+		}
+		if err := v.checkField(f.Type, tag, scenario, namespace+"."+f.Name, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkField checks the rules in tag, those after "dive" against the element
+// type, then descends into nested structs like validateField.
+func (v *Validator) checkField(t reflect.Type, tag, scenario, namespace string, seen map[reflect.Type]bool) error {
+	rules := splitRules(tag)
+	for i, r := range rules {
+		name, param, _ := strings.Cut(r, "=")
+		switch name {
+		case "", "omitempty":
+			continue
+		case "dive":
+			elem := indirectType(t)
+			switch elem.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map:
+				return v.checkField(elem.Elem(), strings.Join(rules[i+1:], ","), scenario, namespace+"[]", seen)
+			}
+			return nil
+		}
+		if err := v.checkRule(name, param, namespace); err != nil {
+			return err
+		}
+	}
+
+	t = indirectType(t)
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if indirectType(t.Elem()).Kind() != reflect.Struct {
+			return nil
+		}
+		t = indirectType(t.Elem())
+		namespace += "[]"
+	case reflect.Struct:
+	default:
+		return nil
+	}
+	return v.checkStruct(t, scenario, namespace, seen)
+}
+
+// checkRule reports a rule the Validator does not know, or a built-in size
+// rule such as min whose parameter is not a number.
+func (v *Validator) checkRule(name, param, namespace string) error {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if _, ok := v.async[name]; ok {
+		return nil
+	}
+	if _, ok := v.rules[name]; !ok {
+		return fmt.Errorf("validate: unknown rule %q on %s", name, namespace)
+	}
+	if v.numeric[name] {
+		if _, err := strconv.ParseFloat(param, 64); err != nil {
+			return fmt.Errorf("validate: rule %s on %s needs a numeric parameter, got %q", name, namespace, param)
+		}
+	}
+	return nil
+}
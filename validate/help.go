@@ -0,0 +1,43 @@
+package validate
+
+// Help is documentation attached to validation failures so client developers
+// get actionable guidance from the error response itself.
+type Help struct {
+	// DocURL links to documentation for the field or rule.
+	DocURL string
+	// Hint is a short remediation, e.g. "use ISO 8601 dates such as 2024-05-31".
+	Hint string
+}
+
+// RegisterRuleHelp attaches help to every failure of rule.
+func (v *Validator) RegisterRuleHelp(rule string, h Help) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.help == nil {
+		v.help = make(map[string]Help)
+	}
+	v.help[rule] = h
+}
+
+// RegisterFieldHelp attaches help to failures of rule on field, named as in
+// FieldError.Field. An empty rule matches any rule on field. Field help takes
+// precedence over rule help.
+func (v *Validator) RegisterFieldHelp(field, rule string, h Help) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.help == nil {
+		v.help = make(map[string]Help)
+	}
+	v.help[field+"."+rule] = h
+}
+
+func (v *Validator) lookupHelp(fe FieldError) Help {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	for _, key := range []string{fe.field + "." + fe.tag, fe.field + ".", fe.tag} {
+		if h, ok := v.help[key]; ok {
+			return h
+		}
+	}
+	return Help{}
+}
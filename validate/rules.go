@@ -0,0 +1,119 @@
+package validate
+
+import (
+	"context"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var builtinRules = map[string]Rule{
+	"required": func(_ context.Context, v reflect.Value, _ string) bool { return !v.IsZero() },
+	"min":      sizeRule(func(n, p float64) bool { return n >= p }),
+	"max":      sizeRule(func(n, p float64) bool { return n <= p }),
+	"len":      sizeRule(func(n, p float64) bool { return n == p }),
+	"gt":       sizeRule(func(n, p float64) bool { return n > p }),
+	"gte":      sizeRule(func(n, p float64) bool { return n >= p }),
+	"lt":       sizeRule(func(n, p float64) bool { return n < p }),
+	"lte":      sizeRule(func(n, p float64) bool { return n <= p }),
+	"oneof":    oneOf,
+	"email": stringRule(func(s string) bool {
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	}),
+	"url": stringRule(func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != "" && u.Host != ""
+	}),
+	"alpha":    stringRule(regexp.MustCompile(`^[a-zA-Z]+$`).MatchString),
+	"alphanum": stringRule(regexp.MustCompile(`^[a-zA-Z0-9]+$`).MatchString),
+	"numeric":  stringRule(regexp.MustCompile(`^[-+]?[0-9]+(\.[0-9]+)?$`).MatchString),
+	"uuid":     stringRule(regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`).MatchString),
+	"e164":     stringRule(regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`).MatchString),
+	"iso4217":  stringRule(func(s string) bool { _, ok := currencyCodes[s]; return ok }),
+}
+
+// numericRules are the built-in rules whose parameter must be a number.
+var numericRules = []string{"min", "max", "len", "gt", "gte", "lt", "lte"}
+
+// currencyCodes are the active ISO 4217 alphabetic currency codes.
+var currencyCodes = func() map[string]struct{} {
+	codes := make(map[string]struct{})
+	for code := range strings.FieldsSeq(`
+		AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND BOB BRL
+		BSD BTN BWP BYN BZD CAD CDF CHF CLP CNY COP CRC CUP CVE CZK DJF DKK DOP DZD EGP
+		ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GNF GTQ GYD HKD HNL HTG HUF IDR ILS INR
+		IQD IRR ISK JMD JOD JPY KES KGS KHR KMF KPW KRW KWD KYD KZT LAK LBP LKR LRD LSL
+		LYD MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MYR MZN NAD NGN NIO NOK NPR
+		NZD OMR PAB PEN PGK PHP PKR PLN PYG QAR RON RSD RUB RWF SAR SBD SCR SDG SEK SGD
+		SHP SLE SOS SRD SSP STN SVC SYP SZL THB TJS TMT TND TOP TRY TTD TWD TZS UAH UGX
+		USD UYU UZS VES VND VUV WST XAF XCD XCG XOF XPF YER ZAR ZMW ZWG`) {
+		codes[code] = struct{}{}
+	}
+	return codes
+}()
+
+// sizeRule compares a number's value, or a string's, slice's or map's length,
+// against the numeric rule parameter. Check rejects tags with a non-numeric
+// parameter before any value is validated.
+func sizeRule(cmp func(n, param float64) bool) Rule {
+	return func(_ context.Context, v reflect.Value, param string) bool {
+		p, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false
+		}
+		n, ok := size(v)
+		return ok && cmp(n, p)
+	}
+}
+
+func size(v reflect.Value) (float64, bool) {
+	v = indirect(v)
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// stringRule applies match to string values. Other kinds never match.
+func stringRule(match func(string) bool) Rule {
+	return func(_ context.Context, v reflect.Value, _ string) bool {
+		v = indirect(v)
+		return v.Kind() == reflect.String && match(v.String())
+	}
+}
+
+// oneOf matches values equal to one of the space-separated words in param.
+func oneOf(_ context.Context, v reflect.Value, param string) bool {
+	v = indirect(v)
+	var s string
+	switch v.Kind() {
+	case reflect.String:
+		s = v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = strconv.FormatUint(v.Uint(), 10)
+	default:
+		return false
+	}
+	for _, word := range strings.Fields(param) {
+		if word == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package validate
+
+import (
+	"context"
+	"reflect"
+	"strings"
+)
+
+// Translations maps rule names to message templates for one locale.
+// Templates may use {field} and {param}, e.g. "{field} must be at least {param} characters".
+type Translations map[string]string
+
+// English holds messages for the built-in rules.
+var English = Translations{
+	"required": "{field} is required",
+	"min":      "{field} must be at least {param}",
+	"max":      "{field} must be at most {param}",
+	"len":      "{field} must have length {param}",
+	"gt":       "{field} must be greater than {param}",
+	"gte":      "{field} must be at least {param}",
+	"lt":       "{field} must be less than {param}",
+	"lte":      "{field} must be at most {param}",
+	"oneof":    "{field} must be one of: {param}",
+	"email":    "{field} must be a valid email address",
+	"url":      "{field} must be a valid URL",
+	"alpha":    "{field} must contain only letters",
+	"alphanum": "{field} must contain only letters and digits",
+	"numeric":  "{field} must be numeric",
+	"uuid":     "{field} must be a valid UUID",
+	"e164":     "{field} must be an E.164 phone number",
+	"iso4217":  "{field} must be an ISO 4217 currency code",
+}
+
+type localeKey struct{}
+
+// WithLocale returns a context whose validations produce messages in the
+// first of locales the Validator has translations for. locales are
+// language tags such as "nb-NO" or an Accept-Language header value; a
+// region-specific tag falls back to its base language.
+func WithLocale(ctx context.Context, locales string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locales)
+}
+
+// SetTranslator registers t as the messages for locale, merged over any
+// already registered. The first locale registered becomes the fallback for
+// requests matching none.
+func (v *Validator) SetTranslator(locale string, t Translations) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	locale = strings.ToLower(locale)
+	if v.translations == nil {
+		v.translations = make(map[string]Translations)
+		v.defaultLocale = locale
+	}
+	merged := make(Translations, len(t))
+	for rule, msg := range v.translations[locale] {
+		merged[rule] = msg
+	}
+	for rule, msg := range t {
+		merged[rule] = msg
+	}
+	v.translations[locale] = merged
+}
+
+// message renders the translated message for fe, or "" without translations.
+func (v *Validator) message(ctx context.Context, fe FieldError) string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.translations == nil {
+		return ""
+	}
+
+	t := v.translations[v.defaultLocale]
+	if locales, ok := ctx.Value(localeKey{}).(string); ok {
+		if match, ok := v.matchLocale(locales); ok {
+			t = match
+		}
+	}
+
+	tmpl, ok := t[fe.tag]
+	if !ok {
+		tmpl, ok = v.translations[v.defaultLocale][fe.tag]
+		if !ok {
+			return ""
+		}
+	}
+	return strings.NewReplacer("{field}", fe.field, "{param}", fe.param).Replace(tmpl)
+}
+
+// matchLocale returns the translations for the first locale in an
+// Accept-Language style list that has any, ignoring quality values.
+func (v *Validator) matchLocale(locales string) (Translations, bool) {
+	for tag := range strings.SplitSeq(locales, ",") {
+		tag, _, _ = strings.Cut(tag, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if t, ok := v.translations[tag]; ok {
+			return t, true
+		}
+		if base, _, ok := strings.Cut(tag, "-"); ok {
+			if t, ok := v.translations[base]; ok {
+				return t, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// JSONFieldName names fields by their json tag, falling back to the Go name,
+// so errors refer to fields the way clients send them.
+func JSONFieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return f.Name
+	}
+	return name
+}
@@ -0,0 +1,352 @@
+// Package validate checks structs against `validate` struct tags. Its errors
+// have the Field/Tag shape apierr.MapError turns into 422 responses.
+package validate
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Rule reports whether v satisfies a rule. param is the text after "=" in the
+// tag, e.g. "3" for min=3.
+type Rule func(ctx context.Context, v reflect.Value, param string) bool
+
+// FieldError describes one failed rule.
+type FieldError struct {
+	field     string
+	namespace string
+	tag       string
+	param     string
+	value     any
+	message   string
+	help      Help
+}
+
+// Field returns the name of the failing field.
+func (e FieldError) Field() string { return e.field }
+
+// Namespace returns the dotted path to the failing field from the validated
+// struct, e.g. "Address.City" or "Items[2].SKU".
+func (e FieldError) Namespace() string { return e.namespace }
+
+// Tag returns the failed rule, e.g. "required".
+func (e FieldError) Tag() string { return e.tag }
+
+// Param returns the rule parameter, e.g. "3" for min=3.
+func (e FieldError) Param() string { return e.param }
+
+// Value returns the offending value.
+func (e FieldError) Value() any { return e.value }
+
+// Message returns the translated message, or "" when the Validator has no
+// translations. apierr.MapError uses it in place of the tag when set.
+func (e FieldError) Message() string { return e.message }
+
+// DocURL returns the documentation link registered for this failure, if any.
+func (e FieldError) DocURL() string { return e.help.DocURL }
+
+// Hint returns the remediation hint registered for this failure, if any.
+func (e FieldError) Hint() string { return e.help.Hint }
+
+func (e FieldError) Error() string {
+	if e.message != "" {
+		return e.message
+	}
+	if e.param != "" {
+		return fmt.Sprintf("%s failed on %s=%s", e.namespace, e.tag, e.param)
+	}
+	return fmt.Sprintf("%s failed on %s", e.namespace, e.tag)
+}
+
+// Errors lists every failed rule of a validation.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Options configures a Validator.
+type Options struct {
+	// TagName is the struct tag holding rules. Defaults to "validate".
+	TagName string
+	// FieldName names fields in errors. Defaults to the Go field name.
+	FieldName func(f reflect.StructField) string
+}
+
+// Validator validates structs. It is safe for concurrent use once rules are registered.
+type Validator struct {
+	tagName   string
+	fieldName func(reflect.StructField) string
+
+	mu            sync.RWMutex
+	rules         map[string]Rule
+	async         map[string]AsyncRule
+	translations  map[string]Translations
+	defaultLocale string
+	help          map[string]Help
+	// checked caches the tag check of each struct type and scenario.
+	checked map[checkKey]error
+	// numeric lists the built-in rules taking a numeric parameter that have
+	// not been replaced by RegisterRule.
+	numeric map[string]bool
+}
+
+// New creates a Validator with the built-in rules.
+func New(opts Options) *Validator {
+	if opts.TagName == "" {
+		opts.TagName = "validate"
+	}
+	if opts.FieldName == nil {
+		opts.FieldName = func(f reflect.StructField) string { return f.Name }
+	}
+	v := &Validator{tagName: opts.TagName, fieldName: opts.FieldName, rules: make(map[string]Rule), numeric: make(map[string]bool)}
+	for name, rule := range builtinRules {
+		v.rules[name] = rule
+	}
+	for _, name := range numericRules {
+		v.numeric[name] = true
+	}
+	return v
+}
+
+// RegisterRule adds or replaces the rule called name.
+func (v *Validator) RegisterRule(name string, rule Rule) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rules[name] = rule
+	delete(v.numeric, name)
+	v.checked = nil
+}
+
+func (v *Validator) rule(name string) (Rule, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	r, ok := v.rules[name]
+	return r, ok
+}
+
+// Struct validates s, a struct or pointer to struct, returning Errors when
+// any rule fails. Tags naming an unknown rule are an error, see Check.
+func (v *Validator) Struct(s any) error {
+	return v.StructCtx(context.Background(), s)
+}
+
+// StructCtx is Struct with a context passed to every rule.
+func (v *Validator) StructCtx(ctx context.Context, s any) error {
+	rv := reflect.ValueOf(s)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return fmt.Errorf("validate: nil %T", s)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("validate: expected struct, got %T", s)
+	}
+	if err := v.checkType(ctx, rv.Type()); err != nil {
+		return err
+	}
+
+	w := &walk{}
+	v.validateStruct(ctx, rv, "", w)
+	if w.err != nil {
+		return w.err
+	}
+	if err := v.runAsync(ctx, w); err != nil {
+		return err
+	}
+	if len(w.errs) > 0 {
+		return w.errs
+	}
+	return nil
+}
+
+// walk accumulates the results of one validation.
+type walk struct {
+	errs Errors
+	// err is an unknown rule met in a value whose type Check could not see,
+	// such as a struct stored in an interface field.
+	err error
+	// pending are async checks of fields whose static rules all passed.
+	pending []pendingCheck
+}
+
+func (v *Validator) validateStruct(ctx context.Context, rv reflect.Value, prefix string, w *walk) {
+	t := rv.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get(v.tagName)
+		if scenario, ok := ctx.Value(scenarioKey{}).(string); ok {
+			if scoped, ok := f.Tag.Lookup(v.tagName + "_" + scenario); ok {
+				tag = scoped
+			}
+		}
+		if tag == "-" {
+			continue
+		}
+
+		name := v.fieldName(f)
+		namespace := name
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			namespace = prefix
+		} else if prefix != "" {
+			namespace = prefix + "." + name
+		}
+		v.validateField(ctx, rv.Field(i), name, namespace, tag, w)
+	}
+}
+
+// validateField applies the comma-separated rules in tag to fv, then descends
+// into nested structs.
+func (v *Validator) validateField(ctx context.Context, fv reflect.Value, name, namespace, tag string, w *walk) {
+	var pending []pendingCheck
+	rules := splitRules(tag)
+	for i, r := range rules {
+		tagName, param, _ := strings.Cut(r, "=")
+		switch tagName {
+		case "":
+			continue
+		case "omitempty":
+			if fv.IsZero() {
+				return
+			}
+			continue
+		case "dive":
+			v.dive(ctx, fv, name, namespace, strings.Join(rules[i+1:], ","), w)
+			return
+		}
+
+		fe := FieldError{field: name, namespace: namespace, tag: tagName, param: param, value: interfaceOf(fv)}
+		if async, ok := v.asyncRule(tagName); ok {
+			pending = append(pending, pendingCheck{fe: fe, value: fv, rule: async})
+			continue
+		}
+
+		if err := v.checkRule(tagName, param, namespace); err != nil {
+			if w.err == nil {
+				w.err = err
+			}
+			return
+		}
+		rule, _ := v.rule(tagName)
+		if !rule(ctx, fv, param) {
+			w.errs = append(w.errs, v.finishError(ctx, fe))
+			return
+		}
+	}
+
+	w.pending = append(w.pending, pending...)
+	v.descend(ctx, fv, namespace, w)
+}
+
+// finishError attaches the translated message and help to fe.
+func (v *Validator) finishError(ctx context.Context, fe FieldError) FieldError {
+	fe.message = v.message(ctx, fe)
+	fe.help = v.lookupHelp(fe)
+	return fe
+}
+
+// dive applies elemTag to each element of a slice, array or map.
+func (v *Validator) dive(ctx context.Context, fv reflect.Value, name, namespace, elemTag string, w *walk) {
+	fv = indirect(fv)
+	switch fv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := range fv.Len() {
+			v.validateField(ctx, fv.Index(i), name, fmt.Sprintf("%s[%d]", namespace, i), elemTag, w)
+		}
+	case reflect.Map:
+		iter := fv.MapRange()
+		for iter.Next() {
+			v.validateField(ctx, iter.Value(), name, fmt.Sprintf("%s[%v]", namespace, iter.Key()), elemTag, w)
+		}
+	}
+}
+
+// descend validates nested structs, including those inside slices and maps.
+func (v *Validator) descend(ctx context.Context, fv reflect.Value, namespace string, w *walk) {
+	fv = indirect(fv)
+	switch fv.Kind() {
+	case reflect.Struct:
+		if fv.Type().PkgPath() == "time" {
+			return
+		}
+		v.validateStruct(ctx, fv, namespace, w)
+	case reflect.Slice, reflect.Array:
+		if k := indirectType(fv.Type().Elem()).Kind(); k == reflect.Struct {
+			for i := range fv.Len() {
+				v.descend(ctx, fv.Index(i), fmt.Sprintf("%s[%d]", namespace, i), w)
+			}
+		}
+	}
+}
+
+// splitRules splits a tag into its comma-separated rules.
+func splitRules(tag string) []string {
+	if tag == "" {
+		return nil
+	}
+	return strings.Split(tag, ",")
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func interfaceOf(v reflect.Value) any {
+	if v.IsValid() && v.CanInterface() {
+		return v.Interface()
+	}
+	return nil
+}
+
+type scenarioKey struct{}
+
+// WithScenario returns a context whose validations use the rules for
+// scenario, e.g. "create" or "update", so one struct serves every operation.
+// Fields tagged `validate_<scenario>` use that tag instead of `validate`:
+//
+//	type UserInput struct {
+//		Email string `validate:"required,email" validate_update:"omitempty,email"`
+//		Role  string `validate:"-" validate_create:"required,oneof=admin member"`
+//	}
+//
+// Fields without a scenario tag keep their `validate` rules.
+func WithScenario(ctx context.Context, scenario string) context.Context {
+	return context.WithValue(ctx, scenarioKey{}, scenario)
+}
+
+// Default is the Validator used by the package-level functions and by bind.
+var Default = New(Options{})
+
+// Struct validates s with the Default Validator.
+func Struct(s any) error {
+	return Default.Struct(s)
+}
+
+// StructCtx validates s with the Default Validator.
+func StructCtx(ctx context.Context, s any) error {
+	return Default.StructCtx(ctx, s)
+}
@@ -0,0 +1,130 @@
+// Package view renders html/template pages from an fs.FS, with a development
+// mode that picks up template edits without restarting the process.
+package view
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// Options configures Templates.
+type Options struct {
+	// FS holds the templates, e.g. an embed.FS in production or os.DirFS of
+	// the source directory in development.
+	FS fs.FS
+	// Patterns are the fs.Glob patterns of the template files. Defaults to "*.html".
+	Patterns []string
+	// Funcs are made available to every template.
+	Funcs template.FuncMap
+	// Dev re-parses the templates before rendering whenever a file was added,
+	// removed or modified, so edits show up on the next request.
+	Dev bool
+}
+
+// Templates renders a parsed set of templates.
+type Templates struct {
+	opts Options
+
+	mu          sync.Mutex
+	tmpl        *template.Template
+	fingerprint string
+}
+
+// New parses the templates matching opts.Patterns.
+func New(opts Options) (*Templates, error) {
+	if len(opts.Patterns) == 0 {
+		opts.Patterns = []string{"*.html"}
+	}
+	t := &Templates{opts: opts}
+	if err := t.reload(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Render executes the template called name with data and writes the result
+// as text/html with status. Nothing is written when execution fails, so the
+// error can be returned from a middleware.APIFunc as is.
+func (t *Templates) Render(w http.ResponseWriter, status int, name string, data any) error {
+	tmpl, err := t.current()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return fmt.Errorf("view: %w", err)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(status)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// current returns the parsed templates, re-parsing them first in Dev mode
+// when the files changed.
+func (t *Templates) current() (*template.Template, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.opts.Dev {
+		if err := t.reloadLocked(); err != nil {
+			return nil, err
+		}
+	}
+	return t.tmpl, nil
+}
+
+func (t *Templates) reload() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.reloadLocked()
+}
+
+// reloadLocked parses the template files unless their fingerprint is unchanged.
+func (t *Templates) reloadLocked() error {
+	files, fingerprint, err := t.files()
+	if err != nil {
+		return err
+	}
+	if t.tmpl != nil && fingerprint == t.fingerprint {
+		return nil
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("view: no templates match %q", t.opts.Patterns)
+	}
+
+	tmpl, err := template.New("").Funcs(t.opts.Funcs).ParseFS(t.opts.FS, files...)
+	if err != nil {
+		return fmt.Errorf("view: %w", err)
+	}
+	t.tmpl, t.fingerprint = tmpl, fingerprint
+	return nil
+}
+
+// files lists the template files with a fingerprint of their names, sizes
+// and modification times.
+func (t *Templates) files() ([]string, string, error) {
+	var files []string
+	var fingerprint bytes.Buffer
+	for _, pattern := range t.opts.Patterns {
+		matches, err := fs.Glob(t.opts.FS, pattern)
+		if err != nil {
+			return nil, "", fmt.Errorf("view: %w", err)
+		}
+		for _, name := range matches {
+			info, err := fs.Stat(t.opts.FS, name)
+			if err != nil {
+				return nil, "", fmt.Errorf("view: %w", err)
+			}
+			files = append(files, name)
+			fmt.Fprintf(&fingerprint, "%s:%d:%d;", name, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return files, fingerprint.String(), nil
+}